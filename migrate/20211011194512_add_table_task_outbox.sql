@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE task_outbox (
+  seq BIGINT UNSIGNED NOT NULL AUTO_INCREMENT PRIMARY KEY,
+  id BINARY(16) NOT NULL,
+  event_type varchar(64) NOT NULL,
+  task_id BINARY(16) NOT NULL,
+  payload JSON,
+  attempts INT NOT NULL DEFAULT 0,
+  last_error varchar(255),
+  created_at TIMESTAMP NOT NULL,
+  published_at TIMESTAMP NULL,
+  UNIQUE INDEX outboxIdIndex (id),
+  INDEX outboxPendingIndex (published_at, seq)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE task_outbox;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE task_attachment (
+  id BINARY(16) NOT NULL PRIMARY KEY,
+  task_id BINARY(16) NOT NULL,
+  name varchar(255) NOT NULL,
+  content_type varchar(127) NOT NULL,
+  size BIGINT NOT NULL,
+  storage_key varchar(255) NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  INDEX attachmentTaskIndex (task_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE task_attachment;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE task_attachment
+ADD INDEX attachmentStorageKeyIndex (storage_key);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE task_attachment
+DROP INDEX attachmentStorageKeyIndex;
+-- +goose StatementEnd
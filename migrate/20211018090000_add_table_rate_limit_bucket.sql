@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE rate_limit_bucket (
+  bucket_key BINARY(32) NOT NULL PRIMARY KEY,
+  tokens DOUBLE NOT NULL,
+  updated_at DATETIME(6) NOT NULL,
+  INDEX rateLimitUpdatedIndex (updated_at)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE rate_limit_bucket;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE task
+ADD COLUMN user_id varchar(255) NOT NULL DEFAULT '',
+ADD INDEX userIndex (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE task
+DROP INDEX userIndex,
+DROP COLUMN user_id;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE idempotency_key (
+  scope_key BINARY(32) NOT NULL PRIMARY KEY,
+  request_hash char(64) NOT NULL,
+  status_code INT NOT NULL DEFAULT 0,
+  content_type varchar(255) NOT NULL DEFAULT '',
+  body MEDIUMBLOB,
+  created_at DATETIME(6) NOT NULL,
+  expires_at DATETIME(6) NOT NULL,
+  INDEX idempotencyExpiresIndex (expires_at)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE idempotency_key;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE task_outbox
+ADD INDEX outboxTaskIndex (task_id, seq);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE task_outbox
+DROP INDEX outboxTaskIndex;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE task_change_seq (
+  id TINYINT UNSIGNED NOT NULL PRIMARY KEY,
+  seq BIGINT UNSIGNED NOT NULL
+);
+-- +goose StatementEnd
+-- +goose StatementBegin
+INSERT INTO task_change_seq (id, seq) SELECT 1, COALESCE(MAX(seq), 0) FROM task_outbox;
+-- +goose StatementEnd
+-- +goose StatementBegin
+ALTER TABLE task_outbox
+ADD COLUMN change_seq BIGINT UNSIGNED NULL,
+ADD UNIQUE INDEX outboxChangeIndex (change_seq),
+ADD INDEX outboxTaskChangeIndex (task_id, change_seq);
+-- +goose StatementEnd
+-- +goose StatementBegin
+UPDATE task_outbox SET change_seq = seq;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE task_outbox
+DROP INDEX outboxTaskChangeIndex,
+DROP INDEX outboxChangeIndex,
+DROP COLUMN change_seq;
+-- +goose StatementEnd
+-- +goose StatementBegin
+DROP TABLE task_change_seq;
+-- +goose StatementEnd
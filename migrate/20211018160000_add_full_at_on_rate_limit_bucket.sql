@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE rate_limit_bucket
+ADD COLUMN full_at DATETIME(6) NULL;
+-- +goose StatementEnd
+-- +goose StatementBegin
+UPDATE rate_limit_bucket SET full_at = DATE_ADD(updated_at, INTERVAL 1 DAY);
+-- +goose StatementEnd
+-- +goose StatementBegin
+ALTER TABLE rate_limit_bucket
+MODIFY COLUMN full_at DATETIME(6) NOT NULL,
+ADD INDEX rateLimitFullIndex (full_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE rate_limit_bucket
+DROP INDEX rateLimitFullIndex,
+DROP COLUMN full_at;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- Changes of tasks deleted before this migration belong to no user.
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE task_outbox
+ADD COLUMN user_id varchar(255) NOT NULL DEFAULT '',
+ADD INDEX outboxUserChangeIndex (user_id, change_seq);
+-- +goose StatementEnd
+-- +goose StatementBegin
+UPDATE task_outbox o JOIN task t ON t.id = o.task_id SET o.user_id = t.user_id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE task_outbox
+DROP INDEX outboxUserChangeIndex,
+DROP COLUMN user_id;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- Tasks saved before the outbox existed get one change each, numbered
+-- after the others, so a full sync returns them. They are marked published,
+-- since consumers never needed them as events.
+-- +goose Up
+-- +goose StatementBegin
+INSERT INTO task_outbox (id, event_type, task_id, payload, created_at, published_at, user_id)
+SELECT UUID_TO_BIN(UUID()), 'task.created', t.id,
+  JSON_OBJECT(
+    'id', BIN_TO_UUID(t.id),
+    'title', t.title,
+    'description', t.description,
+    'created_at', DATE_FORMAT(CONVERT_TZ(t.created_at, @@session.time_zone, '+00:00'), '%Y-%m-%dT%H:%i:%sZ'),
+    'updated_at', DATE_FORMAT(CONVERT_TZ(t.updated_at, @@session.time_zone, '+00:00'), '%Y-%m-%dT%H:%i:%sZ'),
+    'completed_at', DATE_FORMAT(CONVERT_TZ(t.completed_at, @@session.time_zone, '+00:00'), '%Y-%m-%dT%H:%i:%sZ')),
+  t.updated_at, NOW(), t.user_id
+FROM task t
+WHERE NOT EXISTS (SELECT 1 FROM task_outbox o WHERE o.task_id = t.id);
+-- +goose StatementEnd
+-- +goose StatementBegin
+UPDATE task_outbox o JOIN task_change_seq c ON c.id = 1
+SET o.change_seq = c.seq + o.seq
+WHERE o.change_seq IS NULL;
+-- +goose StatementEnd
+-- +goose StatementBegin
+UPDATE task_change_seq
+SET seq = GREATEST(seq, (SELECT COALESCE(MAX(change_seq), 0) FROM task_outbox))
+WHERE id = 1;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 1;
+-- +goose StatementEnd
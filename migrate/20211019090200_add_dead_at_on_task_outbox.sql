@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE task_outbox
+ADD COLUMN dead_at TIMESTAMP NULL,
+DROP INDEX outboxPendingIndex,
+ADD INDEX outboxPendingIndex (published_at, dead_at, seq);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE task_outbox
+DROP INDEX outboxPendingIndex,
+DROP COLUMN dead_at,
+ADD INDEX outboxPendingIndex (published_at, seq);
+-- +goose StatementEnd
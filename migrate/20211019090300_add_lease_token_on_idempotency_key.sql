@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE idempotency_key
+ADD COLUMN lease_token char(36) NOT NULL DEFAULT '' AFTER request_hash;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE idempotency_key
+DROP COLUMN lease_token;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+// Package migrate ships the goose migrations with the binary so the
+// service can tell whether the database schema is up to date.
+package migrate
+
+import (
+	"embed"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.sql
+var Files embed.FS
+
+// Latest is the version of the newest migration, the numeric prefix of its
+// file name.
+func Latest() (int64, error) {
+	names, err := fs.Glob(Files, "*.sql")
+	if err != nil {
+		return 0, err
+	}
+	var latest int64
+	for _, name := range names {
+		version, err := strconv.ParseInt(strings.SplitN(name, "_", 2)[0], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		if version > latest {
+			latest = version
+		}
+	}
+	return latest, nil
+}
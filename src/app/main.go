@@ -1,62 +1,419 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	stdlog "log"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/go-redis/redis/v8"
 	_ "github.com/go-sql-driver/mysql"
+	"github.com/gorilla/mux"
+	"github.com/isaias-dgr/todo/migrate"
+	"github.com/isaias-dgr/todo/src/app/router"
+	"github.com/isaias-dgr/todo/src/config"
 	"github.com/isaias-dgr/todo/src/domain"
-	"github.com/isaias-dgr/todo/src/task/deliver/http"
+	"github.com/isaias-dgr/todo/src/health"
+	"github.com/isaias-dgr/todo/src/httpx"
+	"github.com/isaias-dgr/todo/src/idempotency"
+	"github.com/isaias-dgr/todo/src/logging"
+	"github.com/isaias-dgr/todo/src/metrics"
+	"github.com/isaias-dgr/todo/src/ratelimit"
+	"github.com/isaias-dgr/todo/src/task/broker"
+	_TaskGrpc "github.com/isaias-dgr/todo/src/task/deliver/grpc"
+	_TaskHttp "github.com/isaias-dgr/todo/src/task/deliver/http"
+	"github.com/isaias-dgr/todo/src/task/publisher"
+	"github.com/isaias-dgr/todo/src/task/relay"
+	_TaskCache "github.com/isaias-dgr/todo/src/task/repository/cache"
+	_TaskMemory "github.com/isaias-dgr/todo/src/task/repository/memory"
 	_TaskRepo "github.com/isaias-dgr/todo/src/task/repository/mysql"
+	"github.com/isaias-dgr/todo/src/task/storage"
 	useCase "github.com/isaias-dgr/todo/src/task/usecase"
+	"github.com/isaias-dgr/todo/src/tracing"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 )
 
-func SetUpLog() *zap.SugaredLogger {
-	logger, _ := zap.NewProduction()
+func SetUpLog(cfg *config.Config) *zap.SugaredLogger {
+	zapConfig := zap.NewProductionConfig()
+	zapConfig.Level = zap.NewAtomicLevelAt(cfg.LogLevel())
+	// Request lines share one message; the request sampling decides which
+	// to keep instead.
+	zapConfig.Sampling = nil
+	logger, err := zapConfig.Build()
+	if err != nil {
+		stdlog.Fatal(err)
+	}
 	defer logger.Sync()
+	zap.ReplaceGlobals(logger)
 	return logger.Sugar()
 }
 
-func SetUpRepository(logger *zap.SugaredLogger) (*sql.DB, domain.TaskRepository) {
+func SetUpTracing(cfg *config.Config, logger *zap.SugaredLogger) func(context.Context) error {
+	logger.Infof("🔭 Export traces to %s.", cfg.Tracing.Exporter)
+	shutdown, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		SampleRatio: cfg.Tracing.SampleRatio,
+		Service:     cfg.Project.Name,
+		Version:     health.Version,
+		Env:         cfg.Project.Env,
+	})
+	if err != nil {
+		logger.Fatal(err)
+	}
+	return shutdown
+}
+
+// Repositories keep the state of the service in MySQL or, with the memory
+// store, in this process only. DB is nil for the memory store.
+type Repositories struct {
+	DB          *sql.DB
+	Tasks       domain.TaskRepository
+	Attachments domain.AttachmentRepository
+	Outbox      domain.OutboxRepository
+	Sync        domain.SyncRepository
+	Idempotency domain.IdempotencyStore
+}
+
+func SetUpRepository(cfg *config.Config, logger *zap.SugaredLogger, m *metrics.Metrics) *Repositories {
+	if cfg.Repository.Store == "memory" {
+		logger.Info("💾 Keep tasks in memory.")
+		db := _TaskMemory.NewDB(time.Now)
+		return &Repositories{
+			Tasks:       _TaskMemory.NewTaskRepository(db),
+			Attachments: _TaskMemory.NewAttachmentRepository(db),
+			Outbox:      _TaskMemory.NewOutboxRepository(db),
+			Sync:        _TaskMemory.NewSyncRepository(db),
+			Idempotency: _TaskMemory.NewIdempotencyRepository(db),
+		}
+	}
 	logger.Info("💾 Set up Database.")
-	connection := fmt.Sprintf(
-		"%s:%s@tcp(%s:%s)/%s?parseTime=true",
-		os.Getenv("MYSQL_USER"),
-		os.Getenv("MYSQL_PASSWORD"),
-		os.Getenv("MYSQL_HOST"),
-		os.Getenv("MYSQL_PORT"),
-		os.Getenv("MYSQL_DATABASE"),
-	)
-	logger.Debug(connection)
-	dbConn, err := sql.Open(`mysql`, connection)
+	dbConn, err := sql.Open(`mysql`, cfg.MySQL.DSN())
 	if err != nil {
-		logger.Info(err)
+		logger.Fatal(err)
+	}
+	dbConn.SetMaxOpenConns(cfg.MySQL.MaxOpenConns)
+	dbConn.SetMaxIdleConns(cfg.MySQL.MaxIdleConns)
+	dbConn.SetConnMaxLifetime(cfg.MySQL.ConnMaxLifetime)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.MySQL.ConnectTimeout)
+	defer cancel()
+	if err := dbConn.PingContext(ctx); err != nil {
+		logger.Fatal(err)
+	}
+	m.RegisterDB(dbConn, cfg.MySQL.Database)
+	return &Repositories{
+		DB:          dbConn,
+		Tasks:       _TaskRepo.NewtaskRepository(dbConn, logger, m.QueryObserver("task")),
+		Attachments: _TaskRepo.NewAttachmentRepository(dbConn, logger),
+		Outbox:      _TaskRepo.NewOutboxRepository(dbConn, logger),
+		Sync:        _TaskRepo.NewSyncRepository(dbConn, logger),
+		Idempotency: _TaskRepo.NewIdempotencyRepository(dbConn, logger),
+	}
+}
+
+// SetUpCache wraps repo in the cache store of the config. The returned
+// func closes the connection to Redis.
+func SetUpCache(cfg *config.Config, logger *zap.SugaredLogger, repo domain.TaskRepository) (domain.TaskRepository, func()) {
+	switch cfg.Cache.Store {
+	case "redis":
+		logger.Info("⚡ Cache tasks in Redis.")
+		client := redis.NewClient(&redis.Options{Addr: cfg.Cache.RedisAddr})
+		store := _TaskCache.NewRedis(client, cfg.Project.Name+":")
+		return _TaskCache.NewTaskRepository(repo, store, cfg.Cache.TTL, logger), func() {
+			if err := client.Close(); err != nil {
+				logger.Error(err)
+			}
+		}
+	case "memory":
+		logger.Info("⚡ Cache tasks in memory.")
+		store := _TaskCache.NewLRU(cfg.Cache.Size, time.Now)
+		return _TaskCache.NewTaskRepository(repo, store, cfg.Cache.TTL, logger), func() {}
+	default:
+		logger.Info("⚡ Cache off.")
+		return repo, func() {}
+	}
+}
+
+func SetUpPublisher(cfg *config.Config, logger *zap.SugaredLogger) domain.Publisher {
+	switch cfg.Events.Publisher {
+	case "sqs":
+		logger.Info("📣 Publish events to SQS.")
+		pub, err := publisher.NewSQSPublisher(publisher.SQSConfig{
+			QueueURL: cfg.Events.SQSQueueURL,
+			Region:   cfg.AWS.Region,
+			Endpoint: cfg.Events.SQSEndpoint,
+		}, logger)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		return pub
+	case "webhook":
+		logger.Info("📣 Publish events to webhook.")
+		return publisher.NewWebhookPublisher(cfg.Events.WebhookURL, nil, logger)
+	default:
+		logger.Info("📣 Publish events to log.")
+		return publisher.NewLogPublisher(logger)
+	}
+}
+
+func SetUpBlobStore(cfg *config.Config, logger *zap.SugaredLogger) domain.BlobStore {
+	switch cfg.Blob.Store {
+	case "s3":
+		logger.Info("🗄️ Store files in S3.")
+		st, err := storage.NewS3BlobStore(storage.S3Config{
+			Bucket:   cfg.Blob.S3Bucket,
+			Region:   cfg.AWS.Region,
+			Endpoint: cfg.Blob.S3Endpoint,
+		}, logger)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		return st
+	default:
+		logger.Info("🗄️ Store files on local disk.")
+		st, err := storage.NewLocalBlobStore(cfg.Blob.Dir, logger)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		return st
 	}
-	err = dbConn.Ping()
+}
+
+func SetUpRateLimit(cfg *config.Config, logger *zap.SugaredLogger, dbConn *sql.DB) *ratelimit.Limiter {
+	rules, err := ratelimit.ParseRules(cfg.RateLimit.Rules)
 	if err != nil {
-		logger.Info(err)
+		logger.Fatal(err)
+	}
+	switch cfg.RateLimit.Store {
+	case "mysql":
+		logger.Info("🚦 Rate limit with MySQL buckets.")
+		return ratelimit.NewLimiter(_TaskRepo.NewRateLimitRepository(dbConn, logger), rules, logger)
+	case "memory":
+		logger.Info("🚦 Rate limit with in-memory buckets.")
+		return ratelimit.NewLimiter(ratelimit.NewMemoryStore(time.Now), rules, logger)
+	default:
+		logger.Info("🚦 Rate limit off.")
+		return nil
 	}
-	return dbConn, _TaskRepo.NewtaskRepository(dbConn, logger)
+}
+
+func SetUpGrpc(cfg *config.Config, logger *zap.SugaredLogger, taskUseCase domain.TaskUseCase, taskBroker domain.Broker) *grpc.Server {
+	logger.Infof("📡 gRPC on %s.", cfg.GRPC.Addr)
+	lis, err := net.Listen("tcp", cfg.GRPC.Addr)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(_TaskGrpc.UserUnaryInterceptor),
+		grpc.StreamInterceptor(_TaskGrpc.UserStreamInterceptor))
+	_TaskGrpc.NewTaskServer(srv, taskUseCase, taskBroker, logger)
+	go func() {
+		if err := srv.Serve(lis); err != nil {
+			logger.Error(err)
+		}
+	}()
+	return srv
+}
+
+func SetUpRouter(
+	cfg *config.Config,
+	logger *zap.SugaredLogger,
+	taskUseCase domain.TaskUseCase,
+	attachmentUseCase domain.AttachmentUseCase,
+	attachmentPolicy *domain.AttachmentPolicy,
+	syncUseCase domain.SyncUseCase,
+	taskBroker domain.Broker,
+	hub *_TaskHttp.Hub,
+	checker *health.Checker,
+	m *metrics.Metrics,
+	limiter *ratelimit.Limiter,
+	keeper *idempotency.Keeper,
+) *mux.Router {
+	r := mux.NewRouter()
+	r.Use(tracing.Middleware)
+	r.Use(m.Middleware)
+	r.Use(_TaskHttp.UserMiddleware)
+	r.Use(logging.Middleware(logger, logging.Sampling{
+		Ratio: cfg.Log.RequestSampleRatio,
+		Slow:  cfg.Log.SlowRequest,
+	}))
+	if limiter != nil {
+		r.Use(limiter.Middleware)
+	}
+	r.Use(keeper.Middleware)
+	router.Mount(r, logger, taskUseCase, attachmentUseCase, attachmentPolicy, syncUseCase, taskBroker, hub, checker, m)
+	return r
+}
+
+// SetUpHttp leaves the read and write timeouts of the server off, since
+// they would cut the streams; Deadlines applies them to the other routes.
+func SetUpHttp(cfg *config.Config, r *mux.Router) *http.Server {
+	deadlines := httpx.Deadlines(cfg.HTTP.ReadTimeout, cfg.HTTP.WriteTimeout,
+		_TaskHttp.StreamPath, _TaskHttp.WebSocketPath)
+	return &http.Server{
+		Addr:              cfg.HTTP.Addr,
+		Handler:           deadlines(r),
+		ConnContext:       httpx.ConnContext,
+		ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+		IdleTimeout:       cfg.HTTP.IdleTimeout,
+	}
+}
+
+func SetUpHealth(cfg *config.Config, logger *zap.SugaredLogger, dbConn *sql.DB, outbox *relay.Relay) *health.Checker {
+	checker := health.NewChecker(cfg.Health.Timeout)
+	if dbConn != nil {
+		latest, err := migrate.Latest()
+		if err != nil {
+			logger.Fatal(err)
+		}
+		checker.Add("database", dbConn.PingContext)
+		checker.Add("migrations", health.Migrations(func(ctx context.Context) (int64, error) {
+			return _TaskRepo.SchemaVersion(ctx, dbConn)
+		}, latest))
+	}
+	checker.Add("relay", outbox.Check)
+	return checker
+}
+
+// Stop waits for the servers to finish the requests in flight, then stops
+// the workers that feed them and closes the cache and the database last,
+// since all of them use both. The spans still buffered are flushed at the
+// end.
+func Stop(cfg *config.Config, logger *zap.SugaredLogger, checker *health.Checker, srv *http.Server, grpcSrv *grpc.Server, stopRelay func(), closeCache func(), dbConn *sql.DB, stopTracing func(context.Context) error) {
+	checker.Shutdown()
+	logger.Infof("🚰 Draining for %s.", cfg.HTTP.DrainPeriod)
+	time.Sleep(cfg.HTTP.DrainPeriod)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+	defer cancel()
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if err := srv.Shutdown(ctx); err != nil {
+			logger.Error(err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		stopped := make(chan struct{})
+		go func() {
+			grpcSrv.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			grpcSrv.Stop()
+		}
+	}()
+	wg.Wait()
+
+	stopRelay()
+	closeCache()
+	if dbConn != nil {
+		if err := dbConn.Close(); err != nil {
+			logger.Error(err)
+		}
+	}
+	if err := stopTracing(ctx); err != nil {
+		logger.Error(err)
+	}
+	logger.Info("👋 Bye.")
 }
 
 func main() {
-	log := SetUpLog()
+	cfg, err := config.Load(os.Args[1:], os.Getenv)
+	if err != nil {
+		stdlog.Fatal(err)
+	}
+	cfg.Print(os.Stdout)
+	log := SetUpLog(cfg)
+	defer log.Sync()
 	msg := fmt.Sprintf(
-		"🤓 SetUp %s_%s:%s..",
-		os.Getenv("PROJ_NAME"),
-		os.Getenv("PROJ_ENV"),
-		"8080")
+		"🤓 SetUp %s_%s%s..",
+		cfg.Project.Name,
+		cfg.Project.Env,
+		cfg.HTTP.Addr)
 	log.Info(msg)
-	log.Info("🚀 API V1.")
-	dbConn, task_repo := SetUpRepository(log)
-	defer func() {
-		err := dbConn.Close()
-		if err != nil {
-			log.Error(err)
+	log.Infow("🚀 API V1.", "version", health.Version, "commit", health.Commit)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	stopTracing := SetUpTracing(cfg, log)
+	m := metrics.New()
+	repos := SetUpRepository(cfg, log, m)
+	m.RegisterTaskCount(repos.Tasks, cfg.Health.Timeout)
+	task_repo, closeCache := SetUpCache(cfg, log, repos.Tasks)
+	outbox := relay.NewRelay(
+		repos.Outbox,
+		SetUpPublisher(cfg, log),
+		log,
+		time.Second)
+	relayCtx, cancelRelay := context.WithCancel(context.Background())
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		outbox.Run(relayCtx)
+	}()
+	stopRelay := func() {
+		cancelRelay()
+		<-relayDone
+	}
+
+	attachmentPolicy := domain.NewAttachmentPolicy(10<<20,
+		"image/png", "image/jpeg", "image/gif", "application/pdf", "text/plain")
+	attachmentUseCase := useCase.NewAttachmentUseCase(
+		repos.Attachments,
+		task_repo,
+		SetUpBlobStore(cfg, log),
+		attachmentPolicy)
+	taskBroker := broker.NewMemoryBroker(1000, 64, log)
+	taskUseCase := metrics.NewTaskUseCase(tracing.NewTaskUseCase(
+		useCase.NewTaskUseCase(task_repo, attachmentUseCase, taskBroker, cfg.Quota.MaxTasksPerUser)), m)
+	syncUseCase := useCase.NewSyncUseCase(repos.Sync, taskUseCase, cfg.Sync.Policy)
+
+	grpcSrv := SetUpGrpc(cfg, log, taskUseCase, taskBroker)
+
+	checker := SetUpHealth(cfg, log, repos.DB, outbox)
+	hub := _TaskHttp.NewHub()
+	limiter := SetUpRateLimit(cfg, log, repos.DB)
+	keeper := idempotency.NewKeeper(
+		repos.Idempotency,
+		cfg.Idempotency.TTL,
+		cfg.Idempotency.Lease,
+		attachmentPolicy.MaxSize+1<<20,
+		log)
+	go keeper.Purge(ctx, time.Hour)
+	if limiter != nil {
+		go limiter.Purge(ctx, time.Minute)
+	}
+	srv := SetUpHttp(cfg, SetUpRouter(cfg, log, taskUseCase, attachmentUseCase, attachmentPolicy, syncUseCase, taskBroker, hub, checker, m, limiter, keeper))
+	// Streams never finish on their own; end them when the drain is over.
+	srv.RegisterOnShutdown(hub.Close)
+	srv.RegisterOnShutdown(taskBroker.Close)
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Infof("🌐 HTTP on %s.", cfg.HTTP.Addr)
+		if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+			serveErr <- err
 		}
 	}()
-	useCase := useCase.NewTaskUseCase(task_repo)
-	http.NewTaskHandler(useCase, log)
+
+	select {
+	case <-ctx.Done():
+		log.Info("🛑 Shutting down.")
+	case err := <-serveErr:
+		log.Error(err)
+	}
+	stop()
+	Stop(cfg, log, checker, srv, grpcSrv, stopRelay, closeCache, repos.DB, stopTracing)
 }
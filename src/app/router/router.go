@@ -0,0 +1,39 @@
+// Package router mounts every handler of the http api. main serves what it
+// mounts, and the openapi test checks the spec against the same routes.
+package router
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/health"
+	"github.com/isaias-dgr/todo/src/metrics"
+	_TaskGraphql "github.com/isaias-dgr/todo/src/task/deliver/graphql"
+	_TaskHttp "github.com/isaias-dgr/todo/src/task/deliver/http"
+	_TaskRpc "github.com/isaias-dgr/todo/src/task/deliver/jsonrpc"
+	_TaskDocs "github.com/isaias-dgr/todo/src/task/deliver/openapi"
+	"go.uber.org/zap"
+)
+
+func Mount(
+	r *mux.Router,
+	logger *zap.SugaredLogger,
+	taskUseCase domain.TaskUseCase,
+	attachmentUseCase domain.AttachmentUseCase,
+	attachmentPolicy *domain.AttachmentPolicy,
+	syncUseCase domain.SyncUseCase,
+	taskBroker domain.Broker,
+	hub *_TaskHttp.Hub,
+	checker *health.Checker,
+	m *metrics.Metrics,
+) {
+	_TaskHttp.NewStreamHandler(r, taskBroker, logger)
+	_TaskHttp.NewTaskHandler(r, taskUseCase, logger)
+	_TaskHttp.NewWebSocketHandler(r, taskUseCase, taskBroker, hub, logger)
+	_TaskHttp.NewAttachmentHandler(r, attachmentUseCase, attachmentPolicy.MaxSize, logger)
+	_TaskHttp.NewSyncHandler(r, syncUseCase, logger)
+	_TaskGraphql.NewGraphqlHandler(r, taskUseCase, attachmentUseCase, logger)
+	_TaskRpc.NewRpcHandler(r, taskUseCase, logger)
+	_TaskDocs.NewDocsHandler(r, logger)
+	_TaskHttp.NewHealthHandler(r, checker, logger)
+	metrics.NewMetricsHandler(r, m)
+}
@@ -0,0 +1,318 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/isaias-dgr/todo/src/domain"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+var (
+	ErrBadRequest = errors.New("bad_request")
+	ErrNotFound   = errors.New("not_found")
+	ErrConflict   = errors.New("conflict")
+)
+
+// Error is a non 2xx answer from the API, carrying the "message" of the
+// error body. It matches ErrBadRequest, ErrNotFound and ErrConflict with
+// errors.Is.
+type Error struct {
+	StatusCode int
+	Message    string
+	// Fields holds the violations of each field of a rejected task.
+	Fields map[string][]string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case domain.ErrQuotaExceeded:
+		return e.StatusCode == http.StatusForbidden && e.Message == domain.ErrQuotaExceeded.Error()
+	}
+	return false
+}
+
+// Client talks to the task routes of the HTTP API. It satisfies
+// domain.TaskUseCase so it can stand in for a local use case.
+type Client struct {
+	BaseURL string
+	UserID  string
+	Token   string
+	HTTP    *http.Client
+	Retry   Retry
+}
+
+var _ domain.TaskUseCase = (*Client)(nil)
+
+// Retry sets how failed calls are retried. Only idempotent methods are
+// retried, on network errors and on 429, 502, 503 and 504. The wait
+// doubles from Base up to Max, with jitter, unless the server sends
+// Retry-After.
+type Retry struct {
+	Attempts int
+	Base     time.Duration
+	Max      time.Duration
+}
+
+var DefaultRetry = Retry{Attempts: 3, Base: 100 * time.Millisecond, Max: 2 * time.Second}
+
+type Option func(*Client)
+
+func WithHTTPClient(h *http.Client) Option {
+	return func(c *Client) { c.HTTP = h }
+}
+
+func WithUser(userID string) Option {
+	return func(c *Client) { c.UserID = userID }
+}
+
+func WithToken(token string) Option {
+	return func(c *Client) { c.Token = token }
+}
+
+func WithRetry(r Retry) Option {
+	return func(c *Client) { c.Retry = r }
+}
+
+func NewClient(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		HTTP:    &http.Client{Timeout: 30 * time.Second},
+		Retry:   DefaultRetry,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+type envelope struct {
+	Data     json.RawMessage  `json:"data"`
+	Metadata *domain.Metadata `json:"metadata"`
+}
+
+func (c *Client) Fetch(ctx context.Context, f *domain.Filter) (*domain.Tasks, error) {
+	query := url.Values{}
+	query.Set("offset", strconv.Itoa(f.Offset))
+	query.Set("limit", strconv.Itoa(f.Limit))
+	if f.SortBy != "" {
+		query.Set("sort_by", f.SortBy)
+	}
+	var tasks []*domain.Task
+	meta, _, err := c.do(ctx, http.MethodGet, "/task/?"+query.Encode(), nil, &tasks)
+	if err != nil {
+		return nil, err
+	}
+	total := len(tasks)
+	if meta != nil {
+		total = meta.Total
+	}
+	return domain.NewTasks(tasks, total), nil
+}
+
+func (c *Client) Insert(ctx context.Context, t *domain.Task) error {
+	_, _, err := c.do(ctx, http.MethodPost, "/task/", taskBody(t), t)
+	return err
+}
+
+// Update creates the task when id is a version 4 UUID that is not taken
+// yet, since PUT creates or replaces. Use Upsert to know which happened.
+func (c *Client) Update(ctx context.Context, id string, t *domain.Task) error {
+	_, err := c.Upsert(ctx, id, t)
+	return err
+}
+
+func (c *Client) Upsert(ctx context.Context, id string, t *domain.Task) (bool, error) {
+	_, code, err := c.do(ctx, http.MethodPut, "/task/"+url.PathEscape(id)+"/", taskBody(t), t)
+	return code == http.StatusCreated, err
+}
+
+func (c *Client) GetByID(ctx context.Context, id string) (*domain.Task, error) {
+	var task domain.Task
+	if _, _, err := c.do(ctx, http.MethodGet, "/task/"+url.PathEscape(id)+"/", nil, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (c *Client) Delete(ctx context.Context, id string) error {
+	_, _, err := c.do(ctx, http.MethodDelete, "/task/"+url.PathEscape(id)+"/", nil, nil)
+	return err
+}
+
+func (c *Client) Complete(ctx context.Context, id string) (*domain.Task, error) {
+	var task domain.Task
+	if _, _, err := c.do(ctx, http.MethodPost, "/task/"+url.PathEscape(id)+"/complete/", nil, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func taskBody(t *domain.Task) interface{} {
+	return map[string]string{
+		"title":       t.Title,
+		"description": t.Description,
+	}
+}
+
+// do returns the metadata and the status code of the answer.
+func (c *Client) do(ctx context.Context, method, path string, body interface{}, out interface{}) (*domain.Metadata, int, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	attempts := c.Retry.Attempts
+	if attempts < 1 || !idempotent(method) {
+		attempts = 1
+	}
+	var (
+		raw  []byte
+		code int
+		err  error
+	)
+	for attempt := 1; ; attempt++ {
+		var wait time.Duration
+		raw, code, wait, err = c.send(ctx, method, path, payload)
+		if err == nil || wait < 0 || attempt == attempts {
+			break
+		}
+		if wait == 0 {
+			wait = c.backoff(attempt)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, 0, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return nil, code, err
+	}
+	if out != nil && len(env.Data) > 0 {
+		if err := json.Unmarshal(env.Data, out); err != nil {
+			return nil, code, err
+		}
+	}
+	return env.Metadata, code, nil
+}
+
+// send makes one attempt. wait is negative when the error must not be
+// retried and positive when the server asked for a delay.
+func (c *Client) send(ctx context.Context, method, path string, payload []byte) ([]byte, int, time.Duration, error) {
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return nil, 0, -1, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.UserID != "" {
+		req.Header.Set("X-User-ID", c.UserID)
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, 0, -1, ctx.Err()
+		}
+		return nil, 0, 0, err
+	}
+	defer resp.Body.Close()
+	raw, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if resp.StatusCode < 300 {
+		return raw, resp.StatusCode, 0, nil
+	}
+
+	apiErr := &Error{StatusCode: resp.StatusCode}
+	var msg struct {
+		Message string              `json:"message"`
+		Errors  map[string][]string `json:"errors"`
+	}
+	if json.Unmarshal(raw, &msg) == nil {
+		apiErr.Message = msg.Message
+		apiErr.Fields = msg.Errors
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return nil, resp.StatusCode, retryAfter(resp.Header.Get("Retry-After")), apiErr
+	default:
+		return nil, resp.StatusCode, -1, apiErr
+	}
+}
+
+func (c *Client) backoff(attempt int) time.Duration {
+	wait := c.Retry.Base << uint(attempt-1)
+	if wait <= 0 || (c.Retry.Max > 0 && wait > c.Retry.Max) {
+		wait = c.Retry.Max
+	}
+	if wait <= 0 {
+		return 0
+	}
+	// Full jitter keeps many clients from retrying in lockstep.
+	return time.Duration(rand.Int63n(int64(wait)) + 1)
+}
+
+func retryAfter(value string) time.Duration {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(at); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
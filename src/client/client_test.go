@@ -0,0 +1,245 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/isaias-dgr/todo/src/client"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/domain/mocks"
+	_TaskHttp "github.com/isaias-dgr/todo/src/task/deliver/http"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type SuiteClient struct {
+	suite.Suite
+	cu     *mocks.TaskUseCase
+	server *httptest.Server
+	client *client.Client
+}
+
+func (s *SuiteClient) SetupTest() {
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	s.cu = new(mocks.TaskUseCase)
+	r := mux.NewRouter()
+	r.Use(_TaskHttp.UserMiddleware)
+	_TaskHttp.NewTaskHandler(r, s.cu, logger.Sugar())
+	s.server = httptest.NewServer(r)
+	s.client = client.NewClient(s.server.URL + "/")
+	s.client.UserID = "user-1"
+}
+
+func (s *SuiteClient) TearDownTest() {
+	s.server.Close()
+}
+
+func (s *SuiteClient) TestFetch() {
+	tasks := domain.NewTasks([]*domain.Task{domain.NewTask("title", "description")}, 7)
+	s.cu.On("Fetch", mock.Anything, &domain.Filter{Offset: 2, Limit: 1, SortBy: "title"}).Return(tasks, nil)
+
+	got, err := s.client.Fetch(context.TODO(), &domain.Filter{Offset: 2, Limit: 1, SortBy: "title"})
+	s.NoError(err)
+	s.Equal(7, got.Total)
+	s.Len(got.Data, 1)
+	s.Equal("title", got.Data[0].Title)
+}
+
+func (s *SuiteClient) TestInsert() {
+	id := uuid.New()
+	s.cu.On("Insert", mock.MatchedBy(func(ctx context.Context) bool {
+		return domain.UserFromContext(ctx) == "user-1"
+	}), mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(*domain.Task).ID = id
+	}).Return(nil)
+
+	task := domain.NewTask("title", "description")
+	s.NoError(s.client.Insert(context.TODO(), task))
+	s.Equal(id, task.ID)
+}
+
+func (s *SuiteClient) TestGetByID() {
+	s.Run("When the task exists", func() {
+		task := domain.NewTask("title", "description")
+		task.ID = uuid.New()
+		s.cu.On("GetByID", mock.Anything, task.ID.String()).Return(task, nil)
+		got, err := s.client.GetByID(context.TODO(), task.ID.String())
+		s.NoError(err)
+		s.Equal(task.ID, got.ID)
+	})
+
+	s.Run("When the task does not exist", func() {
+		s.cu.On("GetByID", mock.Anything, "missing").Return(nil, errors.New("not_found"))
+		_, err := s.client.GetByID(context.TODO(), "missing")
+		var apiErr *client.Error
+		s.True(errors.As(err, &apiErr))
+		s.Equal(404, apiErr.StatusCode)
+		s.Equal("not_found", apiErr.Message)
+		s.True(errors.Is(err, client.ErrNotFound))
+		s.False(errors.Is(err, client.ErrBadRequest))
+	})
+}
+
+func (s *SuiteClient) TestUpdateAndDelete() {
+	s.cu.On("Upsert", mock.Anything, "1", mock.Anything).Return(false, nil)
+	s.cu.On("Delete", mock.Anything, "1").Return(nil)
+	s.NoError(s.client.Update(context.TODO(), "1", domain.NewTask("title", "description")))
+	s.NoError(s.client.Delete(context.TODO(), "1"))
+}
+
+func (s *SuiteClient) TestComplete() {
+	task := domain.NewTask("title", "description")
+	completed_at := time.Date(2021, 10, 18, 12, 0, 0, 0, time.UTC)
+	task.CompletedAt = &completed_at
+	s.cu.On("Complete", mock.Anything, "1").Return(task, nil)
+	got, err := s.client.Complete(context.TODO(), "1")
+	s.NoError(err)
+	s.Equal(completed_at, *got.CompletedAt)
+	s.cu.AssertNotCalled(s.T(), "Delete", mock.Anything, mock.Anything)
+}
+
+func (s *SuiteClient) TestUpsert() {
+	s.cu.On("Upsert", mock.Anything, "new", mock.Anything).Return(true, nil)
+	s.cu.On("Upsert", mock.Anything, "old", mock.Anything).Return(false, nil)
+	created, err := s.client.Upsert(context.TODO(), "new", domain.NewTask("title", "description"))
+	s.NoError(err)
+	s.True(created)
+	created, err = s.client.Upsert(context.TODO(), "old", domain.NewTask("title", "description"))
+	s.NoError(err)
+	s.False(created)
+}
+
+func (s *SuiteClient) TestTypedErrors() {
+	s.cu.On("Insert", mock.Anything, mock.Anything).Return(errors.New("conflict_insert")).Once()
+	err := s.client.Insert(context.TODO(), domain.NewTask("title", "description"))
+	s.True(errors.Is(err, client.ErrBadRequest))
+
+	s.cu.On("Insert", mock.Anything, mock.Anything).Return(func(_ context.Context, t *domain.Task) error {
+		return t.Validate()
+	}).Once()
+	err = s.client.Insert(context.TODO(), domain.NewTask("title", ""))
+	var apiErr *client.Error
+	s.Require().True(errors.As(err, &apiErr))
+	s.Equal("validation_failed", apiErr.Message)
+	s.Equal(map[string][]string{"description": {"is required"}}, apiErr.Fields)
+
+	s.cu.On("Delete", mock.Anything, "busy").Return(errors.New("conflict_delete")).Once()
+	err = s.client.Delete(context.TODO(), "busy")
+	s.True(errors.Is(err, client.ErrConflict))
+	s.False(errors.Is(err, client.ErrNotFound))
+}
+
+func (s *SuiteClient) TestRetry() {
+	fast := client.Retry{Attempts: 3, Base: time.Millisecond, Max: 5 * time.Millisecond}
+
+	s.Run("When the server recovers", func() {
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"data": {"id": "6a2f41a3-c54c-fce8-32d2-0324e1c32e22", "title": "title"}}`))
+		}))
+		defer server.Close()
+
+		c := client.NewClient(server.URL, client.WithRetry(fast))
+		task, err := c.GetByID(context.TODO(), "6a2f41a3-c54c-fce8-32d2-0324e1c32e22")
+		s.NoError(err)
+		s.Equal("title", task.Title)
+		s.Equal(int32(3), atomic.LoadInt32(&calls))
+	})
+
+	s.Run("When the server keeps failing", func() {
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		c := client.NewClient(server.URL, client.WithRetry(fast))
+		err := c.Delete(context.TODO(), "1")
+		var apiErr *client.Error
+		s.True(errors.As(err, &apiErr))
+		s.Equal(http.StatusBadGateway, apiErr.StatusCode)
+		s.Equal(int32(3), atomic.LoadInt32(&calls))
+	})
+
+	s.Run("When the request is not idempotent", func() {
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		c := client.NewClient(server.URL, client.WithRetry(fast))
+		s.Error(c.Insert(context.TODO(), domain.NewTask("title", "description")))
+		s.Equal(int32(1), atomic.LoadInt32(&calls))
+	})
+
+	s.Run("When the error is not retryable", func() {
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		c := client.NewClient(server.URL, client.WithRetry(fast))
+		_, err := c.GetByID(context.TODO(), "1")
+		s.True(errors.Is(err, client.ErrNotFound))
+		s.Equal(int32(1), atomic.LoadInt32(&calls))
+	})
+
+	s.Run("When the context is cancelled while waiting", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithTimeout(context.TODO(), 20*time.Millisecond)
+		defer cancel()
+		c := client.NewClient(server.URL, client.WithRetry(fast))
+		_, err := c.GetByID(ctx, "1")
+		s.Equal(context.DeadlineExceeded, err)
+	})
+}
+
+func (s *SuiteClient) TestTraceparent() {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("traceparent")
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.TODO(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+	s.NoError(client.NewClient(server.URL).Delete(ctx, "1"))
+	s.Equal("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", got)
+}
+
+func TestSuiteClient(t *testing.T) {
+	suite.Run(t, new(SuiteClient))
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+
+	"github.com/isaias-dgr/todo/src/domain"
+)
+
+// TaskIterator walks every task matching a filter, one page at a time:
+//
+//	it := c.Iterate(&domain.Filter{Limit: 50})
+//	for it.Next(ctx) {
+//		task := it.Task()
+//	}
+//	if err := it.Err(); err != nil {
+//	}
+type TaskIterator struct {
+	client *Client
+	filter domain.Filter
+	page   []*domain.Task
+	pos    int
+	total  int
+	done   bool
+	err    error
+}
+
+func (c *Client) Iterate(f *domain.Filter) *TaskIterator {
+	filter := *f
+	if filter.Limit <= 0 {
+		filter.Limit = 10
+	}
+	return &TaskIterator{client: c, filter: filter, pos: -1}
+}
+
+func (it *TaskIterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+	if it.pos+1 < len(it.page) {
+		it.pos++
+		return true
+	}
+	if it.done {
+		return false
+	}
+	tasks, err := it.client.Fetch(ctx, &it.filter)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	it.page, it.pos, it.total = tasks.Data, 0, tasks.Total
+	it.filter.Offset += len(tasks.Data)
+	it.done = len(tasks.Data) < it.filter.Limit || it.filter.Offset >= tasks.Total
+	return len(it.page) > 0
+}
+
+func (it *TaskIterator) Task() *domain.Task {
+	if it.pos < 0 || it.pos >= len(it.page) {
+		return nil
+	}
+	return it.page[it.pos]
+}
+
+// Total is the count reported by the last page fetched.
+func (it *TaskIterator) Total() int {
+	return it.total
+}
+
+func (it *TaskIterator) Err() error {
+	return it.err
+}
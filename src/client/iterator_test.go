@@ -0,0 +1,71 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/isaias-dgr/todo/src/client"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+// pagedServer serves total tasks titled by position, honoring offset and
+// limit like the real handler.
+func pagedServer(total int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		data := "["
+		for i := offset; i < offset+limit && i < total; i++ {
+			if i > offset {
+				data += ","
+			}
+			data += fmt.Sprintf(`{"title": "%d"}`, i)
+		}
+		data += "]"
+		fmt.Fprintf(w, `{"data": %s, "metadata": {"offset": %d, "limit": %d, "total": %d}}`, data, offset, limit, total)
+	}))
+}
+
+func TestIterate(t *testing.T) {
+	assert := assert.New(t)
+	server := pagedServer(7)
+	defer server.Close()
+
+	it := client.NewClient(server.URL).Iterate(&domain.Filter{Limit: 3})
+	var titles []string
+	for it.Next(context.TODO()) {
+		titles = append(titles, it.Task().Title)
+	}
+	assert.NoError(it.Err())
+	assert.Equal([]string{"0", "1", "2", "3", "4", "5", "6"}, titles)
+	assert.Equal(7, it.Total())
+	assert.False(it.Next(context.TODO()))
+}
+
+func TestIterateEmpty(t *testing.T) {
+	server := pagedServer(0)
+	defer server.Close()
+
+	it := client.NewClient(server.URL).Iterate(&domain.Filter{})
+	assert.False(t, it.Next(context.TODO()))
+	assert.Nil(t, it.Task())
+	assert.NoError(t, it.Err())
+}
+
+func TestIterateError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message": "bad request"}`))
+	}))
+	defer server.Close()
+
+	it := client.NewClient(server.URL).Iterate(&domain.Filter{Limit: 3})
+	assert.False(t, it.Next(context.TODO()))
+	assert.True(t, errors.Is(it.Err(), client.ErrBadRequest))
+}
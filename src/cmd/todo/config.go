@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+const defaultURL = "http://localhost:8080"
+
+// Profile is one server the CLI can talk to. The API trusts the gateway in
+// front of it, so credentials are the user id and an optional bearer token.
+type Profile struct {
+	URL   string `yaml:"url"`
+	User  string `yaml:"user"`
+	Token string `yaml:"token"`
+}
+
+// Config is the profiles file, by default ~/.config/todo/config.yaml:
+//
+//	default: local
+//	profiles:
+//	  local:
+//	    url: http://localhost:8080
+//	    user: ana
+type Config struct {
+	Default  string             `yaml:"default"`
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+func configPath() string {
+	if path := os.Getenv("TODO_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "todo", "config.yaml")
+}
+
+// loadConfig reads the profiles file. A missing file is not an error so the
+// CLI works against a local server without any setup.
+func loadConfig(path string) (*Config, error) {
+	config := &Config{}
+	if path == "" {
+		return config, nil
+	}
+	raw, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.UnmarshalStrict(raw, config); err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// Profile returns the named profile, the default one when name is empty,
+// or a profile pointing at a local server when there is neither.
+func (c *Config) Profile(name string) (Profile, error) {
+	if name == "" {
+		name = c.Default
+	}
+	if name == "" {
+		return Profile{URL: defaultURL}, nil
+	}
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("unknown profile %q", name)
+	}
+	if profile.URL == "" {
+		profile.URL = defaultURL
+	}
+	return profile, nil
+}
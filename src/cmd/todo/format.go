@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/isaias-dgr/todo/src/domain"
+)
+
+var formats = map[string]bool{"table": true, "json": true, "csv": true}
+
+func printTasks(w io.Writer, format string, tasks []*domain.Task) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(tasks)
+	case "csv":
+		out := csv.NewWriter(w)
+		out.Write([]string{"id", "title", "description", "created_at", "updated_at", "completed_at"})
+		for _, t := range tasks {
+			out.Write([]string{t.ID.String(), t.Title, t.Description,
+				stamp(t.CreatedAt), stamp(t.UpdatedAt), stamp(t.CompletedAt)})
+		}
+		out.Flush()
+		return out.Error()
+	default:
+		out := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(out, "ID\tTITLE\tDESCRIPTION\tCREATED\tCOMPLETED")
+		for _, t := range tasks {
+			fmt.Fprintf(out, "%s\t%s\t%s\t%s\t%s\n", t.ID, t.Title, t.Description, stamp(t.CreatedAt), stamp(t.CompletedAt))
+		}
+		return out.Flush()
+	}
+}
+
+func stamp(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,245 @@
+// Command todo manages tasks through the HTTP API.
+//
+//	todo [flags] list [--offset N] [--limit N] [--sort-by FIELD]
+//	todo [flags] add --title T --description D
+//	todo [flags] edit ID [--title T] [--description D]
+//	todo [flags] show ID
+//	todo [flags] rm ID
+//	todo [flags] done ID
+//
+// Flags: --config FILE, --profile NAME, --url URL, --format table|json|csv.
+// They may also follow the command.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/isaias-dgr/todo/src/client"
+	"github.com/isaias-dgr/todo/src/domain"
+)
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
+
+type options struct {
+	config  string
+	profile string
+	url     string
+	format  string
+}
+
+func (o *options) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.config, "config", o.config, "profiles file")
+	fs.StringVar(&o.profile, "profile", o.profile, "profile to use")
+	fs.StringVar(&o.url, "url", o.url, "server url, overrides the profile")
+	fs.StringVar(&o.format, "format", o.format, "output format: table, json or csv")
+}
+
+type command func(ctx context.Context, fs *flag.FlagSet, args []string, o *options, stdout io.Writer) error
+
+var commands = map[string]command{
+	"list": list,
+	"add":  add,
+	"edit": edit,
+	"show": show,
+	"rm":   remove,
+	"done": done,
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	o := &options{config: configPath(), format: "table"}
+	global := flag.NewFlagSet("todo", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	o.register(global)
+	global.Usage = func() {
+		fmt.Fprintln(stderr, "usage: todo [flags] list|add|edit|show|rm|done [args]")
+		global.PrintDefaults()
+	}
+	if err := global.Parse(args); err != nil {
+		return 2
+	}
+	if global.NArg() == 0 {
+		global.Usage()
+		return 2
+	}
+	name := global.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "todo: unknown command %q\n", name)
+		global.Usage()
+		return 2
+	}
+
+	fs := flag.NewFlagSet("todo "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	o.register(fs)
+	err := cmd(ctx, fs, global.Args()[1:], o, stdout)
+	if errors.Is(err, flag.ErrHelp) {
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "todo:", err)
+		return 1
+	}
+	return 0
+}
+
+// parse reads the command flags, which may come before or after the
+// positional arguments, and builds the client from the chosen profile.
+func parse(fs *flag.FlagSet, args []string, o *options) (*client.Client, []string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, nil, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if !formats[o.format] {
+		return nil, nil, fmt.Errorf("unknown format %q", o.format)
+	}
+	config, err := loadConfig(o.config)
+	if err != nil {
+		return nil, nil, err
+	}
+	profile, err := config.Profile(o.profile)
+	if err != nil {
+		return nil, nil, err
+	}
+	if o.url != "" {
+		profile.URL = o.url
+	}
+	c := client.NewClient(profile.URL, client.WithUser(profile.User), client.WithToken(profile.Token))
+	return c, positional, nil
+}
+
+func taskID(positional []string) (string, error) {
+	if len(positional) != 1 {
+		return "", errors.New("expected exactly one task id")
+	}
+	return positional[0], nil
+}
+
+func list(ctx context.Context, fs *flag.FlagSet, args []string, o *options, stdout io.Writer) error {
+	filter := domain.Filter{}
+	fs.IntVar(&filter.Offset, "offset", 0, "tasks to skip")
+	fs.IntVar(&filter.Limit, "limit", 10, "tasks to return")
+	fs.StringVar(&filter.SortBy, "sort-by", "", "field to sort by")
+	c, _, err := parse(fs, args, o)
+	if err != nil {
+		return err
+	}
+	tasks, err := c.Fetch(ctx, &filter)
+	if err != nil {
+		return err
+	}
+	if err := printTasks(stdout, o.format, tasks.Data); err != nil {
+		return err
+	}
+	if o.format == "table" {
+		fmt.Fprintf(stdout, "\n%d of %d\n", len(tasks.Data), tasks.Total)
+	}
+	return nil
+}
+
+func add(ctx context.Context, fs *flag.FlagSet, args []string, o *options, stdout io.Writer) error {
+	task := domain.Task{}
+	fs.StringVar(&task.Title, "title", "", "task title")
+	fs.StringVar(&task.Description, "description", "", "task description")
+	c, _, err := parse(fs, args, o)
+	if err != nil {
+		return err
+	}
+	if err := c.Insert(ctx, &task); err != nil {
+		return err
+	}
+	return printTasks(stdout, o.format, []*domain.Task{&task})
+}
+
+// edit only changes the fields given; the API replaces both, so the rest
+// is read from the current task first.
+func edit(ctx context.Context, fs *flag.FlagSet, args []string, o *options, stdout io.Writer) error {
+	title := fs.String("title", "", "new title")
+	description := fs.String("description", "", "new description")
+	c, positional, err := parse(fs, args, o)
+	if err != nil {
+		return err
+	}
+	id, err := taskID(positional)
+	if err != nil {
+		return err
+	}
+	task, err := c.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if *title != "" {
+		task.Title = *title
+	}
+	if *description != "" {
+		task.Description = *description
+	}
+	if err := c.Update(ctx, id, task); err != nil {
+		return err
+	}
+	return printTasks(stdout, o.format, []*domain.Task{task})
+}
+
+func show(ctx context.Context, fs *flag.FlagSet, args []string, o *options, stdout io.Writer) error {
+	c, positional, err := parse(fs, args, o)
+	if err != nil {
+		return err
+	}
+	id, err := taskID(positional)
+	if err != nil {
+		return err
+	}
+	task, err := c.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return printTasks(stdout, o.format, []*domain.Task{task})
+}
+
+func remove(ctx context.Context, fs *flag.FlagSet, args []string, o *options, stdout io.Writer) error {
+	c, positional, err := parse(fs, args, o)
+	if err != nil {
+		return err
+	}
+	id, err := taskID(positional)
+	if err != nil {
+		return err
+	}
+	if err := c.Delete(ctx, id); err != nil {
+		return err
+	}
+	if o.format == "table" {
+		fmt.Fprintf(stdout, "%s removed\n", id)
+	}
+	return nil
+}
+
+func done(ctx context.Context, fs *flag.FlagSet, args []string, o *options, stdout io.Writer) error {
+	c, positional, err := parse(fs, args, o)
+	if err != nil {
+		return err
+	}
+	id, err := taskID(positional)
+	if err != nil {
+		return err
+	}
+	task, err := c.Complete(ctx, id)
+	if err != nil {
+		return err
+	}
+	return printTasks(stdout, o.format, []*domain.Task{task})
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/domain/mocks"
+	_TaskHttp "github.com/isaias-dgr/todo/src/task/deliver/http"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type SuiteCli struct {
+	suite.Suite
+	cu     *mocks.TaskUseCase
+	server *httptest.Server
+	dir    string
+	task   *domain.Task
+}
+
+func (s *SuiteCli) SetupTest() {
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	s.cu = new(mocks.TaskUseCase)
+	r := mux.NewRouter()
+	r.Use(_TaskHttp.UserMiddleware)
+	_TaskHttp.NewTaskHandler(r, s.cu, logger.Sugar())
+	s.server = httptest.NewServer(r)
+
+	s.dir, _ = ioutil.TempDir("", "todo-cli-")
+	config := "default: dev\nprofiles:\n  dev:\n    url: " + s.server.URL + "\n    user: ana\n  other:\n    url: http://127.0.0.1:1\n"
+	ioutil.WriteFile(filepath.Join(s.dir, "config.yaml"), []byte(config), 0o600)
+	os.Setenv("TODO_CONFIG", filepath.Join(s.dir, "config.yaml"))
+
+	s.task = domain.NewTask("buy milk", "two bottles, semi-skimmed")
+	s.task.ID = uuid.MustParse("6a2f41a3-c54c-fce8-32d2-0324e1c32e22")
+}
+
+func (s *SuiteCli) TearDownTest() {
+	s.server.Close()
+	os.RemoveAll(s.dir)
+	os.Unsetenv("TODO_CONFIG")
+}
+
+func (s *SuiteCli) run(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(context.TODO(), args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func (s *SuiteCli) TestList() {
+	s.cu.On("Fetch", mock.Anything, &domain.Filter{Offset: 0, Limit: 5, SortBy: "title"}).
+		Return(domain.NewTasks([]*domain.Task{s.task}, 1), nil)
+
+	s.Run("As a table", func() {
+		code, out, _ := s.run("list", "--limit", "5", "--sort-by", "title")
+		s.Equal(0, code)
+		s.Equal("ID                                    TITLE     DESCRIPTION                CREATED  COMPLETED\n"+
+			"6a2f41a3-c54c-fce8-32d2-0324e1c32e22  buy milk  two bottles, semi-skimmed           \n\n1 of 1\n", out)
+	})
+
+	s.Run("As csv", func() {
+		code, out, _ := s.run("--format", "csv", "list", "--limit", "5", "--sort-by", "title")
+		s.Equal(0, code)
+		s.Equal("id,title,description,created_at,updated_at,completed_at\n"+
+			"6a2f41a3-c54c-fce8-32d2-0324e1c32e22,buy milk,\"two bottles, semi-skimmed\",,,\n", out)
+	})
+
+	s.Run("As json", func() {
+		code, out, _ := s.run("list", "--limit=5", "--sort-by=title", "--format=json")
+		s.Equal(0, code)
+		s.JSONEq(`[{"id": "6a2f41a3-c54c-fce8-32d2-0324e1c32e22", "title": "buy milk", "description": "two bottles, semi-skimmed"}]`, out)
+	})
+}
+
+func (s *SuiteCli) TestAdd() {
+	s.cu.On("Insert", mock.MatchedBy(func(ctx context.Context) bool {
+		return domain.UserFromContext(ctx) == "ana"
+	}), &domain.Task{Title: "buy milk", Description: "two bottles"}).Return(nil)
+
+	code, out, _ := s.run("add", "--title", "buy milk", "--description", "two bottles", "--format", "csv")
+	s.Equal(0, code)
+	s.Contains(out, ",buy milk,two bottles,")
+}
+
+func (s *SuiteCli) TestEdit() {
+	s.cu.On("GetByID", mock.Anything, s.task.ID.String()).Return(s.task, nil)
+	s.cu.On("Upsert", mock.Anything, s.task.ID.String(), &domain.Task{Title: "buy milk", Description: "one bottle"}).Return(false, nil)
+
+	code, _, stderr := s.run("edit", s.task.ID.String(), "--description", "one bottle")
+	s.Equal(0, code, stderr)
+	s.cu.AssertExpectations(s.T())
+}
+
+func (s *SuiteCli) TestShow() {
+	s.Run("When the task exists", func() {
+		s.cu.On("GetByID", mock.Anything, s.task.ID.String()).Return(s.task, nil).Once()
+		code, out, _ := s.run("show", s.task.ID.String())
+		s.Equal(0, code)
+		s.Contains(out, "buy milk")
+	})
+
+	s.Run("When the task does not exist", func() {
+		s.cu.On("GetByID", mock.Anything, "missing").Return(nil, errors.New("not_found")).Once()
+		code, _, stderr := s.run("show", "missing")
+		s.Equal(1, code)
+		s.Equal("todo: 404 Not Found: not_found\n", stderr)
+	})
+
+	s.Run("When the id is missing", func() {
+		code, _, stderr := s.run("show")
+		s.Equal(1, code)
+		s.Equal("todo: expected exactly one task id\n", stderr)
+	})
+}
+
+func (s *SuiteCli) TestRemove() {
+	s.cu.On("Delete", mock.Anything, s.task.ID.String()).Return(nil)
+	code, out, _ := s.run("rm", s.task.ID.String())
+	s.Equal(0, code)
+	s.Equal(s.task.ID.String()+" removed\n", out)
+}
+
+func (s *SuiteCli) TestDone() {
+	completed_at := time.Date(2021, 10, 18, 12, 0, 0, 0, time.UTC)
+	s.task.CompletedAt = &completed_at
+	s.cu.On("Complete", mock.Anything, s.task.ID.String()).Return(s.task, nil)
+	code, out, _ := s.run("done", s.task.ID.String(), "--format", "csv")
+	s.Equal(0, code)
+	s.Contains(out, ",2021-10-18T12:00:00Z\n")
+	s.cu.AssertNotCalled(s.T(), "Delete", mock.Anything, mock.Anything)
+}
+
+func (s *SuiteCli) TestProfiles() {
+	s.Run("When the profile does not exist", func() {
+		code, _, stderr := s.run("list", "--profile", "prod")
+		s.Equal(1, code)
+		s.Equal("todo: unknown profile \"prod\"\n", stderr)
+	})
+
+	s.Run("When --url overrides the profile", func() {
+		s.cu.On("Delete", mock.Anything, "1").Return(nil).Once()
+		code, _, stderr := s.run("--profile", "other", "--url", s.server.URL, "rm", "1")
+		s.Equal(0, code, stderr)
+	})
+}
+
+func (s *SuiteCli) TestUsage() {
+	code, _, _ := s.run()
+	s.Equal(2, code)
+	code, _, stderr := s.run("archive")
+	s.Equal(2, code)
+	s.Contains(stderr, "unknown command \"archive\"")
+	code, _, stderr = s.run("list", "--format", "xml")
+	s.Equal(1, code)
+	s.Equal("todo: unknown format \"xml\"\n", stderr)
+}
+
+func TestSuiteCli(t *testing.T) {
+	suite.Run(t, new(SuiteCli))
+}
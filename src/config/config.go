@@ -0,0 +1,490 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v2"
+)
+
+type Config struct {
+	Project     Project
+	HTTP        HTTP
+	GRPC        GRPC
+	Repository  Repository
+	MySQL       MySQL
+	Log         Log
+	Health      Health
+	Events      Events
+	Blob        Blob
+	AWS         AWS
+	Tracing     Tracing
+	RateLimit   RateLimit
+	Quota       Quota
+	Idempotency Idempotency
+	Sync        Sync
+	Cache       Cache
+}
+
+type Project struct {
+	Name string
+	Env  string
+}
+
+// HTTP timeouts of zero disable them. Read and write timeouts bound every
+// route but the SSE and WebSocket streams, which would be cut. On shutdown
+// the server keeps serving for DrainPeriod, so load balancers stop sending
+// traffic, then waits up to ShutdownTimeout for the requests in flight.
+type HTTP struct {
+	Addr              string
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	DrainPeriod       time.Duration
+	ShutdownTimeout   time.Duration
+}
+
+type GRPC struct {
+	Addr string
+}
+
+// Repository keeps the tasks, their attachments, the outbox and the
+// idempotency keys in MySQL or, for demos and tests, in memory. The memory
+// store is lost on restart and is not shared between replicas.
+type Repository struct {
+	Store string
+}
+
+type MySQL struct {
+	Host            string
+	Port            int
+	User            string
+	Password        string
+	Database        string
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnectTimeout  time.Duration
+}
+
+func (m MySQL) DSN() string {
+	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true&timeout=%s",
+		m.User, m.Password, m.Host, m.Port, m.Database, m.ConnectTimeout)
+}
+
+// Log keeps RequestSampleRatio of the request lines of requests that went
+// well; failed requests and those slower than SlowRequest are always logged.
+type Log struct {
+	Level              string
+	RequestSampleRatio float64
+	SlowRequest        time.Duration
+}
+
+// Health bounds the readiness checks, so a hung database fails /readyz
+// instead of hanging it.
+type Health struct {
+	Timeout time.Duration
+}
+
+type Events struct {
+	Publisher   string
+	WebhookURL  string
+	SQSQueueURL string
+	SQSEndpoint string
+}
+
+type Blob struct {
+	Store      string
+	Dir        string
+	S3Bucket   string
+	S3Endpoint string
+}
+
+type AWS struct {
+	Region string
+}
+
+// Tracing sends spans to stdout or to an OTLP collector at Endpoint.
+// SampleRatio is the share of new traces kept, from 0 to 1.
+type Tracing struct {
+	Exporter    string
+	Endpoint    string
+	SampleRatio float64
+}
+
+// RateLimit keeps the token buckets in memory, per replica, or in MySQL,
+// shared by all of them. Rules are parsed by the ratelimit package.
+type RateLimit struct {
+	Store string
+	Rules string
+}
+
+// Quota caps the tasks each user can own; zero means no cap.
+type Quota struct {
+	MaxTasksPerUser int
+}
+
+// Idempotency keeps the answers to requests sent with an Idempotency-Key
+// for TTL. A key being served is held for Lease, so a crashed replica does
+// not block it for long; Lease should outlast the slowest request.
+type Idempotency struct {
+	TTL   time.Duration
+	Lease time.Duration
+}
+
+// Cache keeps the tasks read by id and the pages of tasks for TTL. It is
+// off by default. The memory store holds up to Size values per replica, so
+// the others may serve a changed task until TTL; it only suits a single
+// replica. Replicas should share redis.
+type Cache struct {
+	Store     string
+	TTL       time.Duration
+	Size      int
+	RedisAddr string
+}
+
+// Sync settles the conflicts of pushed changes by Policy,
+// last_writer_wins or server_wins.
+type Sync struct {
+	Policy string
+}
+
+// setting binds one value of the config to its key in the file, its
+// environment variable and its flag, which is the key itself.
+type setting struct {
+	key    string
+	env    string
+	def    string
+	secret bool
+	value  interface{}
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{key: "project.name", env: "PROJ_NAME", def: "mstodo", value: &c.Project.Name},
+		{key: "project.env", env: "PROJ_ENV", def: "dev", value: &c.Project.Env},
+		{key: "http.addr", env: "HTTP_ADDR", def: ":8080", value: &c.HTTP.Addr},
+		{key: "http.read_header_timeout", env: "HTTP_READ_HEADER_TIMEOUT", def: "5s", value: &c.HTTP.ReadHeaderTimeout},
+		{key: "http.read_timeout", env: "HTTP_READ_TIMEOUT", def: "1m", value: &c.HTTP.ReadTimeout},
+		{key: "http.write_timeout", env: "HTTP_WRITE_TIMEOUT", def: "1m", value: &c.HTTP.WriteTimeout},
+		{key: "http.idle_timeout", env: "HTTP_IDLE_TIMEOUT", def: "120s", value: &c.HTTP.IdleTimeout},
+		{key: "http.drain_period", env: "HTTP_DRAIN_PERIOD", def: "5s", value: &c.HTTP.DrainPeriod},
+		{key: "http.shutdown_timeout", env: "HTTP_SHUTDOWN_TIMEOUT", def: "15s", value: &c.HTTP.ShutdownTimeout},
+		{key: "grpc.addr", env: "GRPC_ADDR", def: ":9090", value: &c.GRPC.Addr},
+		{key: "repository.store", env: "REPOSITORY_STORE", def: "mysql", value: &c.Repository.Store},
+		{key: "mysql.host", env: "MYSQL_HOST", value: &c.MySQL.Host},
+		{key: "mysql.port", env: "MYSQL_PORT", def: "3306", value: &c.MySQL.Port},
+		{key: "mysql.user", env: "MYSQL_USER", value: &c.MySQL.User},
+		{key: "mysql.password", env: "MYSQL_PASSWORD", secret: true, value: &c.MySQL.Password},
+		{key: "mysql.database", env: "MYSQL_DATABASE", value: &c.MySQL.Database},
+		{key: "mysql.max_open_conns", env: "MYSQL_MAX_OPEN_CONNS", def: "20", value: &c.MySQL.MaxOpenConns},
+		{key: "mysql.max_idle_conns", env: "MYSQL_MAX_IDLE_CONNS", def: "10", value: &c.MySQL.MaxIdleConns},
+		{key: "mysql.conn_max_lifetime", env: "MYSQL_CONN_MAX_LIFETIME", def: "5m", value: &c.MySQL.ConnMaxLifetime},
+		{key: "mysql.connect_timeout", env: "MYSQL_CONNECT_TIMEOUT", def: "5s", value: &c.MySQL.ConnectTimeout},
+		{key: "log.level", env: "LOG_LEVEL", def: "info", value: &c.Log.Level},
+		{key: "log.request_sample_ratio", env: "LOG_REQUEST_SAMPLE_RATIO", def: "1", value: &c.Log.RequestSampleRatio},
+		{key: "log.slow_request", env: "LOG_SLOW_REQUEST", def: "1s", value: &c.Log.SlowRequest},
+		{key: "health.timeout", env: "HEALTH_TIMEOUT", def: "2s", value: &c.Health.Timeout},
+		{key: "events.publisher", env: "EVENT_PUBLISHER", def: "log", value: &c.Events.Publisher},
+		{key: "events.webhook_url", env: "EVENT_WEBHOOK_URL", secret: true, value: &c.Events.WebhookURL},
+		{key: "events.sqs_queue_url", env: "SQS_QUEUE_URL", value: &c.Events.SQSQueueURL},
+		{key: "events.sqs_endpoint", env: "SQS_ENDPOINT", value: &c.Events.SQSEndpoint},
+		{key: "blob.store", env: "BLOB_STORE", def: "local", value: &c.Blob.Store},
+		{key: "blob.dir", env: "BLOB_DIR", def: "/var/lib/todo/blobs", value: &c.Blob.Dir},
+		{key: "blob.s3_bucket", env: "S3_BUCKET", value: &c.Blob.S3Bucket},
+		{key: "blob.s3_endpoint", env: "S3_ENDPOINT", value: &c.Blob.S3Endpoint},
+		{key: "aws.region", env: "AWS_REGION", def: "us-east-1", value: &c.AWS.Region},
+		{key: "tracing.exporter", env: "TRACING_EXPORTER", def: "none", value: &c.Tracing.Exporter},
+		{key: "tracing.endpoint", env: "OTEL_EXPORTER_OTLP_ENDPOINT", def: "localhost:4317", value: &c.Tracing.Endpoint},
+		{key: "tracing.sample_ratio", env: "TRACING_SAMPLE_RATIO", def: "1", value: &c.Tracing.SampleRatio},
+		{key: "ratelimit.store", env: "RATE_LIMIT_STORE", def: "memory", value: &c.RateLimit.Store},
+		{key: "ratelimit.rules", env: "RATE_LIMIT_RULES", def: "default=600/1m, POST /task/=60/1m:20, GET /healthz=off, GET /readyz=off, GET /metrics=off", value: &c.RateLimit.Rules},
+		{key: "idempotency.ttl", env: "IDEMPOTENCY_TTL", def: "24h", value: &c.Idempotency.TTL},
+		{key: "idempotency.lease", env: "IDEMPOTENCY_LEASE", def: "1m", value: &c.Idempotency.Lease},
+		{key: "sync.policy", env: "SYNC_CONFLICT_POLICY", def: "last_writer_wins", value: &c.Sync.Policy},
+		{key: "cache.store", env: "CACHE_STORE", def: "none", value: &c.Cache.Store},
+		{key: "cache.ttl", env: "CACHE_TTL", def: "1m", value: &c.Cache.TTL},
+		{key: "cache.size", env: "CACHE_SIZE", def: "10000", value: &c.Cache.Size},
+		{key: "cache.redis_addr", env: "REDIS_ADDR", def: "localhost:6379", value: &c.Cache.RedisAddr},
+		{key: "quota.max_tasks_per_user", env: "TASK_QUOTA_PER_USER", def: "10000", value: &c.Quota.MaxTasksPerUser},
+	}
+}
+
+// Load builds the config from, in increasing precedence, the defaults, the
+// file given by -config or CONFIG_FILE (YAML or TOML), the environment and
+// the flags in args. It returns every invalid value at once.
+func Load(args []string, getenv func(string) string) (*Config, error) {
+	c := &Config{}
+	settings := c.settings()
+
+	fs := flag.NewFlagSet("todo", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	file := fs.String("config", getenv("CONFIG_FILE"), "config file, .yaml or .toml")
+	flags := map[string]*string{}
+	for _, s := range settings {
+		flags[s.key] = fs.String(s.key, "", "overrides "+s.env)
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+	for _, s := range settings {
+		values[s.key] = s.def
+	}
+	if *file != "" {
+		fromFile, err := readFile(*file)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range fromFile {
+			if _, ok := values[key]; !ok {
+				return nil, fmt.Errorf("config: unknown key %s in %s", key, *file)
+			}
+			values[key] = value
+		}
+	}
+	for _, s := range settings {
+		if value := getenv(s.env); value != "" {
+			values[s.key] = value
+		}
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if _, ok := values[f.Name]; ok {
+			values[f.Name] = *flags[f.Name]
+		}
+	})
+
+	problems := []string{}
+	for _, s := range settings {
+		if err := set(s.value, values[s.key]); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", s.key, err))
+		}
+	}
+	problems = append(problems, c.validate()...)
+	if len(problems) > 0 {
+		return nil, errors.New("config: " + strings.Join(problems, "; "))
+	}
+	return c, nil
+}
+
+func (c *Config) validate() []string {
+	problems := []string{}
+	required := map[string]string{
+		"http.addr": c.HTTP.Addr,
+		"grpc.addr": c.GRPC.Addr,
+	}
+	switch c.Repository.Store {
+	case "mysql":
+		required["mysql.host"] = c.MySQL.Host
+		required["mysql.user"] = c.MySQL.User
+		required["mysql.database"] = c.MySQL.Database
+	case "memory":
+		if c.RateLimit.Store == "mysql" {
+			problems = append(problems, "ratelimit.store: mysql needs the mysql repository store")
+		}
+	default:
+		problems = append(problems, "repository.store: must be one of mysql, memory")
+	}
+	for key, value := range required {
+		if value == "" {
+			problems = append(problems, key+": is required")
+		}
+	}
+	if c.MySQL.MaxOpenConns < 0 || c.MySQL.MaxIdleConns < 0 {
+		problems = append(problems, "mysql: pool sizes can not be negative")
+	}
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		problems = append(problems, "log.level: "+err.Error())
+	}
+	if c.Log.RequestSampleRatio < 0 || c.Log.RequestSampleRatio > 1 {
+		problems = append(problems, "log.request_sample_ratio: must be between 0 and 1")
+	}
+	switch c.Events.Publisher {
+	case "log":
+	case "webhook":
+		if _, err := url.ParseRequestURI(c.Events.WebhookURL); err != nil {
+			problems = append(problems, "events.webhook_url: is required by the webhook publisher")
+		}
+	case "sqs":
+		if c.Events.SQSQueueURL == "" {
+			problems = append(problems, "events.sqs_queue_url: is required by the sqs publisher")
+		}
+	default:
+		problems = append(problems, "events.publisher: must be one of log, webhook, sqs")
+	}
+	switch c.Blob.Store {
+	case "local":
+		if c.Blob.Dir == "" {
+			problems = append(problems, "blob.dir: is required by the local store")
+		}
+	case "s3":
+		if c.Blob.S3Bucket == "" {
+			problems = append(problems, "blob.s3_bucket: is required by the s3 store")
+		}
+	default:
+		problems = append(problems, "blob.store: must be one of local, s3")
+	}
+	switch c.Tracing.Exporter {
+	case "none", "stdout":
+	case "otlp":
+		if c.Tracing.Endpoint == "" {
+			problems = append(problems, "tracing.endpoint: is required by the otlp exporter")
+		}
+	default:
+		problems = append(problems, "tracing.exporter: must be one of none, stdout, otlp")
+	}
+	switch c.RateLimit.Store {
+	case "none", "memory", "mysql":
+	default:
+		problems = append(problems, "ratelimit.store: must be one of none, memory, mysql")
+	}
+	if c.Idempotency.TTL <= 0 {
+		problems = append(problems, "idempotency.ttl: must be positive")
+	}
+	if c.Idempotency.Lease <= 0 {
+		problems = append(problems, "idempotency.lease: must be positive")
+	}
+	switch c.Cache.Store {
+	case "none":
+	case "memory":
+		if c.Cache.Size <= 0 {
+			problems = append(problems, "cache.size: must be positive")
+		}
+	case "redis":
+		if c.Cache.RedisAddr == "" {
+			problems = append(problems, "cache.redis_addr: is required by the redis store")
+		}
+	default:
+		problems = append(problems, "cache.store: must be one of none, memory, redis")
+	}
+	if c.Cache.TTL <= 0 {
+		problems = append(problems, "cache.ttl: must be positive")
+	}
+	switch c.Sync.Policy {
+	case "last_writer_wins", "server_wins":
+	default:
+		problems = append(problems, "sync.policy: must be one of last_writer_wins, server_wins")
+	}
+	if c.Quota.MaxTasksPerUser < 0 {
+		problems = append(problems, "quota.max_tasks_per_user: can not be negative")
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		problems = append(problems, "tracing.sample_ratio: must be between 0 and 1")
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// LogLevel is valid once the config is loaded.
+func (c *Config) LogLevel() zapcore.Level {
+	var level zapcore.Level
+	level.UnmarshalText([]byte(c.Log.Level))
+	return level
+}
+
+// Print writes the effective config, one key per line, with the secrets
+// hidden.
+func (c *Config) Print(w io.Writer) {
+	for _, s := range c.settings() {
+		value := format(s.value)
+		if s.secret && value != "" {
+			value = "******"
+		}
+		fmt.Fprintf(w, "%s=%s\n", s.key, value)
+	}
+}
+
+func set(target interface{}, value string) error {
+	switch t := target.(type) {
+	case *string:
+		*t = value
+	case *int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		*t = n
+	case *float64:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return errors.New("must be a number")
+		}
+		*t = n
+	case *time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return errors.New("must be a duration like 5s")
+		}
+		*t = d
+	}
+	return nil
+}
+
+func format(target interface{}) string {
+	switch t := target.(type) {
+	case *string:
+		return *t
+	case *int:
+		return strconv.Itoa(*t)
+	case *float64:
+		return strconv.FormatFloat(*t, 'g', -1, 64)
+	case *time.Duration:
+		return t.String()
+	}
+	return ""
+}
+
+// readFile flattens the file into dotted keys, so a section [mysql] with a
+// host key becomes mysql.host.
+func readFile(path string) (map[string]string, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	tree := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(raw, &tree)
+	case ".toml":
+		err = toml.Unmarshal(raw, &tree)
+	default:
+		return nil, fmt.Errorf("config: %s must be .yaml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config: %s: %w", path, err)
+	}
+	values := map[string]string{}
+	flatten("", tree, values)
+	return values, nil
+}
+
+func flatten(prefix string, tree map[string]interface{}, values map[string]string) {
+	for key, value := range tree {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flatten(prefix+key+".", v, values)
+		case map[interface{}]interface{}:
+			sub := map[string]interface{}{}
+			for k, val := range v {
+				sub[fmt.Sprint(k)] = val
+			}
+			flatten(prefix+key+".", sub, values)
+		default:
+			values[prefix+key] = fmt.Sprint(v)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package config_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/isaias-dgr/todo/src/config"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap/zapcore"
+)
+
+type SuiteConfig struct {
+	suite.Suite
+	dir string
+	env map[string]string
+}
+
+func (s *SuiteConfig) SetupTest() {
+	dir, err := ioutil.TempDir("", "config-")
+	s.Require().NoError(err)
+	s.dir = dir
+	s.env = map[string]string{
+		"MYSQL_HOST":     "db",
+		"MYSQL_USER":     "user",
+		"MYSQL_PASSWORD": "secret",
+		"MYSQL_DATABASE": "todo",
+	}
+}
+
+func (s *SuiteConfig) TearDownTest() {
+	os.RemoveAll(s.dir)
+}
+
+func (s *SuiteConfig) getenv(key string) string {
+	return s.env[key]
+}
+
+func (s *SuiteConfig) write(name, content string) string {
+	path := filepath.Join(s.dir, name)
+	s.Require().NoError(ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func (s *SuiteConfig) TestDefaults() {
+	c, err := config.Load(nil, s.getenv)
+	s.Require().NoError(err)
+	s.Equal(":8080", c.HTTP.Addr)
+	s.Equal(5*time.Second, c.HTTP.ReadHeaderTimeout)
+	s.Equal(time.Minute, c.HTTP.ReadTimeout)
+	s.Equal(time.Minute, c.HTTP.WriteTimeout)
+	s.Equal(3306, c.MySQL.Port)
+	s.Equal(20, c.MySQL.MaxOpenConns)
+	s.Equal(zapcore.InfoLevel, c.LogLevel())
+	s.Equal(1.0, c.Log.RequestSampleRatio)
+	s.Equal(time.Second, c.Log.SlowRequest)
+	s.Equal("none", c.Tracing.Exporter)
+	s.Equal("mysql", c.Repository.Store)
+	s.Equal("memory", c.RateLimit.Store)
+	s.Equal(10000, c.Quota.MaxTasksPerUser)
+	s.Equal(24*time.Hour, c.Idempotency.TTL)
+	s.Equal(time.Minute, c.Idempotency.Lease)
+	s.Equal("last_writer_wins", c.Sync.Policy)
+	s.Equal("none", c.Cache.Store)
+	s.Equal(time.Minute, c.Cache.TTL)
+	s.Equal(1.0, c.Tracing.SampleRatio)
+	s.Equal("user:secret@tcp(db:3306)/todo?parseTime=true&timeout=5s", c.MySQL.DSN())
+}
+
+func (s *SuiteConfig) TestPrecedence() {
+	path := s.write("todo.yaml", "http:\n  addr: \":7000\"\n  idle_timeout: 1m\nmysql:\n  max_open_conns: 5\n  max_idle_conns: 2\nlog:\n  level: debug\n")
+	s.env["CONFIG_FILE"] = path
+	s.env["MYSQL_MAX_OPEN_CONNS"] = "8"
+	s.env["LOG_LEVEL"] = "warn"
+
+	c, err := config.Load([]string{"-log.level", "error"}, s.getenv)
+	s.Require().NoError(err)
+	s.Equal(":7000", c.HTTP.Addr, "the file overrides the defaults")
+	s.Equal(time.Minute, c.HTTP.IdleTimeout)
+	s.Equal(2, c.MySQL.MaxIdleConns)
+	s.Equal(8, c.MySQL.MaxOpenConns, "the environment overrides the file")
+	s.Equal(zapcore.ErrorLevel, c.LogLevel(), "the flags override the environment")
+}
+
+func (s *SuiteConfig) TestTOML() {
+	path := s.write("todo.toml", "[http]\naddr = \":7001\"\n\n[mysql]\nport = 3307\n")
+	c, err := config.Load([]string{"-config", path}, s.getenv)
+	s.Require().NoError(err)
+	s.Equal(":7001", c.HTTP.Addr)
+	s.Equal(3307, c.MySQL.Port)
+}
+
+func (s *SuiteConfig) TestFailFast() {
+	s.Run("When required values are missing", func() {
+		_, err := config.Load(nil, func(string) string { return "" })
+		s.Error(err)
+		s.Equal("config: mysql.database: is required; mysql.host: is required; mysql.user: is required", err.Error())
+	})
+
+	s.Run("When the repository is in memory", func() {
+		c, err := config.Load([]string{"-repository.store", "memory"}, func(string) string { return "" })
+		s.Require().NoError(err)
+		s.Equal("memory", c.Repository.Store)
+
+		_, err = config.Load([]string{"-repository.store", "memory", "-ratelimit.store", "mysql"}, func(string) string { return "" })
+		s.Error(err)
+		s.Equal("config: ratelimit.store: mysql needs the mysql repository store", err.Error())
+	})
+
+	s.Run("When values are malformed", func() {
+		s.env["MYSQL_PORT"] = "db"
+		s.env["EVENT_PUBLISHER"] = "kafka"
+		s.env["TRACING_EXPORTER"] = "zipkin"
+		s.env["TRACING_SAMPLE_RATIO"] = "half"
+		s.env["LOG_REQUEST_SAMPLE_RATIO"] = "2"
+		s.env["RATE_LIMIT_STORE"] = "redis"
+		s.env["SYNC_CONFLICT_POLICY"] = "client_wins"
+		s.env["CACHE_STORE"] = "memcached"
+		s.env["REPOSITORY_STORE"] = "sqlite"
+		_, err := config.Load([]string{"-http.idle_timeout", "soon", "-log.level", "loud"}, s.getenv)
+		s.Error(err)
+		s.Contains(err.Error(), "mysql.port: must be an integer")
+		s.Contains(err.Error(), "http.idle_timeout: must be a duration like 5s")
+		s.Contains(err.Error(), "log.level: ")
+		s.Contains(err.Error(), "events.publisher: must be one of log, webhook, sqs")
+		s.Contains(err.Error(), "tracing.exporter: must be one of none, stdout, otlp")
+		s.Contains(err.Error(), "tracing.sample_ratio: must be a number")
+		s.Contains(err.Error(), "log.request_sample_ratio: must be between 0 and 1")
+		s.Contains(err.Error(), "ratelimit.store: must be one of none, memory, mysql")
+		s.Contains(err.Error(), "sync.policy: must be one of last_writer_wins, server_wins")
+		s.Contains(err.Error(), "cache.store: must be one of none, memory, redis")
+		s.Contains(err.Error(), "repository.store: must be one of mysql, memory")
+	})
+
+	s.Run("When the file has an unknown key", func() {
+		path := s.write("bad.yaml", "mysql:\n  hots: db\n")
+		_, err := config.Load([]string{"-config", path}, s.getenv)
+		s.Error(err)
+		s.Contains(err.Error(), "unknown key mysql.hots")
+	})
+
+	s.Run("When a flag is unknown", func() {
+		_, err := config.Load([]string{"-port", "80"}, s.getenv)
+		s.Error(err)
+	})
+}
+
+func (s *SuiteConfig) TestPrintRedacts() {
+	s.env["EVENT_PUBLISHER"] = "webhook"
+	s.env["EVENT_WEBHOOK_URL"] = "https://hooks.local/token"
+	c, err := config.Load(nil, s.getenv)
+	s.Require().NoError(err)
+	var out bytes.Buffer
+	c.Print(&out)
+	s.Contains(out.String(), "mysql.user=user\n")
+	s.Contains(out.String(), "mysql.password=******\n")
+	s.Contains(out.String(), "events.webhook_url=******\n")
+	s.Contains(out.String(), "http.read_header_timeout=5s\n")
+	s.NotContains(out.String(), "secret")
+	s.NotContains(out.String(), "token")
+}
+
+func TestSuiteConfig(t *testing.T) {
+	suite.Run(t, new(SuiteConfig))
+}
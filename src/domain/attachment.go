@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAttachmentTooLarge = errors.New("attachment_too_large")
+	ErrAttachmentType     = errors.New("attachment_type")
+	ErrAttachmentConflict = errors.New("attachment_conflict")
+)
+
+type Attachment struct {
+	ID          uuid.UUID  `json:"id"`
+	TaskID      uuid.UUID  `json:"task_id"`
+	Name        string     `json:"name"`
+	ContentType string     `json:"content_type"`
+	Size        int64      `json:"size"`
+	Key         string     `json:"-"`
+	URL         string     `json:"url,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+}
+
+func NewAttachment(taskID uuid.UUID, name, contentType string) *Attachment {
+	return &Attachment{
+		ID:          uuid.New(),
+		TaskID:      taskID,
+		Name:        name,
+		ContentType: contentType,
+	}
+}
+
+// AttachmentPolicy limits what can be uploaded to a task.
+type AttachmentPolicy struct {
+	MaxSize int64
+	Types   map[string]bool
+}
+
+func NewAttachmentPolicy(maxSize int64, types ...string) *AttachmentPolicy {
+	allowed := make(map[string]bool, len(types))
+	for _, t := range types {
+		allowed[t] = true
+	}
+	return &AttachmentPolicy{
+		MaxSize: maxSize,
+		Types:   allowed,
+	}
+}
+
+func (p *AttachmentPolicy) Allows(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return p.Types[mediaType]
+}
+
+type AttachmentUseCase interface {
+	Upload(ctx context.Context, taskID string, name string, body io.Reader) (*Attachment, error)
+	Fetch(ctx context.Context, taskID string) ([]*Attachment, error)
+	GetByID(ctx context.Context, taskID string, id string) (*Attachment, error)
+	Open(ctx context.Context, taskID string, id string) (*Attachment, BlobReader, error)
+	Delete(ctx context.Context, taskID string, id string) error
+	DeleteByTask(ctx context.Context, taskID string) error
+}
+
+// AttachmentRepository serializes the writers of a blob key: Insert runs
+// check after saving the row and before committing it, and Release runs
+// drop only when no row points at key, so a blob is never dropped under an
+// attachment being saved.
+type AttachmentRepository interface {
+	Fetch(ctx context.Context, taskID string) ([]*Attachment, error)
+	GetByID(ctx context.Context, taskID string, id string) (*Attachment, error)
+	Insert(ctx context.Context, a *Attachment, check func(context.Context) error) error
+	Delete(ctx context.Context, id string) error
+	Release(ctx context.Context, key string, drop func(context.Context) error) error
+}
+
+// Blob is stored content addressed by the hex SHA-256 of its bytes, so the
+// same file uploaded twice is kept once.
+type Blob struct {
+	Key  string
+	Size int64
+}
+
+type BlobReader interface {
+	io.ReadSeeker
+	io.Closer
+}
+
+type BlobStore interface {
+	Put(ctx context.Context, contentType string, body io.Reader) (*Blob, error)
+	Open(ctx context.Context, key string) (BlobReader, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// BlobURLer is implemented by blob stores that can hand out a direct,
+// time limited download url.
+type BlobURLer interface {
+	URL(ctx context.Context, key string) (string, error)
+}
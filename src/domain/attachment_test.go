@@ -0,0 +1,27 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAttachment(t *testing.T) {
+	assert := assert.New(t)
+	taskID := uuid.New()
+	a := domain.NewAttachment(taskID, "spec.pdf", "application/pdf")
+	assert.NotEqual(uuid.Nil, a.ID)
+	assert.Equal(taskID, a.TaskID)
+	assert.Equal("spec.pdf", a.Name)
+}
+
+func TestAttachmentPolicyAllows(t *testing.T) {
+	assert := assert.New(t)
+	policy := domain.NewAttachmentPolicy(1024, "image/png", "text/plain")
+	assert.True(policy.Allows("image/png"))
+	assert.True(policy.Allows("text/plain; charset=utf-8"))
+	assert.False(policy.Allows("application/zip"))
+	assert.False(policy.Allows(""))
+}
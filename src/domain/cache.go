@@ -0,0 +1,17 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrCacheMiss = errors.New("cache_miss")
+
+// Cache keeps values for ttl, or until they are deleted or evicted. Get
+// returns ErrCacheMiss when the key is not there.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TaskCreated   = "task.created"
+	TaskUpdated   = "task.updated"
+	TaskDeleted   = "task.deleted"
+	TaskCompleted = "task.completed"
+)
+
+var (
+	ErrEventExpired = errors.New("event_expired")
+	ErrBrokerClosed = errors.New("broker_closed")
+)
+
+// Event is a task change recorded in the outbox. ID doubles as the
+// idempotency key consumers use to discard redeliveries. Attempts counts
+// the failed publishes of a pending event.
+type Event struct {
+	ID        uuid.UUID       `json:"id"`
+	Type      string          `json:"type"`
+	TaskID    uuid.UUID       `json:"task_id"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	CreatedAt *time.Time      `json:"created_at,omitempty"`
+	UserID    string          `json:"user_id,omitempty"`
+	Attempts  int             `json:"-"`
+}
+
+func NewEvent(eventType string, t *Task) (*Event, error) {
+	payload, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	created_at := time.Now()
+	return &Event{
+		ID:        uuid.New(),
+		Type:      eventType,
+		TaskID:    t.ID,
+		Payload:   payload,
+		CreatedAt: &created_at,
+	}, nil
+}
+
+type Publisher interface {
+	Publish(ctx context.Context, e *Event) error
+}
+
+// OutboxRepository hands out the events neither published nor dead. A
+// dead event failed too many times and is kept only to be looked into.
+type OutboxRepository interface {
+	Pending(ctx context.Context, limit int) ([]*Event, error)
+	MarkPublished(ctx context.Context, id uuid.UUID) error
+	MarkFailed(ctx context.Context, id uuid.UUID, reason string) error
+	MarkDead(ctx context.Context, id uuid.UUID, reason string) error
+}
+
+// Broker fans task events out to the live subscribers of this process.
+// Subscribe replays what was published after lastEventID and returns
+// ErrEventExpired when that event already left the replay buffer. The
+// channel is closed when ctx is done, the subscriber falls behind or the
+// broker is closed on shutdown; later subscriptions fail with
+// ErrBrokerClosed.
+type Broker interface {
+	Publish(e *Event)
+	Subscribe(ctx context.Context, userID string, lastEventID string) (<-chan *Event, error)
+	Close()
+}
@@ -0,0 +1,34 @@
+package domain_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewEvent(t *testing.T) {
+	assert := assert.New(t)
+	task := domain.NewTask("title", "description")
+	task.ID = uuid.New()
+
+	event, err := domain.NewEvent(domain.TaskCreated, task)
+	assert.NoError(err)
+	assert.NotEqual(uuid.Nil, event.ID)
+	assert.Equal(domain.TaskCreated, event.Type)
+	assert.Equal(task.ID, event.TaskID)
+	assert.NotNil(event.CreatedAt)
+
+	var payload domain.Task
+	assert.NoError(json.Unmarshal(event.Payload, &payload))
+	assert.Equal(task.Title, payload.Title)
+}
+
+func TestNewEventUniqueID(t *testing.T) {
+	task := domain.NewTask("title", "description")
+	first, _ := domain.NewEvent(domain.TaskUpdated, task)
+	second, _ := domain.NewEvent(domain.TaskUpdated, task)
+	assert.NotEqual(t, first.ID, second.ID)
+}
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrLeaseLost is returned by Complete when the key was reserved again by
+// another request after the lease of this one ran out.
+var ErrLeaseLost = errors.New("idempotency_lease_lost")
+
+// IdempotencyRecord is what is kept of the first request sent with an
+// Idempotency-Key. StatusCode stays zero until that request is answered.
+type IdempotencyRecord struct {
+	RequestHash string
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+func (r *IdempotencyRecord) Pending() bool {
+	return r.StatusCode == 0
+}
+
+// IdempotencyStore keeps the records of the keys. Reserve saves a pending
+// record for a new or expired key that lives for lease and returns nil; for
+// a live key it returns the record saved before. Complete stores the answer
+// and keeps it for ttl. Complete and Release only touch a pending record
+// still held with the token it was reserved with.
+type IdempotencyStore interface {
+	Reserve(ctx context.Context, key string, token string, requestHash string, lease time.Duration) (*IdempotencyRecord, error)
+	Complete(ctx context.Context, key string, token string, record *IdempotencyRecord, ttl time.Duration) error
+	Release(ctx context.Context, key string, token string) error
+	Purge(ctx context.Context) (int64, error)
+}
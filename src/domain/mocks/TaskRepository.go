@@ -14,6 +14,57 @@ type TaskRepository struct {
 	mock.Mock
 }
 
+// Complete provides a mock function with given fields: ctx, uuid
+func (_m *TaskRepository) Complete(ctx context.Context, uuid string) (*domain.Task, bool, error) {
+	ret := _m.Called(ctx, uuid)
+
+	var r0 *domain.Task
+	if rf, ok := ret.Get(0).(func(context.Context, string) *domain.Task); ok {
+		r0 = rf(ctx, uuid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Task)
+		}
+	}
+
+	var r1 bool
+	if rf, ok := ret.Get(1).(func(context.Context, string) bool); ok {
+		r1 = rf(ctx, uuid)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string) error); ok {
+		r2 = rf(ctx, uuid)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// CountByUser provides a mock function with given fields: ctx, userID
+func (_m *TaskRepository) CountByUser(ctx context.Context, userID string) (int, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Delete provides a mock function with given fields: ctx, uuid
 func (_m *TaskRepository) Delete(ctx context.Context, uuid string) error {
 	ret := _m.Called(ctx, uuid)
@@ -101,3 +152,24 @@ func (_m *TaskRepository) Update(ctx context.Context, uuid string, t *domain.Tas
 
 	return r0
 }
+
+// Upsert provides a mock function with given fields: ctx, uuid, t
+func (_m *TaskRepository) Upsert(ctx context.Context, uuid string, t *domain.Task) (bool, error) {
+	ret := _m.Called(ctx, uuid, t)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string, *domain.Task) bool); ok {
+		r0 = rf(ctx, uuid, t)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *domain.Task) error); ok {
+		r1 = rf(ctx, uuid, t)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+var ErrQuotaExceeded = errors.New("quota_exceeded")
+
+// RateLimit is a token bucket holding up to Burst tokens and refilled at
+// Rate tokens per second. Each request takes one token.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// Bucket is the state a RateLimitStore keeps per client.
+type Bucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter is how long until the next token, zero when allowed.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// Take refills b up to now and takes a token from it when there is one.
+// A nil b is a full bucket.
+func (l RateLimit) Take(b *Bucket, now time.Time) (Bucket, RateLimitResult) {
+	burst := float64(l.Burst)
+	tokens := burst
+	if b != nil {
+		elapsed := now.Sub(b.UpdatedAt).Seconds()
+		if elapsed < 0 {
+			elapsed = 0
+		}
+		tokens = math.Min(burst, b.Tokens+elapsed*l.Rate)
+	}
+
+	res := RateLimitResult{}
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = l.wait(1 - tokens)
+	}
+	res.Remaining = int(math.Floor(tokens))
+	res.Reset = l.wait(burst - tokens)
+	return Bucket{Tokens: tokens, UpdatedAt: now}, res
+}
+
+func (l RateLimit) wait(tokens float64) time.Duration {
+	if l.Rate <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(tokens / l.Rate * float64(time.Second)))
+}
+
+// RateLimitStore keeps the buckets, so replicas sharing a store share the
+// limits. Purge drops the buckets that are full again, which is what a
+// missing bucket stands for.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error)
+	Purge(ctx context.Context) (int64, error)
+}
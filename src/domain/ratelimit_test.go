@@ -0,0 +1,36 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitTake(t *testing.T) {
+	assert := assert.New(t)
+	limit := domain.RateLimit{Rate: 1, Burst: 2}
+	now := time.Now()
+
+	b, res := limit.Take(nil, now)
+	assert.True(res.Allowed)
+	assert.Equal(1, res.Remaining)
+	assert.Equal(time.Second, res.Reset)
+
+	b, res = limit.Take(&b, now)
+	assert.True(res.Allowed)
+	assert.Equal(0, res.Remaining)
+
+	b, res = limit.Take(&b, now.Add(500*time.Millisecond))
+	assert.False(res.Allowed)
+	assert.Equal(500*time.Millisecond, res.RetryAfter)
+	assert.Equal(1500*time.Millisecond, res.Reset)
+
+	b, res = limit.Take(&b, now.Add(time.Second))
+	assert.True(res.Allowed)
+
+	_, res = limit.Take(&b, now.Add(time.Hour))
+	assert.True(res.Allowed)
+	assert.Equal(1, res.Remaining, "the bucket never holds more than the burst")
+}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"errors"
 	"log"
 	"net/url"
 	"strconv"
@@ -37,12 +38,23 @@ func NewMetadata(total int, filter *Filter, msg string) *Metadata {
 	}
 }
 
+var ErrNegativePage = errors.New("bad request: offset and limit can not be negative")
+
 type Filter struct {
 	Offset int
 	Limit  int
 	SortBy string
 }
 
+// Validate rejects the pages the repositories can not serve. Every
+// delivery checks the filter it built before fetching.
+func (f *Filter) Validate() error {
+	if f.Offset < 0 || f.Limit < 0 {
+		return ErrNegativePage
+	}
+	return nil
+}
+
 func NewFilter(qs url.Values) *Filter {
 	return &Filter{
 		Offset: GetIntDefault(qs, "offset", 0),
@@ -27,6 +27,13 @@ func TestNewFilterError(t *testing.T) {
 	filter := domain.NewFilter(val)
 	assert.Equal(t, filter.Offset, 0)
 }
+
+func TestFilterValidate(t *testing.T) {
+	assert.NoError(t, (&domain.Filter{Offset: 0, Limit: 0}).Validate())
+	assert.Equal(t, domain.ErrNegativePage, (&domain.Filter{Offset: -1, Limit: 10}).Validate())
+	assert.Equal(t, domain.ErrNegativePage, (&domain.Filter{Limit: -10}).Validate())
+}
+
 func TestNewMetadata(t *testing.T) {
 	val := url.Values{
 		"offset":  []string{"10"},
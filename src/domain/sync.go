@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	SyncUpsert = "upsert"
+	SyncDelete = "delete"
+)
+
+// Conflict policies of a push. A change conflicts when the server changed
+// the same task after the token the client last pulled.
+const (
+	LastWriterWins = "last_writer_wins"
+	ServerWins     = "server_wins"
+)
+
+// Status of each change of a push.
+const (
+	SyncApplied  = "applied"
+	SyncConflict = "conflict"
+	SyncRejected = "rejected"
+)
+
+var (
+	ErrSyncToken = errors.New("sync_token")
+	ErrSyncOp    = errors.New("sync_op")
+	ErrSyncBatch = errors.New("sync_batch_too_large")
+)
+
+// Change is the latest state of a task as of Seq, its position in the
+// change sequence. Task is nil for a delete.
+type Change struct {
+	Seq       int64      `json:"-"`
+	Op        string     `json:"op"`
+	TaskID    uuid.UUID  `json:"task_id"`
+	Task      *Task      `json:"task,omitempty"`
+	ChangedAt *time.Time `json:"changed_at,omitempty"`
+}
+
+// ChangeSet is a page of changes. Token is passed as since to get the
+// next page, or the changes that come later.
+type ChangeSet struct {
+	Changes []*Change `json:"changes"`
+	Token   string    `json:"token"`
+	HasMore bool      `json:"has_more"`
+}
+
+// ClientChange is a change made on a device. ChangedAt is when it was
+// made there; last-writer-wins compares it with the server change.
+type ClientChange struct {
+	Op          string     `json:"op"`
+	TaskID      string     `json:"task_id"`
+	Title       string     `json:"title,omitempty"`
+	Description string     `json:"description,omitempty"`
+	ChangedAt   *time.Time `json:"changed_at,omitempty"`
+}
+
+type SyncPush struct {
+	Since   string          `json:"since"`
+	Changes []*ClientChange `json:"changes"`
+}
+
+// SyncResult tells what became of a client change. Server holds the
+// state that won a conflict.
+type SyncResult struct {
+	TaskID string  `json:"task_id"`
+	Status string  `json:"status"`
+	Error  string  `json:"error,omitempty"`
+	Server *Change `json:"server,omitempty"`
+}
+
+type SyncReply struct {
+	Results []*SyncResult `json:"results"`
+	*ChangeSet
+}
+
+// ParseSyncToken reads a token handed out by a ChangeSet. The empty token
+// starts from the beginning.
+func ParseSyncToken(token string) (int64, error) {
+	if token == "" {
+		return 0, nil
+	}
+	seq, err := strconv.ParseInt(token, 10, 64)
+	if err != nil || seq < 0 {
+		return 0, ErrSyncToken
+	}
+	return seq, nil
+}
+
+func NewSyncToken(seq int64) string {
+	return strconv.FormatInt(seq, 10)
+}
+
+// SyncRepository reads the change sequence kept by the outbox, which
+// records deletes as well. Changes are those made by userID, like the
+// events the live streams send to that user.
+type SyncRepository interface {
+	Changes(ctx context.Context, userID string, since int64, limit int) ([]*Change, error)
+	// LastChange returns nil when the task never changed.
+	LastChange(ctx context.Context, taskID uuid.UUID) (*Change, error)
+}
+
+type SyncUseCase interface {
+	Pull(ctx context.Context, since string, limit int) (*ChangeSet, error)
+	Push(ctx context.Context, p *SyncPush) (*SyncReply, error)
+}
@@ -2,17 +2,24 @@ package domain
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrUUIDFormat  = errors.New("uuid_format")
+	ErrUUIDVersion = errors.New("uuid_version")
+)
+
 type Task struct {
 	ID          uuid.UUID  `json:"id"`
 	Title       string     `json:"title"`
 	Description string     `json:"description"`
 	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
 	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
 }
 
 func NewTask(t, d string) *Task {
@@ -22,6 +29,29 @@ func NewTask(t, d string) *Task {
 	}
 }
 
+// Validate trims the text fields and checks them against the columns they
+// are stored in.
+func (t *Task) Validate() error {
+	return NewValidator().
+		Field("title", &t.Title, Required, MaxLength(255), SingleLine).
+		Field("description", &t.Description, Required, MaxLength(255), MultiLine).
+		Err()
+}
+
+// ParseTaskID checks an id chosen by a client for a new task. Only random
+// (version 4) UUIDs are taken, like the ones the server generates, so ids
+// from different devices do not collide.
+func ParseTaskID(id string) (uuid.UUID, error) {
+	raw, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, ErrUUIDFormat
+	}
+	if raw.Variant() != uuid.RFC4122 || raw.Version() != 4 {
+		return uuid.Nil, ErrUUIDVersion
+	}
+	return raw, nil
+}
+
 type Tasks struct {
 	Data  []*Task
 	Total int
@@ -38,14 +68,25 @@ type TaskUseCase interface {
 	Fetch(ctx context.Context, f *Filter) (*Tasks, error)
 	Insert(ctx context.Context, t *Task) error
 	Update(ctx context.Context, uuid string, t *Task) error
+	// Upsert creates the task with the given id or replaces its title and
+	// description, and tells which one happened.
+	Upsert(ctx context.Context, uuid string, t *Task) (created bool, err error)
 	GetByID(ctx context.Context, uuid string) (*Task, error)
 	Delete(ctx context.Context, uuid string) error
+	// Complete marks the task as done. Completing it again changes nothing.
+	Complete(ctx context.Context, uuid string) (*Task, error)
 }
 
+// TaskRepository stores each task with the user in the context of Insert
+// as its owner.
 type TaskRepository interface {
 	Fetch(ctx context.Context, f *Filter) (*Tasks, error)
 	Insert(ctx context.Context, t *Task) error
 	Update(ctx context.Context, uuid string, t *Task) error
+	Upsert(ctx context.Context, uuid string, t *Task) (created bool, err error)
 	GetByID(ctx context.Context, uuid string) (*Task, error)
 	Delete(ctx context.Context, uuid string) error
+	// Complete tells whether the task was completed by this call or before.
+	Complete(ctx context.Context, uuid string) (t *Task, completed bool, err error)
+	CountByUser(ctx context.Context, userID string) (int, error)
 }
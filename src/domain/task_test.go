@@ -1,6 +1,8 @@
 package domain_test
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/isaias-dgr/todo/src/domain"
@@ -27,3 +29,44 @@ func TestNewTasks(t *testing.T) {
 	ts := domain.NewTasks(tasks, total_task)
 	assert.Equal(ts.Total, total_task)
 }
+
+func TestTaskValidate(t *testing.T) {
+	assert := assert.New(t)
+	task := domain.NewTask("  title ", "line 1\nline 2\t")
+	assert.NoError(task.Validate())
+	assert.Equal("title", task.Title, "The title is not trimmed")
+	assert.Equal("line 1\nline 2", task.Description)
+
+	task = domain.NewTask("bad\ntitle", strings.Repeat("ñ", 256))
+	err := task.Validate()
+	assert.True(errors.Is(err, domain.ErrValidation))
+	var verr *domain.ValidationError
+	assert.True(errors.As(err, &verr))
+	assert.Equal(map[string][]string{
+		"title":       {"contains characters that are not allowed"},
+		"description": {"must be at most 255 characters"},
+	}, verr.Fields)
+
+	task = domain.NewTask(" ", strings.Repeat("ñ", 255))
+	err = task.Validate()
+	assert.True(errors.As(err, &verr))
+	assert.Equal(map[string][]string{"title": {"is required"}}, verr.Fields)
+}
+
+func TestParseTaskID(t *testing.T) {
+	assert := assert.New(t)
+	id, err := domain.ParseTaskID("6F1C1B0E-3A4D-4C8E-9B7A-2D5E8F0A1B2C")
+	assert.NoError(err)
+	assert.Equal("6f1c1b0e-3a4d-4c8e-9b7a-2d5e8f0a1b2c", id.String())
+
+	_, err = domain.ParseTaskID("not-a-uuid")
+	assert.Equal(domain.ErrUUIDFormat, err)
+	for _, id := range []string{
+		"00000000-0000-0000-0000-000000000000",
+		"6f1c1b0e-3a4d-1c8e-9b7a-2d5e8f0a1b2c",
+		"6f1c1b0e-3a4d-4c8e-cb7a-2d5e8f0a1b2c",
+	} {
+		_, err = domain.ParseTaskID(id)
+		assert.Equal(domain.ErrUUIDVersion, err, id)
+	}
+}
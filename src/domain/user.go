@@ -0,0 +1,30 @@
+package domain
+
+import "context"
+
+type (
+	userKey   struct{}
+	apiKeyKey struct{}
+)
+
+// WithUser stores the id of the caller in the context. Authentication
+// happens in front of the service, which only receives the id.
+func WithUser(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userKey{}, userID)
+}
+
+func UserFromContext(ctx context.Context) string {
+	userID, _ := ctx.Value(userKey{}).(string)
+	return userID
+}
+
+// WithAPIKey stores the id of the API key the caller was authenticated
+// with, in front of the service like the user.
+func WithAPIKey(ctx context.Context, keyID string) context.Context {
+	return context.WithValue(ctx, apiKeyKey{}, keyID)
+}
+
+func APIKeyFromContext(ctx context.Context) string {
+	keyID, _ := ctx.Value(apiKeyKey{}).(string)
+	return keyID
+}
@@ -0,0 +1,26 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserFromContext(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("", domain.UserFromContext(context.Background()))
+
+	ctx := domain.WithUser(context.Background(), "user-1")
+	assert.Equal("user-1", domain.UserFromContext(ctx))
+}
+
+func TestAPIKeyFromContext(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("", domain.APIKeyFromContext(context.Background()))
+
+	ctx := domain.WithAPIKey(domain.WithUser(context.Background(), "user-1"), "key-1")
+	assert.Equal("key-1", domain.APIKeyFromContext(ctx))
+	assert.Equal("user-1", domain.UserFromContext(ctx))
+}
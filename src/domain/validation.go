@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var ErrValidation = errors.New("validation_failed")
+
+// ValidationError holds every violation found, by field name.
+type ValidationError struct {
+	Fields map[string][]string `json:"errors"`
+}
+
+func (v *ValidationError) Error() string {
+	return ErrValidation.Error()
+}
+
+func (v *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// Detail lists the violations in a single line, ordered by field.
+func (v *ValidationError) Detail() string {
+	names := make([]string, 0, len(v.Fields))
+	for name := range v.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, name+" "+strings.Join(v.Fields[name], ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Rule checks a value and returns a message when it is not valid.
+type Rule func(value string) string
+
+// Validator collects the violations of several fields, so they can be
+// reported at once.
+type Validator struct {
+	fields map[string][]string
+}
+
+func NewValidator() *Validator {
+	return &Validator{fields: map[string][]string{}}
+}
+
+// Field trims the value in place and then runs every rule on it.
+func (v *Validator) Field(name string, value *string, rules ...Rule) *Validator {
+	*value = strings.TrimSpace(*value)
+	for _, rule := range rules {
+		if msg := rule(*value); msg != "" {
+			v.fields[name] = append(v.fields[name], msg)
+		}
+	}
+	return v
+}
+
+func (v *Validator) Err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: v.fields}
+}
+
+func Required(value string) string {
+	if value == "" {
+		return "is required"
+	}
+	return ""
+}
+
+// MaxLength counts characters, like a varchar column does.
+func MaxLength(max int) Rule {
+	return func(value string) string {
+		if utf8.RuneCountInString(value) > max {
+			return fmt.Sprintf("must be at most %d characters", max)
+		}
+		return ""
+	}
+}
+
+// SingleLine allows printable characters only.
+func SingleLine(value string) string {
+	return allowed(value, func(r rune) bool {
+		return unicode.IsPrint(r)
+	})
+}
+
+// MultiLine allows printable characters, new lines and tabs.
+func MultiLine(value string) string {
+	return allowed(value, func(r rune) bool {
+		return unicode.IsPrint(r) || r == '\n' || r == '\r' || r == '\t'
+	})
+}
+
+func allowed(value string, ok func(r rune) bool) string {
+	if !utf8.ValidString(value) {
+		return "must be valid UTF-8"
+	}
+	for _, r := range value {
+		if !ok(r) {
+			return "contains characters that are not allowed"
+		}
+	}
+	return ""
+}
+
+func OneOf(values ...string) Rule {
+	return func(value string) string {
+		for _, v := range values {
+			if value == v {
+				return ""
+			}
+		}
+		return "must be one of " + strings.Join(values, ", ")
+	}
+}
@@ -0,0 +1,38 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidator(t *testing.T) {
+	assert := assert.New(t)
+	name, kind := "", "weekly"
+	err := domain.NewValidator().
+		Field("name", &name, domain.Required, domain.MaxLength(3)).
+		Field("kind", &kind, domain.OneOf("daily", "monthly")).
+		Err()
+	assert.Equal("validation_failed", err.Error())
+	verr := err.(*domain.ValidationError)
+	assert.Equal([]string{"is required"}, verr.Fields["name"])
+	assert.Equal([]string{"must be one of daily, monthly"}, verr.Fields["kind"])
+	assert.Equal("kind must be one of daily, monthly; name is required", verr.Detail())
+
+	name, kind = "abc", "daily"
+	assert.NoError(domain.NewValidator().
+		Field("name", &name, domain.Required, domain.MaxLength(3)).
+		Field("kind", &kind, domain.OneOf("daily", "monthly")).
+		Err())
+}
+
+func TestCharacterRules(t *testing.T) {
+	assert := assert.New(t)
+	assert.Empty(domain.SingleLine("héllo wörld"))
+	assert.NotEmpty(domain.SingleLine("a\nb"))
+	assert.NotEmpty(domain.SingleLine("a\x00b"))
+	assert.Equal("must be valid UTF-8", domain.SingleLine("\xff"))
+	assert.Empty(domain.MultiLine("a\r\nb\tc"))
+	assert.NotEmpty(domain.MultiLine("a\x1bb"))
+}
@@ -0,0 +1,119 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Set at build time with -ldflags "-X github.com/isaias-dgr/todo/src/health.Commit=...".
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = ""
+)
+
+var ErrShuttingDown = errors.New("shutting_down")
+
+type Build struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+func CurrentBuild() Build {
+	return Build{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+}
+
+// Check fails when a dependency the service needs to take traffic is not
+// usable.
+type Check func(ctx context.Context) error
+
+type named struct {
+	name  string
+	check Check
+}
+
+// Checker runs the readiness checks together, each one bounded by the
+// timeout. Once Shutdown is called the service reports itself not ready so
+// load balancers stop routing to it while it drains.
+type Checker struct {
+	mu           sync.Mutex
+	checks       []named
+	timeout      time.Duration
+	shuttingDown bool
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, named{name: name, check: check})
+}
+
+func (c *Checker) Shutdown() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.shuttingDown = true
+}
+
+// Ready returns the result of every check by name, "ok" or the error.
+func (c *Checker) Ready(ctx context.Context) (map[string]string, error) {
+	c.mu.Lock()
+	checks := c.checks
+	shuttingDown := c.shuttingDown
+	c.mu.Unlock()
+	if shuttingDown {
+		return map[string]string{}, ErrShuttingDown
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	errs := make([]error, len(checks))
+	var wg sync.WaitGroup
+	for i, n := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			errs[i] = check(ctx)
+		}(i, n.check)
+	}
+	wg.Wait()
+
+	report := map[string]string{}
+	var failed error
+	for i, n := range checks {
+		report[n.name] = "ok"
+		if errs[i] != nil {
+			report[n.name] = errs[i].Error()
+			failed = errs[i]
+		}
+	}
+	return report, failed
+}
+
+// Migrations fails until the database has every migration the binary was
+// built with, so a new version never serves an old schema.
+func Migrations(current func(ctx context.Context) (int64, error), latest int64) Check {
+	return func(ctx context.Context) error {
+		version, err := current(ctx)
+		if err != nil {
+			return err
+		}
+		if version < latest {
+			return errors.New("schema_outdated")
+		}
+		return nil
+	}
+}
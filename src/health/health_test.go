@@ -0,0 +1,67 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/isaias-dgr/todo/src/health"
+	"github.com/stretchr/testify/suite"
+)
+
+type SuiteChecker struct {
+	suite.Suite
+	checker *health.Checker
+}
+
+func (s *SuiteChecker) SetupTest() {
+	s.checker = health.NewChecker(20 * time.Millisecond)
+}
+
+func (s *SuiteChecker) TestReady() {
+	s.checker.Add("database", func(ctx context.Context) error { return nil })
+	report, err := s.checker.Ready(context.TODO())
+	s.NoError(err)
+	s.Equal(map[string]string{"database": "ok"}, report)
+}
+
+func (s *SuiteChecker) TestReadyReportsEveryFailure() {
+	s.checker.Add("database", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	s.checker.Add("relay", func(ctx context.Context) error { return errors.New("relay_stopped") })
+	s.checker.Add("blobs", func(ctx context.Context) error { return nil })
+
+	start := time.Now()
+	report, err := s.checker.Ready(context.TODO())
+	s.Error(err)
+	s.Less(int64(time.Since(start)), int64(time.Second), "the checks must be bounded by the timeout")
+	s.Equal(map[string]string{
+		"database": "context deadline exceeded",
+		"relay":    "relay_stopped",
+		"blobs":    "ok",
+	}, report)
+}
+
+func (s *SuiteChecker) TestShutdown() {
+	s.checker.Add("database", func(ctx context.Context) error { return nil })
+	s.checker.Shutdown()
+	_, err := s.checker.Ready(context.TODO())
+	s.Equal(health.ErrShuttingDown, err)
+}
+
+func (s *SuiteChecker) TestMigrations() {
+	version := func(v int64, err error) func(context.Context) (int64, error) {
+		return func(context.Context) (int64, error) { return v, err }
+	}
+	s.NoError(health.Migrations(version(3, nil), 3)(context.TODO()))
+	s.NoError(health.Migrations(version(4, nil), 3)(context.TODO()), "a newer schema is fine during a rollback")
+	s.EqualError(health.Migrations(version(2, nil), 3)(context.TODO()), "schema_outdated")
+	s.EqualError(health.Migrations(version(0, errors.New("query_context")), 3)(context.TODO()), "query_context")
+}
+
+func TestSuiteChecker(t *testing.T) {
+	suite.Run(t, new(SuiteChecker))
+}
@@ -0,0 +1,48 @@
+package httpx
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+)
+
+type connKey struct{}
+
+// ConnContext keeps the connection in the context of its requests, so
+// Deadlines can reach it. It is meant for http.Server.ConnContext.
+func ConnContext(ctx context.Context, c net.Conn) context.Context {
+	return context.WithValue(ctx, connKey{}, c)
+}
+
+// Deadlines bounds each request like the ReadTimeout and WriteTimeout of
+// http.Server, which can not be lifted for one route: reading the body
+// must end within read and writing the answer within write. The streams
+// get their deadlines cleared instead, so they last while the client
+// stays. Every request sets both, since a keep-alive connection carries
+// the deadlines of the request before.
+func Deadlines(read, write time.Duration, streams ...string) func(http.Handler) http.Handler {
+	streaming := make(map[string]bool, len(streams))
+	for _, path := range streams {
+		streaming[path] = true
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if conn, ok := r.Context().Value(connKey{}).(net.Conn); ok {
+				var readBy, writeBy time.Time
+				if !streaming[r.URL.Path] {
+					now := time.Now()
+					if read > 0 {
+						readBy = now.Add(read)
+					}
+					if write > 0 {
+						writeBy = now.Add(write)
+					}
+				}
+				conn.SetReadDeadline(readBy)
+				conn.SetWriteDeadline(writeBy)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package httpx_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/isaias-dgr/todo/src/httpx"
+	"github.com/stretchr/testify/suite"
+)
+
+type SuiteDeadlines struct {
+	suite.Suite
+	srv *httptest.Server
+}
+
+func (s *SuiteDeadlines) SetupTest() {
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("slow") != "" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Write([]byte("done"))
+	})
+	deadlines := httpx.Deadlines(time.Second, 50*time.Millisecond, "/stream")
+	s.srv = httptest.NewUnstartedServer(deadlines(slow))
+	s.srv.Config.ConnContext = httpx.ConnContext
+	s.srv.Start()
+}
+
+func (s *SuiteDeadlines) TearDownTest() {
+	s.srv.Close()
+}
+
+func (s *SuiteDeadlines) get(path string) (string, error) {
+	res, err := s.srv.Client().Get(s.srv.URL + path)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	return string(body), err
+}
+
+func (s *SuiteDeadlines) TestSlowAnswerIsCut() {
+	body, err := s.get("/task/?slow=1")
+	s.Error(err)
+	s.Empty(body)
+}
+
+func (s *SuiteDeadlines) TestStreamsHaveNoDeadline() {
+	body, err := s.get("/task/")
+	s.Require().NoError(err)
+	s.Equal("done", body)
+
+	// Same connection: the deadline of the request before must not stay.
+	body, err = s.get("/stream?slow=1")
+	s.NoError(err)
+	s.Equal("done", body)
+}
+
+func TestSuiteDeadlines(t *testing.T) {
+	suite.Run(t, new(SuiteDeadlines))
+}
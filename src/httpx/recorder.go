@@ -0,0 +1,68 @@
+package httpx
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Recorder keeps the status code and the size of the response while still
+// letting the SSE stream flush and the WebSocket upgrade hijack the
+// connection.
+type Recorder struct {
+	http.ResponseWriter
+	Code        int
+	Bytes       int
+	wroteHeader bool
+}
+
+func NewRecorder(w http.ResponseWriter) *Recorder {
+	return &Recorder{ResponseWriter: w, Code: http.StatusOK}
+}
+
+func (s *Recorder) WriteHeader(code int) {
+	if !s.wroteHeader {
+		s.Code = code
+		s.wroteHeader = true
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *Recorder) Write(b []byte) (int, error) {
+	s.wroteHeader = true
+	n, err := s.ResponseWriter.Write(b)
+	s.Bytes += n
+	return n, err
+}
+
+func (s *Recorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (s *Recorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := s.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijack_unsupported")
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil {
+		s.Code = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Route is the template of the matched route, so /task/{task_id}/ is one
+// name whatever the id.
+func Route(r *http.Request) string {
+	if current := mux.CurrentRoute(r); current != nil {
+		if tpl, err := current.GetPathTemplate(); err == nil {
+			return tpl
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,43 @@
+package httpx_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/isaias-dgr/todo/src/httpx"
+	"github.com/stretchr/testify/suite"
+)
+
+type SuiteRecorder struct {
+	suite.Suite
+}
+
+func (s *SuiteRecorder) TestRecorder() {
+	rec := httpx.NewRecorder(httptest.NewRecorder())
+	rec.WriteHeader(http.StatusCreated)
+	rec.WriteHeader(http.StatusInternalServerError)
+	rec.Write([]byte("hello"))
+	rec.Write([]byte(" world"))
+	s.Equal(http.StatusCreated, rec.Code)
+	s.Equal(11, rec.Bytes)
+
+	_, _, err := rec.Hijack()
+	s.EqualError(err, "hijack_unsupported")
+}
+
+func (s *SuiteRecorder) TestRoute() {
+	var route string
+	r := mux.NewRouter()
+	r.HandleFunc("/task/{task_id}/", func(w http.ResponseWriter, r *http.Request) {
+		route = httpx.Route(r)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/task/1/", nil))
+	s.Equal("/task/{task_id}/", route)
+	s.Equal("unknown", httpx.Route(httptest.NewRequest(http.MethodGet, "/", nil)))
+}
+
+func TestSuiteRecorder(t *testing.T) {
+	suite.Run(t, new(SuiteRecorder))
+}
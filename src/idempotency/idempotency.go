@@ -0,0 +1,185 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/logging"
+	"go.uber.org/zap"
+)
+
+const (
+	KeyHeader      = "Idempotency-Key"
+	ReplayedHeader = "Idempotent-Replayed"
+	maxKeyLength   = 255
+)
+
+type Keeper struct {
+	Store   domain.IdempotencyStore
+	TTL     time.Duration
+	Lease   time.Duration
+	MaxBody int64
+	L       *zap.SugaredLogger
+}
+
+// NewKeeper remembers the answers to POST and PATCH requests sent with an
+// Idempotency-Key for ttl. A key is held for lease while its request is
+// served. Bodies are hashed as they are read and bodies over maxBody are
+// refused.
+func NewKeeper(store domain.IdempotencyStore, ttl, lease time.Duration, maxBody int64, logger *zap.SugaredLogger) *Keeper {
+	return &Keeper{Store: store, TTL: ttl, Lease: lease, MaxBody: maxBody, L: logger}
+}
+
+// Middleware replays the stored answer when a key comes back with the same
+// request, and refuses it with 422 when the request is different. Keys
+// belong to the user, so two users can pick the same one. Answers with a
+// 5xx, or lost to a panic, are not kept, so the client can try again with
+// the same key.
+func (k *Keeper) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(KeyHeader)
+		if key == "" || (r.Method != http.MethodPost && r.Method != http.MethodPatch) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxKeyLength {
+			writeMessage(w, http.StatusBadRequest, "idempotency_key_too_long")
+			return
+		}
+		ctx := r.Context()
+		log := logging.FromContext(ctx, k.L)
+		body := &spool{}
+		defer body.Close()
+		h := sha256.New()
+		io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+		n, err := io.Copy(io.MultiWriter(h, body), io.LimitReader(r.Body, k.MaxBody+1))
+		switch {
+		case body.err != nil:
+			log.Errorw("Idempotency body not spooled", "error", body.err.Error())
+			writeMessage(w, http.StatusServiceUnavailable, "idempotency_unavailable")
+			return
+		case err != nil:
+			writeMessage(w, http.StatusBadRequest, "body_unreadable")
+			return
+		case n > k.MaxBody:
+			writeMessage(w, http.StatusRequestEntityTooLarge, "body_too_large")
+			return
+		}
+		read, err := body.Reader()
+		if err != nil {
+			log.Errorw("Idempotency body not spooled", "error", err.Error())
+			writeMessage(w, http.StatusServiceUnavailable, "idempotency_unavailable")
+			return
+		}
+		r.Body = ioutil.NopCloser(read)
+
+		scope := domain.UserFromContext(ctx) + "|" + key
+		hash := hex.EncodeToString(h.Sum(nil))
+		token := uuid.New().String()
+		record, err := k.Store.Reserve(ctx, scope, token, hash, k.Lease)
+		switch {
+		case err != nil:
+			log.Errorw("Idempotency key not reserved", "error", err.Error())
+			writeMessage(w, http.StatusServiceUnavailable, "idempotency_unavailable")
+			return
+		case record != nil && record.RequestHash != hash:
+			writeMessage(w, http.StatusUnprocessableEntity, "idempotency_key_mismatch")
+			return
+		case record != nil && record.Pending():
+			w.Header().Set("Retry-After", "1")
+			writeMessage(w, http.StatusConflict, "idempotency_key_in_progress")
+			return
+		case record != nil:
+			if record.ContentType != "" {
+				w.Header().Set("Content-Type", record.ContentType)
+			}
+			w.Header().Set(ReplayedHeader, "true")
+			w.WriteHeader(record.StatusCode)
+			w.Write(record.Body)
+			return
+		}
+
+		// The key is freed when no answer is stored, even when the handler
+		// panics or the caller has gone away.
+		stored := false
+		defer func() {
+			if stored {
+				return
+			}
+			if err := k.Store.Release(context.Background(), scope, token); err != nil {
+				log.Errorw("Idempotency key not released", "error", err.Error())
+			}
+		}()
+
+		rec := &recorder{ResponseWriter: w, code: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		if rec.code >= http.StatusInternalServerError {
+			return
+		}
+		err = k.Store.Complete(context.Background(), scope, token, &domain.IdempotencyRecord{
+			RequestHash: hash,
+			StatusCode:  rec.code,
+			ContentType: w.Header().Get("Content-Type"),
+			Body:        rec.body.Bytes(),
+		}, k.TTL)
+		if err != nil {
+			log.Errorw("Idempotency key not completed", "error", err.Error())
+			return
+		}
+		stored = true
+	})
+}
+
+// Purge deletes the expired keys every interval until ctx is done.
+func (k *Keeper) Purge(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := k.Store.Purge(ctx); err != nil && ctx.Err() == nil {
+				k.L.Errorw("Idempotency purge", "error", err.Error())
+			}
+		}
+	}
+}
+
+func writeMessage(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	jsonResp, _ := json.Marshal(map[string]string{"message": msg})
+	w.Write(jsonResp)
+}
+
+// recorder copies the answer as it is written.
+type recorder struct {
+	http.ResponseWriter
+	code        int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (r *recorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.code = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,187 @@
+package idempotency_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/domain/mocks"
+	"github.com/isaias-dgr/todo/src/idempotency"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type SuiteIdempotency struct {
+	suite.Suite
+	store   *mocks.IdempotencyStore
+	handler http.Handler
+	calls   int
+	code    int
+}
+
+func (s *SuiteIdempotency) SetupTest() {
+	logger, _ := zap.NewProduction()
+	s.store = new(mocks.IdempotencyStore)
+	s.calls = 0
+	s.code = http.StatusAccepted
+	keeper := idempotency.NewKeeper(s.store, time.Hour, time.Minute, 16, logger.Sugar())
+	s.handler = keeper.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(s.code)
+		w.Write([]byte(`{"data":1}`))
+	}))
+}
+
+func (s *SuiteIdempotency) do(method, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/task/", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(idempotency.KeyHeader, key)
+	}
+	req = req.WithContext(domain.WithUser(req.Context(), "user-1"))
+	w := httptest.NewRecorder()
+	s.handler.ServeHTTP(w, req)
+	return w
+}
+
+// first serves a new key and returns the hash the store was given.
+func (s *SuiteIdempotency) first(body string) string {
+	var hash string
+	s.store.On("Reserve", mock.Anything, "user-1|k1", mock.Anything, mock.Anything, time.Minute).
+		Run(func(args mock.Arguments) { hash = args.String(3) }).
+		Return(nil, nil).Once()
+	s.store.On("Complete", mock.Anything, "user-1|k1", mock.Anything, mock.Anything, time.Hour).Return(nil).Once()
+	s.do("POST", "k1", body)
+	return hash
+}
+
+func (s *SuiteIdempotency) TestWithoutKey() {
+	s.Equal(http.StatusAccepted, s.do("POST", "", `{}`).Code)
+	s.Equal(http.StatusAccepted, s.do("PUT", "k1", `{}`).Code)
+	s.Equal(2, s.calls)
+	s.store.AssertNotCalled(s.T(), "Reserve", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *SuiteIdempotency) TestFirstRequestIsKept() {
+	s.first(`{"a":1}`)
+	s.Equal(1, s.calls)
+	s.store.AssertCalled(s.T(), "Complete", mock.Anything, "user-1|k1", mock.Anything, mock.MatchedBy(func(r *domain.IdempotencyRecord) bool {
+		return r.StatusCode == http.StatusAccepted && r.ContentType == "application/json" && string(r.Body) == `{"data":1}`
+	}), time.Hour)
+	s.store.AssertNotCalled(s.T(), "Release", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *SuiteIdempotency) TestReplay() {
+	hash := s.first(`{"a":1}`)
+	s.store.On("Reserve", mock.Anything, "user-1|k1", mock.Anything, mock.Anything, time.Minute).Return(&domain.IdempotencyRecord{
+		RequestHash: hash,
+		StatusCode:  http.StatusAccepted,
+		ContentType: "application/json",
+		Body:        []byte(`{"data":1}`),
+	}, nil)
+
+	w := s.do("POST", "k1", `{"a":1}`)
+	s.Equal(1, s.calls, "the handler runs once")
+	s.Equal(http.StatusAccepted, w.Code)
+	s.Equal(`{"data":1}`, w.Body.String())
+	s.Equal("true", w.Header().Get(idempotency.ReplayedHeader))
+
+	w = s.do("POST", "k1", `{"a":2}`)
+	s.Equal(http.StatusUnprocessableEntity, w.Code)
+	s.JSONEq(`{"message":"idempotency_key_mismatch"}`, w.Body.String())
+}
+
+func (s *SuiteIdempotency) TestInProgress() {
+	hash := s.first(`{}`)
+	s.store.On("Reserve", mock.Anything, "user-1|k1", mock.Anything, mock.Anything, time.Minute).
+		Return(&domain.IdempotencyRecord{RequestHash: hash}, nil)
+	w := s.do("POST", "k1", `{}`)
+	s.Equal(http.StatusConflict, w.Code)
+	s.Equal("1", w.Header().Get("Retry-After"))
+}
+
+func (s *SuiteIdempotency) TestServerErrorsAreNotKept() {
+	s.code = http.StatusInternalServerError
+	s.store.On("Reserve", mock.Anything, "user-1|k1", mock.Anything, mock.Anything, time.Minute).Return(nil, nil)
+	s.store.On("Release", mock.Anything, "user-1|k1", mock.Anything).Return(nil)
+	s.do("POST", "k1", `{}`)
+	s.store.AssertCalled(s.T(), "Release", mock.Anything, "user-1|k1", mock.Anything)
+	s.store.AssertNotCalled(s.T(), "Complete", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *SuiteIdempotency) TestPanicsReleaseTheKey() {
+	logger, _ := zap.NewProduction()
+	keeper := idempotency.NewKeeper(s.store, time.Hour, time.Minute, 16, logger.Sugar())
+	s.handler = keeper.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	s.store.On("Reserve", mock.Anything, "user-1|k1", mock.Anything, mock.Anything, time.Minute).Return(nil, nil)
+	s.store.On("Release", mock.Anything, "user-1|k1", mock.Anything).Return(nil)
+	s.PanicsWithValue("boom", func() { s.do("POST", "k1", `{}`) })
+	s.store.AssertCalled(s.T(), "Release", mock.Anything, "user-1|k1", mock.Anything)
+	s.store.AssertNotCalled(s.T(), "Complete", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *SuiteIdempotency) TestFailedCompleteReleasesTheKey() {
+	s.store.On("Reserve", mock.Anything, "user-1|k1", mock.Anything, mock.Anything, time.Minute).Return(nil, nil)
+	s.store.On("Complete", mock.Anything, "user-1|k1", mock.Anything, mock.Anything, time.Hour).Return(errors.New("query_exec"))
+	s.store.On("Release", mock.Anything, "user-1|k1", mock.Anything).Return(nil)
+	s.Equal(http.StatusAccepted, s.do("POST", "k1", `{}`).Code)
+	s.store.AssertCalled(s.T(), "Release", mock.Anything, "user-1|k1", mock.Anything)
+}
+
+func (s *SuiteIdempotency) TestTheLeaseTokenIsPassedOn() {
+	var token string
+	s.store.On("Reserve", mock.Anything, "user-1|k1", mock.Anything, mock.Anything, time.Minute).
+		Run(func(args mock.Arguments) { token = args.String(2) }).
+		Return(nil, nil).Twice()
+	s.store.On("Complete", mock.Anything, "user-1|k1", mock.Anything, mock.Anything, time.Hour).Return(domain.ErrLeaseLost).Once()
+	s.store.On("Release", mock.Anything, "user-1|k1", mock.Anything).Return(nil).Once()
+
+	s.do("POST", "k1", `{}`)
+	s.NotEmpty(token)
+	s.store.AssertCalled(s.T(), "Complete", mock.Anything, "user-1|k1", token, mock.Anything, time.Hour)
+	s.store.AssertCalled(s.T(), "Release", mock.Anything, "user-1|k1", token)
+
+	first := token
+	s.store.On("Complete", mock.Anything, "user-1|k1", mock.Anything, mock.Anything, time.Hour).Return(nil).Once()
+	s.do("POST", "k1", `{}`)
+	s.NotEqual(first, token, "every request holds its own lease")
+}
+
+func (s *SuiteIdempotency) TestLargeBodiesAreSpooled() {
+	logger, _ := zap.NewProduction()
+	var read []byte
+	keeper := idempotency.NewKeeper(s.store, time.Hour, time.Minute, 1<<20, logger.Sugar())
+	s.handler = keeper.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		read, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	body := strings.Repeat("a", 200<<10)
+	hash := s.first(body)
+
+	s.Equal(body, string(read), "the handler reads the whole body")
+	sum := sha256.Sum256([]byte("POST /task/\n" + body))
+	s.Equal(hex.EncodeToString(sum[:]), hash)
+}
+
+func (s *SuiteIdempotency) TestRefusals() {
+	s.store.On("Reserve", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, errors.New("tx_begin"))
+	s.Equal(http.StatusServiceUnavailable, s.do("PATCH", "k1", `{}`).Code)
+	s.Equal(http.StatusRequestEntityTooLarge, s.do("POST", "k1", `{"title":"too long"}`).Code)
+	s.Equal(http.StatusBadRequest, s.do("POST", strings.Repeat("k", 256), `{}`).Code)
+	s.Equal(0, s.calls)
+}
+
+func TestSuiteIdempotency(t *testing.T) {
+	suite.Run(t, new(SuiteIdempotency))
+}
@@ -0,0 +1,59 @@
+package idempotency
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// memoryBody is how much of a body is kept in memory before it is moved to
+// a temporary file.
+const memoryBody = 64 << 10
+
+// spool keeps a request body while it is hashed, so the handler can read
+// it again. Attachment uploads are large, so past memoryBody bytes it goes
+// to a temporary file.
+type spool struct {
+	buf  bytes.Buffer
+	file *os.File
+	err  error
+}
+
+func (s *spool) Write(p []byte) (int, error) {
+	if s.file == nil && s.buf.Len()+len(p) > memoryBody {
+		s.file, s.err = ioutil.TempFile("", "idempotency-")
+		if s.err != nil {
+			return 0, s.err
+		}
+		if _, s.err = s.buf.WriteTo(s.file); s.err != nil {
+			return 0, s.err
+		}
+	}
+	if s.file == nil {
+		return s.buf.Write(p)
+	}
+	var n int
+	n, s.err = s.file.Write(p)
+	return n, s.err
+}
+
+// Reader reads the body from the start.
+func (s *spool) Reader() (io.Reader, error) {
+	if s.file == nil {
+		return &s.buf, nil
+	}
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return s.file, nil
+}
+
+// Close removes the temporary file, if there is one.
+func (s *spool) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	s.file.Close()
+	return os.Remove(s.file.Name())
+}
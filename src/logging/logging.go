@@ -0,0 +1,102 @@
+package logging
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/httpx"
+	"github.com/isaias-dgr/todo/src/tracing"
+	"go.uber.org/zap"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+type ctxKey struct{}
+
+type requestIDKey struct{}
+
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+func WithLogger(ctx context.Context, l *zap.SugaredLogger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext returns the logger of the request in ctx, or fallback outside
+// of a request, with the ids of the current span added. A nil fallback
+// means the global zap logger.
+func FromContext(ctx context.Context, fallback *zap.SugaredLogger) *zap.SugaredLogger {
+	l, ok := ctx.Value(ctxKey{}).(*zap.SugaredLogger)
+	if !ok {
+		l = fallback
+	}
+	if l == nil {
+		l = zap.S()
+	}
+	return tracing.Logger(ctx, l)
+}
+
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Sampling keeps a share of the lines of requests that went well. Failed
+// requests and requests slower than Slow are always logged.
+type Sampling struct {
+	Ratio float64
+	Slow  time.Duration
+}
+
+func (s Sampling) keep(code int, elapsed time.Duration) bool {
+	if code >= http.StatusBadRequest || (s.Slow > 0 && elapsed >= s.Slow) {
+		return true
+	}
+	return s.Ratio >= 1 || rand.Float64() < s.Ratio
+}
+
+// Middleware takes the request id from X-Request-ID, or makes one, sends
+// it back, puts a logger tagged with it in the context and writes one line
+// per request once it is served. It has to run after the user is known.
+func Middleware(l *zap.SugaredLogger, sampling Sampling) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			id := r.Header.Get(RequestIDHeader)
+			if !validRequestID.MatchString(id) {
+				id = uuid.New().String()
+			}
+			w.Header().Set(RequestIDHeader, id)
+
+			reqLogger := l.With("request_id", id)
+			ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+			ctx = WithLogger(ctx, reqLogger)
+			rec := httpx.NewRecorder(w)
+			next.ServeHTTP(rec, r.WithContext(ctx))
+
+			elapsed := time.Since(start)
+			if !sampling.keep(rec.Code, elapsed) {
+				return
+			}
+			log := FromContext(ctx, nil).Infow
+			switch {
+			case rec.Code >= http.StatusInternalServerError:
+				log = FromContext(ctx, nil).Errorw
+			case rec.Code >= http.StatusBadRequest:
+				log = FromContext(ctx, nil).Warnw
+			}
+			log("Request",
+				"method", r.Method,
+				"route", httpx.Route(r),
+				"path", r.URL.Path,
+				"status", rec.Code,
+				"bytes", rec.Bytes,
+				"duration", elapsed,
+				"user", domain.UserFromContext(ctx))
+		})
+	}
+}
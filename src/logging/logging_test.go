@@ -0,0 +1,128 @@
+package logging_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/logging"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type SuiteLogging struct {
+	suite.Suite
+	logs   *observer.ObservedLogs
+	logger *zap.SugaredLogger
+}
+
+func (s *SuiteLogging) SetupTest() {
+	core, logs := observer.New(zap.DebugLevel)
+	s.logs = logs
+	s.logger = zap.New(core).Sugar()
+}
+
+func (s *SuiteLogging) router(sampling logging.Sampling, handler http.HandlerFunc) *mux.Router {
+	r := mux.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(domain.WithUser(r.Context(), "user-1")))
+		})
+	})
+	r.Use(logging.Middleware(s.logger, sampling))
+	r.HandleFunc("/task/{task_id}/", handler)
+	return r
+}
+
+func (s *SuiteLogging) TestMiddleware() {
+	var inside string
+	r := s.router(logging.Sampling{Ratio: 1}, func(w http.ResponseWriter, r *http.Request) {
+		inside = logging.RequestID(r.Context())
+		logging.FromContext(r.Context(), nil).Info("in the handler")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("missing"))
+	})
+
+	s.Run("When the caller sends a request id", func() {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/task/1/", nil)
+		req.Header.Set(logging.RequestIDHeader, "req-1")
+		r.ServeHTTP(w, req)
+
+		s.Equal("req-1", w.Header().Get(logging.RequestIDHeader))
+		s.Equal("req-1", inside)
+		entries := s.logs.TakeAll()
+		s.Require().Len(entries, 2)
+		s.Equal("req-1", entries[0].ContextMap()["request_id"])
+		line := entries[1]
+		s.Equal("Request", line.Message)
+		s.Equal(zapcore.WarnLevel, line.Level)
+		fields := line.ContextMap()
+		s.Equal("req-1", fields["request_id"])
+		s.Equal("GET", fields["method"])
+		s.Equal("/task/{task_id}/", fields["route"])
+		s.Equal("/task/1/", fields["path"])
+		s.Equal(int64(404), fields["status"])
+		s.Equal(int64(7), fields["bytes"])
+		s.Equal("user-1", fields["user"])
+		s.Contains(fields, "duration")
+	})
+
+	s.Run("When the request id is missing or invalid", func() {
+		for _, id := range []string{"", "bad id\n"} {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/task/1/", nil)
+			req.Header.Set(logging.RequestIDHeader, id)
+			r.ServeHTTP(w, req)
+			s.Len(w.Header().Get(logging.RequestIDHeader), 36)
+			s.Equal(w.Header().Get(logging.RequestIDHeader), inside)
+		}
+	})
+}
+
+func (s *SuiteLogging) TestSampling() {
+	code := http.StatusOK
+	delay := time.Duration(0)
+	r := s.router(logging.Sampling{Ratio: 0, Slow: 20 * time.Millisecond}, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		w.WriteHeader(code)
+	})
+	serve := func() {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/task/1/", nil))
+	}
+
+	serve()
+	s.Equal(0, s.logs.Len(), "fast successful requests are sampled out")
+
+	code = http.StatusInternalServerError
+	serve()
+	s.Require().Equal(1, s.logs.Len(), "failed requests are always logged")
+	s.Equal(zapcore.ErrorLevel, s.logs.TakeAll()[0].Level)
+
+	code = http.StatusOK
+	delay = 25 * time.Millisecond
+	serve()
+	s.Equal(1, s.logs.Len(), "slow requests are always logged")
+}
+
+func (s *SuiteLogging) TestFromContext() {
+	fallback := s.logger.With("component", "relay")
+	logging.FromContext(context.TODO(), fallback).Info("outside")
+	logging.FromContext(logging.WithLogger(context.TODO(), s.logger.With("request_id", "req-2")), fallback).Info("inside")
+
+	entries := s.logs.AllUntimed()
+	s.Require().Len(entries, 2)
+	s.Equal("relay", entries[0].ContextMap()["component"])
+	s.Equal("req-2", entries[1].ContextMap()["request_id"])
+	s.NotContains(entries[1].ContextMap(), "component")
+}
+
+func TestSuiteLogging(t *testing.T) {
+	suite.Run(t, new(SuiteLogging))
+}
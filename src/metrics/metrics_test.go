@@ -0,0 +1,99 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/domain/mocks"
+	"github.com/isaias-dgr/todo/src/metrics"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type SuiteMetrics struct {
+	suite.Suite
+	m      *metrics.Metrics
+	router *mux.Router
+}
+
+func (s *SuiteMetrics) SetupTest() {
+	s.m = metrics.New()
+	s.router = mux.NewRouter()
+	s.router.Use(s.m.Middleware)
+	metrics.NewMetricsHandler(s.router, s.m)
+}
+
+func (s *SuiteMetrics) scrape() string {
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	s.Equal(http.StatusOK, w.Code)
+	body, _ := ioutil.ReadAll(w.Body)
+	return string(body)
+}
+
+func (s *SuiteMetrics) TestMiddlewareUsesRouteTemplate() {
+	s.router.HandleFunc("/task/{task_id}/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}).Methods("GET")
+
+	for _, id := range []string{"a", "b"} {
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/task/"+id+"/", nil))
+	}
+	out := s.scrape()
+	s.Contains(out, `todo_http_requests_total{code="404",method="GET",route="/task/{task_id}/"} 2`)
+	s.Contains(out, `todo_http_request_duration_seconds_count{method="GET",route="/task/{task_id}/"} 2`)
+}
+
+func (s *SuiteMetrics) TestMiddlewareKeepsFlusher() {
+	s.router.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		_, ok := w.(http.Flusher)
+		s.True(ok)
+		_, ok = w.(http.Hijacker)
+		s.True(ok)
+	})
+	s.router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/stream", nil))
+	s.Contains(s.scrape(), `todo_http_requests_total{code="200",method="GET",route="/stream"} 1`)
+}
+
+func (s *SuiteMetrics) TestQueryObserver() {
+	observe := s.m.QueryObserver("task")
+	observe("count", time.Millisecond, nil)
+	observe("Insert", time.Millisecond, errors.New("query_exec"))
+	out := s.scrape()
+	s.Contains(out, `todo_repository_query_duration_seconds_count{method="count",repository="task",result="ok"} 1`)
+	s.Contains(out, `todo_repository_query_duration_seconds_count{method="Insert",repository="task",result="error"} 1`)
+}
+
+func (s *SuiteMetrics) TestTaskUseCase() {
+	next := new(mocks.TaskUseCase)
+	next.On("Insert", mock.Anything, mock.Anything).Return(nil).Once()
+	next.On("Insert", mock.Anything, mock.Anything).Return(errors.New("conflict_insert")).Once()
+	uc := metrics.NewTaskUseCase(next, s.m)
+
+	s.NoError(uc.Insert(context.TODO(), domain.NewTask("t", "d")))
+	s.Error(uc.Insert(context.TODO(), domain.NewTask("t", "d")))
+	out := s.scrape()
+	s.Contains(out, "todo_tasks_created_total 1")
+	s.Contains(out, `todo_usecase_duration_seconds_count{method="Insert",result="ok"} 1`)
+	s.Contains(out, `todo_usecase_duration_seconds_count{method="Insert",result="error"} 1`)
+}
+
+func (s *SuiteMetrics) TestTaskCount() {
+	repo := new(mocks.TaskRepository)
+	repo.On("Fetch", mock.Anything, mock.MatchedBy(func(f *domain.Filter) bool { return f.Limit == 0 })).
+		Return(domain.NewTasks(nil, 42), nil)
+	s.m.RegisterTaskCount(repo, time.Second)
+	s.Contains(s.scrape(), "todo_tasks_total 42")
+}
+
+func TestSuiteMetrics(t *testing.T) {
+	suite.Run(t, new(SuiteMetrics))
+}
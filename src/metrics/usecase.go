@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/isaias-dgr/todo/src/domain"
+)
+
+type taskUseCase struct {
+	next domain.TaskUseCase
+	m    *Metrics
+}
+
+// NewTaskUseCase times every call to next and counts the tasks it creates.
+func NewTaskUseCase(next domain.TaskUseCase, m *Metrics) domain.TaskUseCase {
+	return &taskUseCase{next: next, m: m}
+}
+
+func (t *taskUseCase) observe(method string, start time.Time, err error) {
+	t.m.useCases.WithLabelValues(method, result(err)).Observe(time.Since(start).Seconds())
+}
+
+func (t *taskUseCase) Fetch(ctx context.Context, f *domain.Filter) (*domain.Tasks, error) {
+	start := time.Now()
+	tasks, err := t.next.Fetch(ctx, f)
+	t.observe("Fetch", start, err)
+	return tasks, err
+}
+
+func (t *taskUseCase) Insert(ctx context.Context, ta *domain.Task) error {
+	start := time.Now()
+	err := t.next.Insert(ctx, ta)
+	t.observe("Insert", start, err)
+	if err == nil {
+		t.m.tasksCreated.Inc()
+	}
+	return err
+}
+
+func (t *taskUseCase) Update(ctx context.Context, uuid string, ta *domain.Task) error {
+	start := time.Now()
+	err := t.next.Update(ctx, uuid, ta)
+	t.observe("Update", start, err)
+	return err
+}
+
+func (t *taskUseCase) Upsert(ctx context.Context, uuid string, ta *domain.Task) (bool, error) {
+	start := time.Now()
+	created, err := t.next.Upsert(ctx, uuid, ta)
+	t.observe("Upsert", start, err)
+	if created {
+		t.m.tasksCreated.Inc()
+	}
+	return created, err
+}
+
+func (t *taskUseCase) GetByID(ctx context.Context, uuid string) (*domain.Task, error) {
+	start := time.Now()
+	task, err := t.next.GetByID(ctx, uuid)
+	t.observe("GetByID", start, err)
+	return task, err
+}
+
+func (t *taskUseCase) Delete(ctx context.Context, uuid string) error {
+	start := time.Now()
+	err := t.next.Delete(ctx, uuid)
+	t.observe("Delete", start, err)
+	return err
+}
+
+func (t *taskUseCase) Complete(ctx context.Context, uuid string) (*domain.Task, error) {
+	start := time.Now()
+	task, err := t.next.Complete(ctx, uuid)
+	t.observe("Complete", start, err)
+	return task, err
+}
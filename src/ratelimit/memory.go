@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/isaias-dgr/todo/src/domain"
+)
+
+type memoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+	swept   time.Time
+}
+
+type bucket struct {
+	domain.Bucket
+	full time.Time
+}
+
+// NewMemoryStore keeps the buckets of this process only. Buckets that are
+// full again are dropped once a minute.
+func NewMemoryStore(now func() time.Time) domain.RateLimitStore {
+	return &memoryStore{
+		buckets: map[string]*bucket{},
+		now:     now,
+		swept:   now(),
+	}
+}
+
+func (m *memoryStore) Take(ctx context.Context, key string, limit domain.RateLimit) (domain.RateLimitResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	if now.Sub(m.swept) >= time.Minute {
+		m.sweep(now)
+	}
+
+	var state *domain.Bucket
+	if b, ok := m.buckets[key]; ok {
+		state = &b.Bucket
+	}
+	next, res := limit.Take(state, now)
+	m.buckets[key] = &bucket{Bucket: next, full: now.Add(res.Reset)}
+	return res, nil
+}
+
+func (m *memoryStore) Purge(ctx context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sweep(m.now()), nil
+}
+
+func (m *memoryStore) sweep(now time.Time) int64 {
+	var swept int64
+	for key, b := range m.buckets {
+		if !now.Before(b.full) {
+			delete(m.buckets, key)
+			swept++
+		}
+	}
+	m.swept = now
+	return swept
+}
@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/httpx"
+	"github.com/isaias-dgr/todo/src/logging"
+	"go.uber.org/zap"
+)
+
+type Limiter struct {
+	Store domain.RateLimitStore
+	Rules *Rules
+	L     *zap.SugaredLogger
+}
+
+func NewLimiter(store domain.RateLimitStore, rules *Rules, logger *zap.SugaredLogger) *Limiter {
+	return &Limiter{Store: store, Rules: rules, L: logger}
+}
+
+// Middleware takes a token from the bucket of the caller for the route and
+// answers 429 when it is empty. Callers are told apart by the API key they
+// were authenticated with, then by user, then by IP. When the store fails
+// the request goes through.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, limit := l.Rules.For(r.Method, httpx.Route(r))
+		if limit.Burst == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		res, err := l.Store.Take(r.Context(), name+"|"+client(r), limit)
+		if err != nil {
+			logging.FromContext(r.Context(), l.L).Warnw("Rate limit not checked", "error", err.Error())
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(limit.Burst))
+		h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("RateLimit-Reset", strconv.Itoa(seconds(res.Reset)))
+		if !res.Allowed {
+			h.Set("Retry-After", strconv.Itoa(seconds(res.RetryAfter)))
+			h.Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusTooManyRequests)
+			jsonResp, _ := json.Marshal(map[string]string{"message": "rate_limited"})
+			w.Write(jsonResp)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Purge drops the buckets that are full again every interval until ctx is
+// done.
+func (l *Limiter) Purge(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := l.Store.Purge(ctx); err != nil && ctx.Err() == nil {
+				l.L.Errorw("Rate limit purge", "error", err.Error())
+			}
+		}
+	}
+}
+
+// client names the caller. Keys sent in X-API-Key or Authorization are
+// not checked by this service, so they are ignored: any caller could pick
+// one per request and never run out of tokens.
+func client(r *http.Request) string {
+	if key := domain.APIKeyFromContext(r.Context()); key != "" {
+		return "key:" + key
+	}
+	if user := domain.UserFromContext(r.Context()); user != "" {
+		return "user:" + user
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,189 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/domain/mocks"
+	"github.com/isaias-dgr/todo/src/ratelimit"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type SuiteRateLimit struct {
+	suite.Suite
+	now    time.Time
+	store  domain.RateLimitStore
+	router *mux.Router
+}
+
+func (s *SuiteRateLimit) SetupTest() {
+	s.now = time.Now()
+	s.store = ratelimit.NewMemoryStore(func() time.Time { return s.now })
+	rules, err := ratelimit.ParseRules("default=100/1m, POST /task/=2/1m, GET /healthz=off")
+	s.Require().NoError(err)
+	s.router = s.newRouter(s.store, rules)
+}
+
+func (s *SuiteRateLimit) newRouter(store domain.RateLimitStore, rules *ratelimit.Rules) *mux.Router {
+	logger, _ := zap.NewProduction()
+	r := mux.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if user := r.Header.Get("X-User-ID"); user != "" {
+				r = r.WithContext(domain.WithUser(r.Context(), user))
+			}
+			if key := r.Header.Get("X-API-Key-ID"); key != "" {
+				r = r.WithContext(domain.WithAPIKey(r.Context(), key))
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	r.Use(ratelimit.NewLimiter(store, rules, logger.Sugar()).Middleware)
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	r.HandleFunc("/task/", ok).Methods("GET", "POST")
+	r.HandleFunc("/healthz", ok).Methods("GET")
+	return r
+}
+
+func (s *SuiteRateLimit) do(method, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func (s *SuiteRateLimit) TestParseRules() {
+	rules, err := ratelimit.ParseRules("default=600/1m, POST  /task/=60/1m:20, GET /healthz=off")
+	s.NoError(err)
+	s.Equal(domain.RateLimit{Rate: 10, Burst: 600}, rules.Default)
+	s.Equal(domain.RateLimit{Rate: 1, Burst: 20}, rules.Routes["POST /task/"])
+	s.Equal(domain.RateLimit{}, rules.Routes["GET /healthz"])
+
+	name, limit := rules.For("GET", "/task/")
+	s.Equal("default", name)
+	s.Equal(600, limit.Burst)
+
+	for _, bad := range []string{"default", "default=60", "default=0/1m", "default=1/soon", "default=1/1m:0", "/task/=1/1m"} {
+		_, err := ratelimit.ParseRules(bad)
+		s.Error(err, bad)
+	}
+}
+
+func (s *SuiteRateLimit) TestMiddleware() {
+	user := map[string]string{"X-User-ID": "user-1"}
+
+	w := s.do("POST", "/task/", user)
+	s.Equal(http.StatusOK, w.Code)
+	s.Equal("2", w.Header().Get("RateLimit-Limit"))
+	s.Equal("1", w.Header().Get("RateLimit-Remaining"))
+	s.Equal("30", w.Header().Get("RateLimit-Reset"))
+
+	s.Equal(http.StatusOK, s.do("POST", "/task/", user).Code)
+	w = s.do("POST", "/task/", user)
+	s.Equal(http.StatusTooManyRequests, w.Code)
+	s.Equal("30", w.Header().Get("Retry-After"))
+	s.Equal("0", w.Header().Get("RateLimit-Remaining"))
+	s.JSONEq(`{"message":"rate_limited"}`, w.Body.String())
+
+	s.Equal(http.StatusOK, s.do("GET", "/task/", user).Code, "other routes use their own bucket")
+	s.Equal(http.StatusOK, s.do("POST", "/task/", map[string]string{"X-User-ID": "user-2"}).Code)
+	s.Equal(http.StatusOK, s.do("POST", "/task/", nil).Code, "anonymous callers go by IP")
+
+	s.now = s.now.Add(30 * time.Second)
+	s.Equal(http.StatusOK, s.do("POST", "/task/", user).Code, "the bucket refills")
+}
+
+func (s *SuiteRateLimit) TestAPIKeyWinsOverUser() {
+	for i := 0; i < 2; i++ {
+		s.do("POST", "/task/", map[string]string{"X-API-Key-ID": "k1", "X-User-ID": "user-1"})
+	}
+	w := s.do("POST", "/task/", map[string]string{"X-API-Key-ID": "k1", "X-User-ID": "user-3"})
+	s.Equal(http.StatusTooManyRequests, w.Code)
+}
+
+func (s *SuiteRateLimit) TestUnauthenticatedKeysAreIgnored() {
+	for i := 0; i < 2; i++ {
+		w := s.do("POST", "/task/", map[string]string{"X-API-Key": string(rune('a' + i)), "X-User-ID": "user-1"})
+		s.Equal(http.StatusOK, w.Code)
+	}
+	w := s.do("POST", "/task/", map[string]string{"Authorization": "Bearer c", "X-User-ID": "user-1"})
+	s.Equal(http.StatusTooManyRequests, w.Code, "a new key does not give the user a new bucket")
+}
+
+func (s *SuiteRateLimit) TestOffRoutes() {
+	for i := 0; i < 5; i++ {
+		w := s.do("GET", "/healthz", nil)
+		s.Equal(http.StatusOK, w.Code)
+		s.Empty(w.Header().Get("RateLimit-Limit"))
+	}
+}
+
+func (s *SuiteRateLimit) TestStoreFailureLetsRequestsThrough() {
+	store := new(mocks.RateLimitStore)
+	store.On("Take", mock.Anything, mock.Anything, mock.Anything).
+		Return(domain.RateLimitResult{}, errors.New("tx_begin"))
+	rules, _ := ratelimit.ParseRules("default=1/1m")
+	s.router = s.newRouter(store, rules)
+	s.Equal(http.StatusOK, s.do("GET", "/task/", nil).Code)
+}
+
+func (s *SuiteRateLimit) TestMemoryStoreDropsFullBuckets() {
+	limit := domain.RateLimit{Rate: 1, Burst: 1}
+	res, _ := s.store.Take(context.TODO(), "a", limit)
+	s.True(res.Allowed)
+	res, _ = s.store.Take(context.TODO(), "a", limit)
+	s.False(res.Allowed)
+
+	s.now = s.now.Add(time.Minute)
+	res, _ = s.store.Take(context.TODO(), "a", limit)
+	s.True(res.Allowed)
+}
+
+func (s *SuiteRateLimit) TestPurge() {
+	limit := domain.RateLimit{Rate: 1, Burst: 2}
+	s.store.Take(context.TODO(), "idle", limit)
+	s.store.Take(context.TODO(), "busy", domain.RateLimit{Rate: 1.0 / 60, Burst: 2})
+	purged, err := s.store.Purge(context.TODO())
+	s.NoError(err)
+	s.Equal(int64(0), purged)
+
+	s.now = s.now.Add(time.Second)
+	purged, _ = s.store.Purge(context.TODO())
+	s.Equal(int64(1), purged, "only the bucket that is full again is dropped")
+
+	store := new(mocks.RateLimitStore)
+	purges := make(chan struct{}, 2)
+	store.On("Purge", mock.Anything).Return(int64(0), errors.New("query_exec")).
+		Run(func(mock.Arguments) {
+			select {
+			case purges <- struct{}{}:
+			default:
+			}
+		})
+	logger, _ := zap.NewProduction()
+	ctx, cancel := context.WithCancel(context.TODO())
+	done := make(chan struct{})
+	go func() {
+		ratelimit.NewLimiter(store, nil, logger.Sugar()).Purge(ctx, time.Millisecond)
+		close(done)
+	}()
+	<-purges
+	<-purges
+	cancel()
+	<-done
+}
+
+func TestSuiteRateLimit(t *testing.T) {
+	suite.Run(t, new(SuiteRateLimit))
+}
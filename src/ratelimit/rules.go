@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/isaias-dgr/todo/src/domain"
+)
+
+const defaultRule = "default"
+
+// Rules holds the limit of each route, keyed by method and route template
+// as in "POST /task/", and the default for the rest. A limit with no burst
+// means the route is not limited.
+type Rules struct {
+	Default domain.RateLimit
+	Routes  map[string]domain.RateLimit
+}
+
+// ParseRules reads a comma separated list of route=limit pairs. A limit is
+// written as requests/period with an optional :burst, which defaults to
+// the requests, or as off:
+//
+//	default=600/1m, POST /task/=60/1m:20, GET /healthz=off
+func ParseRules(s string) (*Rules, error) {
+	rules := &Rules{Routes: map[string]domain.RateLimit{}}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		eq := strings.LastIndex(part, "=")
+		if eq < 0 {
+			return nil, fmt.Errorf("rule %q: must be route=limit", part)
+		}
+		route := strings.Join(strings.Fields(part[:eq]), " ")
+		limit, err := parseLimit(strings.TrimSpace(part[eq+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", part, err)
+		}
+		if route == defaultRule {
+			rules.Default = limit
+			continue
+		}
+		if len(strings.Fields(route)) != 2 {
+			return nil, fmt.Errorf("rule %q: route must be a method and a path like POST /task/", part)
+		}
+		rules.Routes[route] = limit
+	}
+	return rules, nil
+}
+
+// For returns the bucket name and the limit of a route.
+func (r *Rules) For(method, route string) (string, domain.RateLimit) {
+	name := method + " " + route
+	if limit, ok := r.Routes[name]; ok {
+		return name, limit
+	}
+	return defaultRule, r.Default
+}
+
+func parseLimit(s string) (domain.RateLimit, error) {
+	if s == "off" {
+		return domain.RateLimit{}, nil
+	}
+	invalid := errors.New("limit must be like 60/1m, 60/1m:20 or off")
+	rate, burst := s, ""
+	if colon := strings.Index(s, ":"); colon >= 0 {
+		rate, burst = s[:colon], s[colon+1:]
+	}
+	slash := strings.Index(rate, "/")
+	if slash < 0 {
+		return domain.RateLimit{}, invalid
+	}
+	requests, err := strconv.Atoi(rate[:slash])
+	if err != nil || requests < 1 {
+		return domain.RateLimit{}, invalid
+	}
+	period, err := time.ParseDuration(rate[slash+1:])
+	if err != nil || period <= 0 {
+		return domain.RateLimit{}, invalid
+	}
+	limit := domain.RateLimit{Rate: float64(requests) / period.Seconds(), Burst: requests}
+	if burst != "" {
+		if limit.Burst, err = strconv.Atoi(burst); err != nil || limit.Burst < 1 {
+			return domain.RateLimit{}, invalid
+		}
+	}
+	return limit, nil
+}
@@ -0,0 +1,122 @@
+package broker
+
+import (
+	"context"
+	"sync"
+
+	"github.com/isaias-dgr/todo/src/domain"
+	"go.uber.org/zap"
+)
+
+type subscriber struct {
+	userID string
+	ch     chan *domain.Event
+}
+
+// memoryBroker keeps the last events in a bounded buffer so a client can
+// resume after a reconnect. Subscribers that can't keep up are dropped
+// instead of blocking the use case; they come back with Last-Event-ID.
+type memoryBroker struct {
+	mu     sync.Mutex
+	buffer []*domain.Event
+	size   int
+	queue  int
+	subs   map[*subscriber]struct{}
+	closed bool
+	l      *zap.SugaredLogger
+}
+
+func NewMemoryBroker(size int, queue int, logger *zap.SugaredLogger) domain.Broker {
+	return &memoryBroker{
+		buffer: make([]*domain.Event, 0, size),
+		size:   size,
+		queue:  queue,
+		subs:   map[*subscriber]struct{}{},
+		l:      logger,
+	}
+}
+
+func (b *memoryBroker) Publish(e *domain.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.buffer) == b.size {
+		copy(b.buffer, b.buffer[1:])
+		b.buffer = b.buffer[:len(b.buffer)-1]
+	}
+	b.buffer = append(b.buffer, e)
+
+	for s := range b.subs {
+		if s.userID != e.UserID {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+			b.l.Warnw("Broker", "message", "dropping slow subscriber", "user_id", s.userID)
+			b.remove(s)
+		}
+	}
+}
+
+func (b *memoryBroker) Subscribe(ctx context.Context, userID string, lastEventID string) (<-chan *domain.Event, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, domain.ErrBrokerClosed
+	}
+
+	var replay []*domain.Event
+	if lastEventID != "" {
+		from := -1
+		for i, e := range b.buffer {
+			if e.ID.String() == lastEventID {
+				from = i + 1
+				break
+			}
+		}
+		if from < 0 {
+			return nil, domain.ErrEventExpired
+		}
+		for _, e := range b.buffer[from:] {
+			if e.UserID == userID {
+				replay = append(replay, e)
+			}
+		}
+	}
+
+	s := &subscriber{
+		userID: userID,
+		ch:     make(chan *domain.Event, len(replay)+b.queue),
+	}
+	for _, e := range replay {
+		s.ch <- e
+	}
+	b.subs[s] = struct{}{}
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.remove(s)
+	}()
+	return s.ch, nil
+}
+
+// Close ends every subscription so the streams return before the servers
+// stop waiting for them.
+func (b *memoryBroker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for s := range b.subs {
+		b.remove(s)
+	}
+}
+
+func (b *memoryBroker) remove(s *subscriber) {
+	if _, ok := b.subs[s]; !ok {
+		return
+	}
+	delete(b.subs, s)
+	close(s.ch)
+}
@@ -0,0 +1,111 @@
+package broker_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/task/broker"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type SuiteMemoryBroker struct {
+	suite.Suite
+	broker domain.Broker
+}
+
+func (s *SuiteMemoryBroker) SetupTest() {
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	s.broker = broker.NewMemoryBroker(3, 2, logger.Sugar())
+}
+
+func (s *SuiteMemoryBroker) event(userID string) *domain.Event {
+	task := domain.NewTask("title", "description")
+	task.ID = uuid.New()
+	e, _ := domain.NewEvent(domain.TaskCreated, task)
+	e.UserID = userID
+	return e
+}
+
+func (s *SuiteMemoryBroker) TestPublishFiltersByUser() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := s.broker.Subscribe(ctx, "ana", "")
+	s.Require().NoError(err)
+
+	mine, other := s.event("ana"), s.event("bob")
+	s.broker.Publish(other)
+	s.broker.Publish(mine)
+
+	s.Equal(mine, <-events)
+	s.Empty(events)
+}
+
+func (s *SuiteMemoryBroker) TestSubscribeReplay() {
+	first, second, third := s.event("ana"), s.event("bob"), s.event("ana")
+	s.broker.Publish(first)
+	s.broker.Publish(second)
+	s.broker.Publish(third)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := s.broker.Subscribe(ctx, "ana", first.ID.String())
+	s.Require().NoError(err)
+	s.Equal(third, <-events)
+	s.Empty(events)
+}
+
+func (s *SuiteMemoryBroker) TestSubscribeExpired() {
+	first := s.event("ana")
+	s.broker.Publish(first)
+	for i := 0; i < 3; i++ {
+		s.broker.Publish(s.event("ana"))
+	}
+
+	_, err := s.broker.Subscribe(context.Background(), "ana", first.ID.String())
+	s.Equal(domain.ErrEventExpired, err)
+}
+
+func (s *SuiteMemoryBroker) TestSlowSubscriberIsDropped() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := s.broker.Subscribe(ctx, "ana", "")
+	s.Require().NoError(err)
+
+	for i := 0; i < 3; i++ {
+		s.broker.Publish(s.event("ana"))
+	}
+	received := 0
+	for range events {
+		received++
+	}
+	s.Equal(2, received)
+}
+
+func (s *SuiteMemoryBroker) TestCancelClosesChannel() {
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := s.broker.Subscribe(ctx, "ana", "")
+	s.Require().NoError(err)
+	cancel()
+
+	_, ok := <-events
+	s.False(ok)
+}
+
+func (s *SuiteMemoryBroker) TestClose() {
+	events, err := s.broker.Subscribe(context.Background(), "ana", "")
+	s.Require().NoError(err)
+	s.broker.Close()
+
+	_, ok := <-events
+	s.False(ok)
+	_, err = s.broker.Subscribe(context.Background(), "ana", "")
+	s.Equal(domain.ErrBrokerClosed, err)
+}
+
+func TestSuiteMemoryBroker(t *testing.T) {
+	suite.Run(t, new(SuiteMemoryBroker))
+}
@@ -0,0 +1,66 @@
+package graphql
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/isaias-dgr/todo/src/domain"
+)
+
+const (
+	defaultPageSize = 10
+	maxPageSize     = 100
+	cursorPrefix    = "offset:"
+)
+
+func pageSize(first *int) int {
+	if first == nil || *first <= 0 {
+		return defaultPageSize
+	}
+	if *first > maxPageSize {
+		return maxPageSize
+	}
+	return *first
+}
+
+// Cursors are opaque to clients but only wrap the offset, since the
+// repository pages with LIMIT/OFFSET.
+func encodeCursor(offset int) string {
+	return base64.StdEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(raw), cursorPrefix) {
+		return 0, errors.New("bad request: invalid cursor")
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(raw), cursorPrefix))
+	if err != nil || offset < 0 {
+		return 0, errors.New("bad request: invalid cursor")
+	}
+	return offset, nil
+}
+
+func newConnection(tasks *domain.Tasks, f *domain.Filter) *TaskConnection {
+	conn := &TaskConnection{
+		Edges:      make([]*TaskEdge, 0, len(tasks.Data)),
+		TotalCount: tasks.Total,
+		PageInfo: &PageInfo{
+			HasPreviousPage: f.Offset > 0,
+			HasNextPage:     f.Offset+len(tasks.Data) < tasks.Total,
+		},
+	}
+	for i, task := range tasks.Data {
+		conn.Edges = append(conn.Edges, &TaskEdge{
+			Cursor: encodeCursor(f.Offset + i),
+			Node:   task,
+		})
+	}
+	if len(conn.Edges) > 0 {
+		conn.PageInfo.StartCursor = &conn.Edges[0].Cursor
+		conn.PageInfo.EndCursor = &conn.Edges[len(conn.Edges)-1].Cursor
+	}
+	return conn
+}
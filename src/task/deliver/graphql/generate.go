@@ -0,0 +1,3 @@
+package graphql
+
+//go:generate go run github.com/99designs/gqlgen generate
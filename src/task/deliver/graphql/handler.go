@@ -0,0 +1,150 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/gorilla/mux"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.uber.org/zap"
+)
+
+// Attachments are fetched per task, so they weigh as a page of them.
+const attachmentsCost = 10
+
+// Limits bound what a single operation may ask for. Complexity counts one
+// per field, multiplied by the page size of lists.
+type Limits struct {
+	Depth      int
+	Complexity int
+}
+
+var DefaultLimits = Limits{Depth: 8, Complexity: 500}
+
+type GraphqlHandler struct {
+	Server *handler.Server
+	L      *zap.SugaredLogger
+}
+
+func NewGraphqlHandler(r *mux.Router, taskUseCase domain.TaskUseCase, attachmentUseCase domain.AttachmentUseCase, logger *zap.SugaredLogger) {
+	h := &GraphqlHandler{
+		Server: NewServer(taskUseCase, attachmentUseCase, DefaultLimits),
+		L:      logger,
+	}
+
+	r.Handle("/graphql", h).Methods("POST")
+}
+
+func NewServer(taskUseCase domain.TaskUseCase, attachmentUseCase domain.AttachmentUseCase, limits Limits) *handler.Server {
+	config := Config{Resolvers: &Resolver{
+		TuseCase: taskUseCase,
+		AuseCase: attachmentUseCase,
+	}}
+	config.Complexity.Query.Tasks = func(childComplexity int, first *int, after *string, filter *TaskFilter) int {
+		return pageSize(first) * childComplexity
+	}
+	config.Complexity.Task.Attachments = func(childComplexity int) int {
+		return attachmentsCost * childComplexity
+	}
+
+	srv := handler.New(NewExecutableSchema(config))
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.Introspection{})
+	srv.Use(extension.FixedComplexityLimit(limits.Complexity))
+	srv.Use(depthLimit{max: limits.Depth})
+	srv.SetErrorPresenter(presentError)
+	return srv
+}
+
+func (g *GraphqlHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.Server.ServeHTTP(w, r)
+}
+
+// presentError adds a machine readable code next to the repository error.
+func presentError(ctx context.Context, err error) *gqlerror.Error {
+	gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+	var cause *gqlerror.Error
+	if errors.As(err, &cause) && cause.Extensions != nil {
+		return gqlErr
+	}
+	var verr *domain.ValidationError
+	if errors.As(err, &verr) {
+		gqlErr.Extensions = map[string]interface{}{"code": "BAD_REQUEST", "fields": verr.Fields}
+		return gqlErr
+	}
+	msg := gqlErr.Message
+	code := "INTERNAL"
+	switch {
+	case msg == "not_found":
+		code = "NOT_FOUND"
+	case msg == "uuid_format", strings.HasPrefix(msg, "bad request"):
+		code = "BAD_REQUEST"
+	case strings.HasPrefix(msg, "conflict_"):
+		code = "CONFLICT"
+	case errors.Is(err, domain.ErrQuotaExceeded):
+		code = "QUOTA_EXCEEDED"
+	}
+	gqlErr.Extensions = map[string]interface{}{"code": code}
+	return gqlErr
+}
+
+// depthLimit rejects operations nested deeper than max fields. Introspection
+// fields are not counted so GraphiQL-like tools keep working.
+type depthLimit struct {
+	max int
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = depthLimit{}
+
+func (d depthLimit) ExtensionName() string {
+	return "DepthLimit"
+}
+
+func (d depthLimit) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (d depthLimit) MutateOperationContext(ctx context.Context, rc *graphql.OperationContext) *gqlerror.Error {
+	if depth := selectionDepth(rc.Operation.SelectionSet); depth > d.max {
+		return &gqlerror.Error{
+			Message:    "query_too_deep",
+			Extensions: map[string]interface{}{"code": "QUERY_TOO_DEEP", "depth": depth, "max": d.max},
+		}
+	}
+	return nil
+}
+
+func selectionDepth(set ast.SelectionSet) int {
+	deepest := 0
+	for _, selection := range set {
+		depth := 0
+		switch s := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name, "__") {
+				continue
+			}
+			depth = 1 + selectionDepth(s.SelectionSet)
+		case *ast.InlineFragment:
+			depth = selectionDepth(s.SelectionSet)
+		case *ast.FragmentSpread:
+			if s.Definition != nil {
+				depth = selectionDepth(s.Definition.SelectionSet)
+			}
+		}
+		if depth > deepest {
+			deepest = depth
+		}
+	}
+	return deepest
+}
@@ -0,0 +1,191 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/domain/mocks"
+	_TaskGraphql "github.com/isaias-dgr/todo/src/task/deliver/graphql"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type gqlResponse struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+type SuiteGraphql struct {
+	suite.Suite
+	tasks       *mocks.TaskUseCase
+	attachments *mocks.AttachmentUseCase
+	router      *mux.Router
+}
+
+func (s *SuiteGraphql) SetupTest() {
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	s.tasks = new(mocks.TaskUseCase)
+	s.attachments = new(mocks.AttachmentUseCase)
+	s.router = mux.NewRouter()
+	_TaskGraphql.NewGraphqlHandler(s.router, s.tasks, s.attachments, logger.Sugar())
+}
+
+func (s *SuiteGraphql) query(query string, variables map[string]interface{}) gqlResponse {
+	body, _ := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	req, _ := http.NewRequest("POST", "/graphql", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	var resp gqlResponse
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+	return resp
+}
+
+func (s *SuiteGraphql) TestTasks() {
+	first, second := domain.NewTask("title 1", "description 1"), domain.NewTask("title 2", "description 2")
+	first.ID, second.ID = uuid.New(), uuid.New()
+	s.tasks.On("Fetch", mock.Anything, &domain.Filter{Offset: 0, Limit: 2, SortBy: "title"}).
+		Return(domain.NewTasks([]*domain.Task{first, second}, 3), nil)
+	s.attachments.On("Fetch", mock.Anything, first.ID.String()).
+		Return([]*domain.Attachment{{ID: uuid.New(), Name: "notes.txt"}}, nil)
+	s.attachments.On("Fetch", mock.Anything, second.ID.String()).Return([]*domain.Attachment{}, nil)
+
+	resp := s.query(`{
+		tasks(first: 2, filter: {sortBy: "title"}) {
+			totalCount
+			pageInfo { hasNextPage endCursor }
+			edges { cursor node { id title attachments { name } } }
+		}
+	}`, nil)
+	s.Empty(resp.Errors)
+
+	var tasks struct {
+		TotalCount int
+		PageInfo   struct {
+			HasNextPage bool
+			EndCursor   string
+		}
+		Edges []struct {
+			Cursor string
+			Node   struct {
+				ID          string
+				Title       string
+				Attachments []struct{ Name string }
+			}
+		}
+	}
+	s.NoError(json.Unmarshal(resp.Data["tasks"], &tasks))
+	s.Equal(3, tasks.TotalCount)
+	s.True(tasks.PageInfo.HasNextPage)
+	s.Len(tasks.Edges, 2)
+	s.Equal(first.ID.String(), tasks.Edges[0].Node.ID)
+	s.Equal("notes.txt", tasks.Edges[0].Node.Attachments[0].Name)
+	s.Equal(tasks.Edges[1].Cursor, tasks.PageInfo.EndCursor)
+
+	s.Run("When paging after a cursor", func() {
+		s.tasks.On("Fetch", mock.Anything, &domain.Filter{Offset: 2, Limit: 2}).
+			Return(domain.NewTasks([]*domain.Task{}, 3), nil)
+		resp := s.query(`query($after: String) { tasks(first: 2, after: $after) { totalCount } }`,
+			map[string]interface{}{"after": tasks.PageInfo.EndCursor})
+		s.Empty(resp.Errors)
+	})
+
+	s.Run("When the cursor is invalid", func() {
+		resp := s.query(`{ tasks(after: "nope") { totalCount } }`, nil)
+		s.Len(resp.Errors, 1)
+		s.Equal("BAD_REQUEST", resp.Errors[0].Extensions["code"])
+	})
+
+	s.Run("When first is negative", func() {
+		resp := s.query(`{ tasks(first: -1) { totalCount } }`, nil)
+		s.Len(resp.Errors, 1)
+		s.Equal("BAD_REQUEST", resp.Errors[0].Extensions["code"])
+	})
+}
+
+func (s *SuiteGraphql) TestTask() {
+	id := uuid.New()
+	s.tasks.On("GetByID", mock.Anything, id.String()).Return(nil, errors.New("not_found"))
+	resp := s.query(`query($id: UUID!) { task(id: $id) { title } }`, map[string]interface{}{"id": id.String()})
+	s.Empty(resp.Errors)
+	s.Equal("null", string(resp.Data["task"]))
+}
+
+func (s *SuiteGraphql) TestMutations() {
+	s.Run("When a task is created", func() {
+		s.tasks.On("Insert", mock.Anything, mock.Anything).Return(nil).Once()
+		resp := s.query(`mutation { createTask(input: {title: "title", description: "description"}) { title } }`, nil)
+		s.Empty(resp.Errors)
+		s.JSONEq(`{"title": "title"}`, string(resp.Data["createTask"]))
+	})
+
+	s.Run("When the input is invalid", func() {
+		s.tasks.On("Insert", mock.Anything, mock.Anything).Return(func(_ context.Context, t *domain.Task) error {
+			return t.Validate()
+		}).Once()
+		resp := s.query(`mutation { createTask(input: {title: "title", description: " "}) { title } }`, nil)
+		s.Len(resp.Errors, 1)
+		s.Equal("validation_failed", resp.Errors[0].Message)
+		s.Equal("BAD_REQUEST", resp.Errors[0].Extensions["code"])
+		s.Equal(map[string]interface{}{"description": []interface{}{"is required"}}, resp.Errors[0].Extensions["fields"])
+	})
+
+	s.Run("When the task to update does not exist", func() {
+		id := uuid.New()
+		s.tasks.On("Update", mock.Anything, id.String(), mock.Anything).Return(errors.New("not_found")).Once()
+		resp := s.query(`mutation($id: UUID!) { updateTask(id: $id, input: {title: "t", description: "d"}) { title } }`,
+			map[string]interface{}{"id": id.String()})
+		s.Len(resp.Errors, 1)
+		s.Equal("NOT_FOUND", resp.Errors[0].Extensions["code"])
+	})
+
+	s.Run("When a task is deleted", func() {
+		id := uuid.New()
+		s.tasks.On("Delete", mock.Anything, id.String()).Return(nil).Once()
+		resp := s.query(`mutation($id: UUID!) { deleteTask(id: $id) }`, map[string]interface{}{"id": id.String()})
+		s.Empty(resp.Errors)
+		s.Equal("true", string(resp.Data["deleteTask"]))
+	})
+}
+
+func (s *SuiteGraphql) TestLimits() {
+	s.Run("When the query is too complex", func() {
+		resp := s.query(`{ tasks(first: 100) { edges { node { attachments { name size } } } } }`, nil)
+		s.Len(resp.Errors, 1)
+		s.Equal("COMPLEXITY_LIMIT_EXCEEDED", resp.Errors[0].Extensions["code"])
+		s.tasks.AssertNotCalled(s.T(), "Fetch", mock.Anything, mock.Anything)
+	})
+
+	s.Run("When the query is too deep", func() {
+		router := mux.NewRouter()
+		router.Handle("/graphql", _TaskGraphql.NewServer(s.tasks, s.attachments, _TaskGraphql.Limits{Depth: 4, Complexity: 500}))
+		s.router = router
+
+		resp := s.query(`{ tasks { edges { node { ...a } } } }
+			fragment a on Task { attachments { name } }`, nil)
+		s.Len(resp.Errors, 1)
+		s.Equal("query_too_deep", resp.Errors[0].Message)
+		s.Equal("QUERY_TOO_DEEP", resp.Errors[0].Extensions["code"])
+		s.tasks.AssertNotCalled(s.T(), "Fetch", mock.Anything, mock.Anything)
+
+		resp = s.query(`{ __schema { types { fields { type { ofType { name } } } } } }`, nil)
+		s.Empty(resp.Errors, "introspection is not limited")
+	})
+}
+
+func TestSuiteGraphql(t *testing.T) {
+	suite.Run(t, new(SuiteGraphql))
+}
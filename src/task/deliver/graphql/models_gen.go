@@ -0,0 +1,34 @@
+// Code generated by github.com/99designs/gqlgen, DO NOT EDIT.
+
+package graphql
+
+import (
+	"github.com/isaias-dgr/todo/src/domain"
+)
+
+type PageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor"`
+	EndCursor       *string `json:"endCursor"`
+}
+
+type TaskConnection struct {
+	Edges      []*TaskEdge `json:"edges"`
+	PageInfo   *PageInfo   `json:"pageInfo"`
+	TotalCount int         `json:"totalCount"`
+}
+
+type TaskEdge struct {
+	Cursor string       `json:"cursor"`
+	Node   *domain.Task `json:"node"`
+}
+
+type TaskFilter struct {
+	SortBy *string `json:"sortBy"`
+}
+
+type TaskInput struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
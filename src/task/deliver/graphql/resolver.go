@@ -0,0 +1,10 @@
+package graphql
+
+import (
+	"github.com/isaias-dgr/todo/src/domain"
+)
+
+type Resolver struct {
+	TuseCase domain.TaskUseCase
+	AuseCase domain.AttachmentUseCase
+}
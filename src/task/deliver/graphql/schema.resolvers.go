@@ -0,0 +1,81 @@
+package graphql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/isaias-dgr/todo/src/domain"
+)
+
+func (r *mutationResolver) CreateTask(ctx context.Context, input TaskInput) (*domain.Task, error) {
+	task := domain.NewTask(input.Title, input.Description)
+	if err := r.TuseCase.Insert(ctx, task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+func (r *mutationResolver) UpdateTask(ctx context.Context, id uuid.UUID, input TaskInput) (*domain.Task, error) {
+	task := domain.NewTask(input.Title, input.Description)
+	if err := r.TuseCase.Update(ctx, id.String(), task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+func (r *mutationResolver) DeleteTask(ctx context.Context, id uuid.UUID) (bool, error) {
+	if err := r.TuseCase.Delete(ctx, id.String()); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *queryResolver) Task(ctx context.Context, id uuid.UUID) (*domain.Task, error) {
+	task, err := r.TuseCase.GetByID(ctx, id.String())
+	if err != nil && err.Error() == "not_found" {
+		return nil, nil
+	}
+	return task, err
+}
+
+func (r *queryResolver) Tasks(ctx context.Context, first *int, after *string, filter *TaskFilter) (*TaskConnection, error) {
+	if first != nil && *first < 0 {
+		return nil, domain.ErrNegativePage
+	}
+	f := &domain.Filter{Limit: pageSize(first)}
+	if after != nil {
+		offset, err := decodeCursor(*after)
+		if err != nil {
+			return nil, err
+		}
+		f.Offset = offset + 1
+	}
+	if filter != nil && filter.SortBy != nil {
+		f.SortBy = *filter.SortBy
+	}
+	tasks, err := r.TuseCase.Fetch(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	return newConnection(tasks, f), nil
+}
+
+func (r *taskResolver) Attachments(ctx context.Context, obj *domain.Task) ([]*domain.Attachment, error) {
+	return r.AuseCase.Fetch(ctx, obj.ID.String())
+}
+
+// Mutation returns MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// Task returns TaskResolver implementation.
+func (r *Resolver) Task() TaskResolver { return &taskResolver{r} }
+
+type mutationResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+type taskResolver struct{ *Resolver }
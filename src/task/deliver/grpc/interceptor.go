@@ -0,0 +1,39 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/isaias-dgr/todo/src/domain"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// UserUnaryInterceptor is the gRPC counterpart of the http UserMiddleware,
+// reading the caller from the x-user-id metadata key.
+func UserUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(withUser(ctx), req)
+}
+
+func UserStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &userStream{ServerStream: ss, ctx: withUser(ss.Context())})
+}
+
+type userStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (u *userStream) Context() context.Context {
+	return u.ctx
+}
+
+func withUser(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	if values := md.Get("x-user-id"); len(values) > 0 && values[0] != "" {
+		return domain.WithUser(ctx, values[0])
+	}
+	return ctx
+}
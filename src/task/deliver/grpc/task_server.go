@@ -0,0 +1,213 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/task/deliver/grpc/taskpb"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type TaskServer struct {
+	taskpb.UnimplementedTaskServiceServer
+	TuseCase domain.TaskUseCase
+	Broker   domain.Broker
+	L        *zap.SugaredLogger
+}
+
+func NewTaskServer(s *grpc.Server, taskUseCase domain.TaskUseCase, broker domain.Broker, logger *zap.SugaredLogger) {
+	taskpb.RegisterTaskServiceServer(s, &TaskServer{
+		TuseCase: taskUseCase,
+		Broker:   broker,
+		L:        logger,
+	})
+}
+
+func (t *TaskServer) Fetch(ctx context.Context, req *taskpb.FetchRequest) (*taskpb.FetchResponse, error) {
+	t.L.Infow("Fetch", "rpc", "grpc")
+	filter := &domain.Filter{
+		Offset: int(req.Offset),
+		Limit:  int(req.Limit),
+		SortBy: req.SortBy,
+	}
+	if filter.Limit == 0 {
+		filter.Limit = 10
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, statusError(err)
+	}
+	tasks, err := t.TuseCase.Fetch(ctx, filter)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	resp := &taskpb.FetchResponse{
+		Total:  int32(tasks.Total),
+		Offset: int32(filter.Offset),
+		Limit:  int32(filter.Limit),
+	}
+	for _, task := range tasks.Data {
+		resp.Tasks = append(resp.Tasks, toProto(task))
+	}
+	return resp, nil
+}
+
+func (t *TaskServer) Insert(ctx context.Context, req *taskpb.InsertRequest) (*taskpb.Task, error) {
+	t.L.Infow("Insert", "rpc", "grpc")
+	task := domain.NewTask(req.Title, req.Description)
+	if err := t.TuseCase.Insert(ctx, task); err != nil {
+		return nil, statusError(err)
+	}
+	return toProto(task), nil
+}
+
+// Update creates the task when no task has the id, as PUT /task/{id}
+// does.
+func (t *TaskServer) Update(ctx context.Context, req *taskpb.UpdateRequest) (*taskpb.Task, error) {
+	t.L.Infow("Update", "rpc", "grpc")
+	task := domain.NewTask(req.Title, req.Description)
+	if _, err := t.TuseCase.Upsert(ctx, req.Id, task); err != nil {
+		return nil, statusError(err)
+	}
+	return toProto(task), nil
+}
+
+func (t *TaskServer) GetByID(ctx context.Context, req *taskpb.GetByIDRequest) (*taskpb.Task, error) {
+	t.L.Infow("Get by uuid", "rpc", "grpc")
+	task, err := t.TuseCase.GetByID(ctx, req.Id)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return toProto(task), nil
+}
+
+func (t *TaskServer) Delete(ctx context.Context, req *taskpb.DeleteRequest) (*emptypb.Empty, error) {
+	t.L.Infow("Delete", "rpc", "grpc")
+	if err := t.TuseCase.Delete(ctx, req.Id); err != nil {
+		return nil, statusError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (t *TaskServer) Complete(ctx context.Context, req *taskpb.CompleteRequest) (*taskpb.Task, error) {
+	t.L.Infow("Complete", "rpc", "grpc")
+	task, err := t.TuseCase.Complete(ctx, req.Id)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return toProto(task), nil
+}
+
+// Watch ends with ResourceExhausted when the stream falls behind; the
+// client resumes with the id of the last event it received.
+func (t *TaskServer) Watch(req *taskpb.WatchRequest, stream taskpb.TaskService_WatchServer) error {
+	t.L.Infow("Watch", "rpc", "grpc")
+	ctx := stream.Context()
+	userID := domain.UserFromContext(ctx)
+	events, err := t.Broker.Subscribe(ctx, userID, req.LastEventId)
+	if errors.Is(err, domain.ErrEventExpired) {
+		if err := stream.Send(&taskpb.Event{Type: "reset"}); err != nil {
+			return err
+		}
+		events, err = t.Broker.Subscribe(ctx, userID, "")
+	}
+	if err != nil {
+		return statusError(err)
+	}
+	for e := range events {
+		if err := stream.Send(eventToProto(e)); err != nil {
+			return err
+		}
+	}
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	return status.Error(codes.ResourceExhausted, "subscriber_too_slow")
+}
+
+func statusError(err error) error {
+	var verr *domain.ValidationError
+	if errors.As(err, &verr) {
+		return validationStatus(verr)
+	}
+	msg := err.Error()
+	switch {
+	case msg == "not_found":
+		return status.Error(codes.NotFound, msg)
+	case msg == "uuid_format", strings.HasPrefix(msg, "bad request"):
+		return status.Error(codes.InvalidArgument, msg)
+	case strings.HasPrefix(msg, "conflict_"):
+		return status.Error(codes.Aborted, msg)
+	case errors.Is(err, domain.ErrQuotaExceeded):
+		return status.Error(codes.ResourceExhausted, msg)
+	case errors.Is(err, domain.ErrBrokerClosed):
+		return status.Error(codes.Unavailable, msg)
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		return status.Error(codes.Internal, msg)
+	}
+}
+
+// validationStatus carries the violations as a BadRequest detail, one per
+// field message.
+func validationStatus(verr *domain.ValidationError) error {
+	st := status.New(codes.InvalidArgument, verr.Error())
+	violations := []*errdetails.BadRequest_FieldViolation{}
+	for field, messages := range verr.Fields {
+		for _, msg := range messages {
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{Field: field, Description: msg})
+		}
+	}
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Field < violations[j].Field
+	})
+	detailed, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+func toProto(t *domain.Task) *taskpb.Task {
+	task := &taskpb.Task{
+		Id:          t.ID.String(),
+		Title:       t.Title,
+		Description: t.Description,
+	}
+	if t.CreatedAt != nil {
+		task.CreatedAt = timestamppb.New(*t.CreatedAt)
+	}
+	if t.UpdatedAt != nil {
+		task.UpdatedAt = timestamppb.New(*t.UpdatedAt)
+	}
+	if t.CompletedAt != nil {
+		task.CompletedAt = timestamppb.New(*t.CompletedAt)
+	}
+	return task
+}
+
+func eventToProto(e *domain.Event) *taskpb.Event {
+	event := &taskpb.Event{
+		Id:     e.ID.String(),
+		Type:   e.Type,
+		TaskId: e.TaskID.String(),
+	}
+	var task domain.Task
+	if err := json.Unmarshal(e.Payload, &task); err == nil {
+		event.Task = toProto(&task)
+	}
+	if e.CreatedAt != nil {
+		event.CreatedAt = timestamppb.New(*e.CreatedAt)
+	}
+	return event
+}
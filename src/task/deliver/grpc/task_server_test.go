@@ -0,0 +1,213 @@
+package grpc_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/domain/mocks"
+	"github.com/isaias-dgr/todo/src/task/broker"
+	_TaskGrpc "github.com/isaias-dgr/todo/src/task/deliver/grpc"
+	"github.com/isaias-dgr/todo/src/task/deliver/grpc/taskpb"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type SuiteTaskServer struct {
+	suite.Suite
+	cu     *mocks.TaskUseCase
+	broker domain.Broker
+	server *grpc.Server
+	conn   *grpc.ClientConn
+	client taskpb.TaskServiceClient
+}
+
+func (s *SuiteTaskServer) SetupTest() {
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	s.cu = new(mocks.TaskUseCase)
+	s.broker = broker.NewMemoryBroker(10, 10, logger.Sugar())
+
+	lis := bufconn.Listen(1 << 20)
+	s.server = grpc.NewServer(
+		grpc.UnaryInterceptor(_TaskGrpc.UserUnaryInterceptor),
+		grpc.StreamInterceptor(_TaskGrpc.UserStreamInterceptor))
+	_TaskGrpc.NewTaskServer(s.server, s.cu, s.broker, logger.Sugar())
+	go s.server.Serve(lis)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithInsecure())
+	s.Require().NoError(err)
+	s.conn = conn
+	s.client = taskpb.NewTaskServiceClient(conn)
+}
+
+func (s *SuiteTaskServer) TearDownTest() {
+	s.conn.Close()
+	s.server.Stop()
+}
+
+func (s *SuiteTaskServer) TestFetch() {
+	tasks := domain.NewTasks([]*domain.Task{
+		domain.NewTask("title 1", "description 1"),
+		domain.NewTask("title 2", "description 2"),
+	}, 2)
+	s.cu.On("Fetch", mock.Anything, &domain.Filter{Offset: 0, Limit: 10}).Return(tasks, nil)
+
+	resp, err := s.client.Fetch(context.TODO(), &taskpb.FetchRequest{})
+	s.NoError(err)
+	s.Len(resp.Tasks, 2)
+	s.Equal(int32(2), resp.Total)
+	s.Equal(int32(10), resp.Limit)
+	s.Equal("title 1", resp.Tasks[0].Title)
+
+	_, err = s.client.Fetch(context.TODO(), &taskpb.FetchRequest{Offset: -1})
+	s.Equal(codes.InvalidArgument, status.Code(err))
+	_, err = s.client.Fetch(context.TODO(), &taskpb.FetchRequest{Limit: -5})
+	s.Equal(codes.InvalidArgument, status.Code(err))
+	s.cu.AssertNumberOfCalls(s.T(), "Fetch", 1)
+}
+
+func (s *SuiteTaskServer) TestInsert() {
+	s.Run("When the use case is succesful", func() {
+		s.cu.On("Insert", mock.MatchedBy(func(ctx context.Context) bool {
+			return domain.UserFromContext(ctx) == "user-1"
+		}), mock.Anything).Return(nil).Once()
+
+		ctx := metadata.AppendToOutgoingContext(context.TODO(), "x-user-id", "user-1")
+		task, err := s.client.Insert(ctx, &taskpb.InsertRequest{Title: "title", Description: "description"})
+		s.NoError(err)
+		s.Equal("title", task.Title)
+	})
+
+	s.Run("When a field is missing", func() {
+		s.cu.On("Insert", mock.Anything, mock.Anything).Return(func(_ context.Context, t *domain.Task) error {
+			return t.Validate()
+		}).Once()
+		_, err := s.client.Insert(context.TODO(), &taskpb.InsertRequest{Title: "title"})
+		s.Equal(codes.InvalidArgument, status.Code(err))
+		details := status.Convert(err).Details()
+		s.Require().Len(details, 1)
+		violations := details[0].(*errdetails.BadRequest).FieldViolations
+		s.Require().Len(violations, 1)
+		s.Equal("description", violations[0].Field)
+		s.Equal("is required", violations[0].Description)
+	})
+}
+
+func (s *SuiteTaskServer) TestUpdate() {
+	id := "4f9b1c3e-8a2d-4e6f-9b1c-3e8a2d4e6f9b"
+	s.Run("When the task does not exist", func() {
+		s.cu.On("Upsert", mock.Anything, id, mock.Anything).Return(func(_ context.Context, id string, t *domain.Task) bool {
+			t.ID = uuid.MustParse(id)
+			return true
+		}, nil).Once()
+		task, err := s.client.Update(context.TODO(), &taskpb.UpdateRequest{Id: id, Title: "title", Description: "description"})
+		s.NoError(err)
+		s.Equal(id, task.Id)
+		s.Equal("title", task.Title)
+	})
+
+	s.Run("When the task exists", func() {
+		s.cu.On("Upsert", mock.Anything, id, mock.Anything).Return(func(_ context.Context, id string, t *domain.Task) bool {
+			t.ID = uuid.MustParse(id)
+			return false
+		}, nil).Once()
+		task, err := s.client.Update(context.TODO(), &taskpb.UpdateRequest{Id: id, Title: "new title", Description: "description"})
+		s.NoError(err)
+		s.Equal(id, task.Id)
+		s.Equal("new title", task.Title)
+	})
+	s.cu.AssertNotCalled(s.T(), "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *SuiteTaskServer) TestComplete() {
+	s.Run("When the task exists", func() {
+		completedAt := time.Date(2021, 10, 19, 9, 0, 0, 0, time.UTC)
+		task := domain.NewTask("title", "description")
+		task.CompletedAt = &completedAt
+		s.cu.On("Complete", mock.Anything, task.ID.String()).Return(task, nil).Once()
+
+		resp, err := s.client.Complete(context.TODO(), &taskpb.CompleteRequest{Id: task.ID.String()})
+		s.NoError(err)
+		s.Equal(task.ID.String(), resp.Id)
+		s.True(completedAt.Equal(resp.CompletedAt.AsTime()))
+	})
+
+	s.Run("When the task does not exist", func() {
+		s.cu.On("Complete", mock.Anything, "missing").Return(nil, errors.New("not_found")).Once()
+		_, err := s.client.Complete(context.TODO(), &taskpb.CompleteRequest{Id: "missing"})
+		s.Equal(codes.NotFound, status.Code(err))
+	})
+}
+
+func (s *SuiteTaskServer) TestErrors() {
+	s.cu.On("GetByID", mock.Anything, "missing").Return(nil, errors.New("not_found"))
+	s.cu.On("GetByID", mock.Anything, "bad").Return(nil, errors.New("uuid_format"))
+	s.cu.On("Upsert", mock.Anything, "busy", mock.Anything).Return(false, errors.New("conflict_update"))
+	s.cu.On("Delete", mock.Anything, "boom").Return(errors.New("query_exec"))
+	s.cu.On("Insert", mock.Anything, mock.Anything).Return(domain.ErrQuotaExceeded)
+
+	_, err := s.client.GetByID(context.TODO(), &taskpb.GetByIDRequest{Id: "missing"})
+	s.Equal(codes.NotFound, status.Code(err))
+	_, err = s.client.GetByID(context.TODO(), &taskpb.GetByIDRequest{Id: "bad"})
+	s.Equal(codes.InvalidArgument, status.Code(err))
+	_, err = s.client.Update(context.TODO(), &taskpb.UpdateRequest{Id: "busy", Title: "t", Description: "d"})
+	s.Equal(codes.Aborted, status.Code(err))
+	_, err = s.client.Delete(context.TODO(), &taskpb.DeleteRequest{Id: "boom"})
+	s.Equal(codes.Internal, status.Code(err))
+	_, err = s.client.Insert(context.TODO(), &taskpb.InsertRequest{Title: "t", Description: "d"})
+	s.Equal(codes.ResourceExhausted, status.Code(err))
+}
+
+func (s *SuiteTaskServer) TestWatch() {
+	s.Run("When events are published", func() {
+		ctx, cancel := context.WithCancel(metadata.AppendToOutgoingContext(context.TODO(), "x-user-id", "user-1"))
+		defer cancel()
+		stream, err := s.client.Watch(ctx, &taskpb.WatchRequest{})
+		s.Require().NoError(err)
+
+		task := domain.NewTask("title", "description")
+		task.ID = uuid.New()
+		e, _ := domain.NewEvent(domain.TaskCreated, task)
+		e.UserID = "user-1"
+		// The subscription is made once the stream reaches the server.
+		s.Eventually(func() bool {
+			s.broker.Publish(e)
+			return true
+		}, time.Second, time.Millisecond)
+		got, err := stream.Recv()
+		s.Require().NoError(err)
+		s.Equal(e.ID.String(), got.Id)
+		s.Equal(domain.TaskCreated, got.Type)
+		s.Equal("title", got.Task.Title)
+	})
+
+	s.Run("When the last event id expired", func() {
+		ctx, cancel := context.WithCancel(context.TODO())
+		defer cancel()
+		stream, err := s.client.Watch(ctx, &taskpb.WatchRequest{LastEventId: uuid.New().String()})
+		s.Require().NoError(err)
+		got, err := stream.Recv()
+		s.Require().NoError(err)
+		s.Equal("reset", got.Type)
+	})
+}
+
+func TestSuiteTaskServer(t *testing.T) {
+	suite.Run(t, new(SuiteTaskServer))
+}
@@ -0,0 +1,142 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/isaias-dgr/todo/src/domain"
+	"go.uber.org/zap"
+)
+
+type AttachmentHandler struct {
+	AuseCase domain.AttachmentUseCase
+	L        *zap.SugaredLogger
+	MaxSize  int64
+}
+
+func NewAttachmentHandler(r *mux.Router, attachmentUseCase domain.AttachmentUseCase, maxSize int64, logger *zap.SugaredLogger) {
+	handler := &AttachmentHandler{
+		AuseCase: attachmentUseCase,
+		L:        logger,
+		MaxSize:  maxSize,
+	}
+
+	r.HandleFunc("/task/{task_id}/attachments/", handler.FetchAttachments).Methods("GET")
+	r.HandleFunc("/task/{task_id}/attachments/", handler.UploadAttachment).Methods("POST")
+	r.HandleFunc("/task/{task_id}/attachments/{attachment_id}/", handler.GetAttachment).Methods("GET")
+	r.HandleFunc("/task/{task_id}/attachments/{attachment_id}/", handler.DeleteAttachment).Methods("DELETE")
+
+	r.HandleFunc("/task/{task_id}/files/", handler.FetchAttachments).Methods("GET")
+	r.HandleFunc("/task/{task_id}/files/", handler.UploadAttachment).Methods("POST")
+	r.HandleFunc("/task/{task_id}/files/{attachment_id}/", handler.DownloadAttachment).Methods("GET")
+	r.HandleFunc("/task/{task_id}/files/{attachment_id}/", handler.DeleteAttachment).Methods("DELETE")
+}
+
+// UploadAttachment streams the "file" part of a multipart body straight to
+// the use case, so nothing is buffered on disk or in memory.
+func (a *AttachmentHandler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	// Leave room for the multipart headers around the file itself.
+	r.Body = http.MaxBytesReader(w, r.Body, a.MaxSize+1<<20)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "bad request: "+err.Error())
+		return
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			errorResponse(w, http.StatusBadRequest, "bad request: Requiered field file")
+			return
+		}
+		if err != nil {
+			errorResponse(w, http.StatusBadRequest, "bad request: "+err.Error())
+			return
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+		attachment, err := a.AuseCase.Upload(r.Context(), vars["task_id"], part.FileName(), part)
+		if err != nil {
+			errorResponse(w, attachmentStatus(err), err.Error())
+			return
+		}
+		makeResponse(w, http.StatusCreated, attachment, nil, 0)
+		return
+	}
+}
+
+func (a *AttachmentHandler) FetchAttachments(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	attachments, err := a.AuseCase.Fetch(r.Context(), vars["task_id"])
+	if err != nil {
+		errorResponse(w, attachmentStatus(err), err.Error())
+		return
+	}
+	makeResponse(w, http.StatusOK, attachments, nil, 0)
+}
+
+func (a *AttachmentHandler) GetAttachment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	attachment, err := a.AuseCase.GetByID(r.Context(), vars["task_id"], vars["attachment_id"])
+	if err != nil {
+		errorResponse(w, attachmentStatus(err), err.Error())
+		return
+	}
+	makeResponse(w, http.StatusOK, attachment, nil, 0)
+}
+
+// DownloadAttachment serves the file content. The blob key is the SHA-256
+// of the content, which makes it a strong ETag; Range requests are served
+// by seeking in the blob.
+func (a *AttachmentHandler) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	attachment, reader, err := a.AuseCase.Open(r.Context(), vars["task_id"], vars["attachment_id"])
+	if err != nil {
+		errorResponse(w, attachmentStatus(err), err.Error())
+		return
+	}
+	defer reader.Close()
+
+	var modified time.Time
+	if attachment.CreatedAt != nil {
+		modified = *attachment.CreatedAt
+	}
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name}))
+	w.Header().Set("ETag", `"`+attachment.Key+`"`)
+	http.ServeContent(w, r, attachment.Name, modified, reader)
+}
+
+func (a *AttachmentHandler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	err := a.AuseCase.Delete(r.Context(), vars["task_id"], vars["attachment_id"])
+	if err != nil {
+		errorResponse(w, attachmentStatus(err), err.Error())
+		return
+	}
+	makeResponse(w, http.StatusAccepted, nil, nil, 0)
+}
+
+func attachmentStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrAttachmentTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, domain.ErrAttachmentType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, domain.ErrAttachmentConflict), strings.HasPrefix(err.Error(), "conflict_"):
+		return http.StatusConflict
+	case err.Error() == "not_found":
+		return http.StatusNotFound
+	case err.Error() == "uuid_format":
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
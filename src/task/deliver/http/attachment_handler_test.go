@@ -0,0 +1,222 @@
+package http_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/domain/mocks"
+	h "github.com/isaias-dgr/todo/src/task/deliver/http"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type SuiteAttachment struct {
+	suite.Suite
+	cu      *mocks.AttachmentUseCase
+	handler *h.AttachmentHandler
+}
+
+func (s *SuiteAttachment) SetupTest() {
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	sugar := logger.Sugar()
+	s.cu = new(mocks.AttachmentUseCase)
+	s.handler = &h.AttachmentHandler{
+		AuseCase: s.cu,
+		L:        sugar,
+		MaxSize:  1 << 20,
+	}
+}
+
+func (s *SuiteAttachment) multipart(field, name, content string) (*bytes.Buffer, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	writer.WriteField("comment", "ignored")
+	part, _ := writer.CreateFormFile(field, name)
+	part.Write([]byte(content))
+	writer.Close()
+	return body, writer.FormDataContentType()
+}
+
+func (s *SuiteAttachment) upload(field string) *httptest.ResponseRecorder {
+	body, contentType := s.multipart(field, "notes.txt", "hello")
+	req, err := http.NewRequest("POST", "/task/01/attachments/", body)
+	s.NoError(err)
+	req.Header.Set("Content-Type", contentType)
+	req = mux.SetURLVars(req, map[string]string{"task_id": "01"})
+	w := httptest.NewRecorder()
+	s.handler.UploadAttachment(w, req)
+	return w
+}
+
+func (s *SuiteAttachment) TestUpload() {
+	s.Run("When the use case is succesful", func() {
+		var content string
+		attachment := &domain.Attachment{Name: "notes.txt", ContentType: "text/plain", Size: 5}
+		s.cu.On("Upload", mock.Anything, "01", "notes.txt", mock.Anything).
+			Run(func(args mock.Arguments) {
+				b, _ := ioutil.ReadAll(args.Get(3).(io.Reader))
+				content = string(b)
+			}).
+			Return(attachment, nil).Once()
+		w := s.upload("file")
+		s.Equal(http.StatusCreated, w.Code)
+		s.Equal("hello", content)
+		s.Contains(w.Body.String(), "\"name\":\"notes.txt\"")
+	})
+
+	s.Run("When the file part is missing", func() {
+		w := s.upload("other")
+		s.Equal(http.StatusBadRequest, w.Code)
+		s.Equal("{\"message\":\"bad request: Requiered field file\"}", w.Body.String())
+	})
+
+	s.Run("When the body is not multipart", func() {
+		req, _ := http.NewRequest("POST", "/task/01/attachments/", strings.NewReader("{}"))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		s.handler.UploadAttachment(w, req)
+		s.Equal(http.StatusBadRequest, w.Code)
+	})
+
+	s.Run("When the file is too large", func() {
+		s.cu.On("Upload", mock.Anything, "01", mock.Anything, mock.Anything).
+			Return(nil, domain.ErrAttachmentTooLarge).Once()
+		w := s.upload("file")
+		s.Equal(http.StatusRequestEntityTooLarge, w.Code)
+		s.Equal("{\"message\":\"attachment_too_large\"}", w.Body.String())
+	})
+
+	s.Run("When the file type is not allowed", func() {
+		s.cu.On("Upload", mock.Anything, "01", mock.Anything, mock.Anything).
+			Return(nil, domain.ErrAttachmentType).Once()
+		w := s.upload("file")
+		s.Equal(http.StatusUnsupportedMediaType, w.Code)
+	})
+
+	s.Run("When the blob was released meanwhile", func() {
+		s.cu.On("Upload", mock.Anything, "01", mock.Anything, mock.Anything).
+			Return(nil, domain.ErrAttachmentConflict).Once()
+		w := s.upload("file")
+		s.Equal(http.StatusConflict, w.Code)
+	})
+
+	s.Run("When the task does not exist", func() {
+		s.cu.On("Upload", mock.Anything, "01", mock.Anything, mock.Anything).
+			Return(nil, errors.New("not_found")).Once()
+		w := s.upload("file")
+		s.Equal(http.StatusNotFound, w.Code)
+	})
+}
+
+func (s *SuiteAttachment) TestFetch() {
+	attachments := []*domain.Attachment{{Name: "a.png", URL: "http://s3/a"}}
+	s.cu.On("Fetch", mock.Anything, "01").Return(attachments, nil)
+	req, _ := http.NewRequest("GET", "/task/01/attachments/", nil)
+	req = mux.SetURLVars(req, map[string]string{"task_id": "01"})
+	w := httptest.NewRecorder()
+	s.handler.FetchAttachments(w, req)
+	s.Equal(http.StatusOK, w.Code)
+	s.Contains(w.Body.String(), "\"url\":\"http://s3/a\"")
+}
+
+func (s *SuiteAttachment) TestGet() {
+	s.Run("When the use case is succesful", func() {
+		s.cu.On("GetByID", mock.Anything, "01", "02").
+			Return(&domain.Attachment{Name: "a.png", URL: "http://s3/a"}, nil)
+		req, _ := http.NewRequest("GET", "/task/01/attachments/02/", nil)
+		req = mux.SetURLVars(req, map[string]string{"task_id": "01", "attachment_id": "02"})
+		w := httptest.NewRecorder()
+		s.handler.GetAttachment(w, req)
+		s.Equal(http.StatusOK, w.Code)
+	})
+
+	s.Run("When the attachment does not exist", func() {
+		s.cu.On("GetByID", mock.Anything, "01", "03").Return(nil, errors.New("not_found"))
+		req, _ := http.NewRequest("GET", "/task/01/attachments/03/", nil)
+		req = mux.SetURLVars(req, map[string]string{"task_id": "01", "attachment_id": "03"})
+		w := httptest.NewRecorder()
+		s.handler.GetAttachment(w, req)
+		s.Equal(http.StatusNotFound, w.Code)
+	})
+}
+
+func (s *SuiteAttachment) TestDelete() {
+	s.cu.On("Delete", mock.Anything, "01", "02").Return(nil)
+	req, _ := http.NewRequest("DELETE", "/task/01/attachments/02/", nil)
+	req = mux.SetURLVars(req, map[string]string{"task_id": "01", "attachment_id": "02"})
+	w := httptest.NewRecorder()
+	s.handler.DeleteAttachment(w, req)
+	s.Equal(http.StatusAccepted, w.Code)
+	s.Equal("{}", w.Body.String())
+
+	s.cu.On("Delete", mock.Anything, "01", "03").Return(errors.New("conflict_delete"))
+	req, _ = http.NewRequest("DELETE", "/task/01/attachments/03/", nil)
+	req = mux.SetURLVars(req, map[string]string{"task_id": "01", "attachment_id": "03"})
+	w = httptest.NewRecorder()
+	s.handler.DeleteAttachment(w, req)
+	s.Equal(http.StatusConflict, w.Code)
+	s.Equal("{\"message\":\"conflict_delete\"}", w.Body.String())
+}
+
+func (s *SuiteAttachment) TestDownload() {
+	s.Run("When the use case is succesful", func() {
+		f, _ := ioutil.TempFile("", "blob-")
+		defer os.Remove(f.Name())
+		f.WriteString("hello world")
+		f.Seek(0, io.SeekStart)
+		attachment := &domain.Attachment{Name: "notes.txt", ContentType: "text/plain", Key: "abc"}
+		s.cu.On("Open", mock.Anything, "01", "02").Return(attachment, f, nil).Once()
+
+		req, _ := http.NewRequest("GET", "/task/01/files/02/", nil)
+		req = mux.SetURLVars(req, map[string]string{"task_id": "01", "attachment_id": "02"})
+		w := httptest.NewRecorder()
+		s.handler.DownloadAttachment(w, req)
+		s.Equal(http.StatusOK, w.Code)
+		s.Equal("hello world", w.Body.String())
+		s.Equal("text/plain", w.Header().Get("Content-Type"))
+		s.Equal("attachment; filename=notes.txt", w.Header().Get("Content-Disposition"))
+		s.Equal(`"abc"`, w.Header().Get("ETag"))
+	})
+
+	s.Run("When a range is requested", func() {
+		f, _ := ioutil.TempFile("", "blob-")
+		defer os.Remove(f.Name())
+		f.WriteString("hello world")
+		attachment := &domain.Attachment{Name: "notes.txt", ContentType: "text/plain", Key: "abc"}
+		s.cu.On("Open", mock.Anything, "01", "02").Return(attachment, f, nil).Once()
+
+		req, _ := http.NewRequest("GET", "/task/01/files/02/", nil)
+		req.Header.Set("Range", "bytes=6-")
+		req = mux.SetURLVars(req, map[string]string{"task_id": "01", "attachment_id": "02"})
+		w := httptest.NewRecorder()
+		s.handler.DownloadAttachment(w, req)
+		s.Equal(http.StatusPartialContent, w.Code)
+		s.Equal("world", w.Body.String())
+		s.Equal("bytes 6-10/11", w.Header().Get("Content-Range"))
+	})
+
+	s.Run("When the file does not exist", func() {
+		s.cu.On("Open", mock.Anything, "01", "03").Return(nil, nil, errors.New("not_found"))
+		req, _ := http.NewRequest("GET", "/task/01/files/03/", nil)
+		req = mux.SetURLVars(req, map[string]string{"task_id": "01", "attachment_id": "03"})
+		w := httptest.NewRecorder()
+		s.handler.DownloadAttachment(w, req)
+		s.Equal(http.StatusNotFound, w.Code)
+	})
+}
+
+func TestSuiteAttachment(t *testing.T) {
+	suite.Run(t, new(SuiteAttachment))
+}
@@ -0,0 +1,51 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/isaias-dgr/todo/src/health"
+	"go.uber.org/zap"
+)
+
+type HealthHandler struct {
+	Checker *health.Checker
+	L       *zap.SugaredLogger
+}
+
+func NewHealthHandler(r *mux.Router, checker *health.Checker, logger *zap.SugaredLogger) {
+	handler := &HealthHandler{
+		Checker: checker,
+		L:       logger,
+	}
+
+	r.HandleFunc("/healthz", handler.Live).Methods("GET")
+	r.HandleFunc("/readyz", handler.Ready).Methods("GET")
+	r.HandleFunc("/version", handler.Version).Methods("GET")
+}
+
+// Live only tells the process answers; a dependency being down must not get
+// it restarted.
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	makeResponse(w, http.StatusOK, map[string]string{"status": "ok"}, nil, 0)
+}
+
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	report, err := h.Checker.Ready(r.Context())
+	if err != nil {
+		h.L.Warnw("Not ready", "error", err.Error(), "checks", report)
+		makeResponse(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"status": err.Error(),
+			"checks": report,
+		}, nil, 0)
+		return
+	}
+	makeResponse(w, http.StatusOK, map[string]interface{}{
+		"status": "ok",
+		"checks": report,
+	}, nil, 0)
+}
+
+func (h *HealthHandler) Version(w http.ResponseWriter, r *http.Request) {
+	makeResponse(w, http.StatusOK, health.CurrentBuild(), nil, 0)
+}
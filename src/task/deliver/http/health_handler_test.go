@@ -0,0 +1,82 @@
+package http_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/isaias-dgr/todo/src/health"
+	_TaskHttp "github.com/isaias-dgr/todo/src/task/deliver/http"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type SuiteHealth struct {
+	suite.Suite
+	checker *health.Checker
+	router  *mux.Router
+	dbErr   error
+}
+
+func (s *SuiteHealth) SetupTest() {
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	s.dbErr = nil
+	s.checker = health.NewChecker(time.Second)
+	s.checker.Add("database", func(ctx context.Context) error { return s.dbErr })
+	s.router = mux.NewRouter()
+	_TaskHttp.NewHealthHandler(s.router, s.checker, logger.Sugar())
+}
+
+func (s *SuiteHealth) get(path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func (s *SuiteHealth) TestLive() {
+	s.dbErr = errors.New("query_context")
+	w := s.get("/healthz")
+	s.Equal(http.StatusOK, w.Code)
+	s.JSONEq(`{"data": {"status": "ok"}}`, w.Body.String())
+}
+
+func (s *SuiteHealth) TestReady() {
+	s.Run("When every check passes", func() {
+		w := s.get("/readyz")
+		s.Equal(http.StatusOK, w.Code)
+		s.JSONEq(`{"data": {"status": "ok", "checks": {"database": "ok"}}}`, w.Body.String())
+	})
+
+	s.Run("When the database is down", func() {
+		s.dbErr = errors.New("query_context")
+		w := s.get("/readyz")
+		s.Equal(http.StatusServiceUnavailable, w.Code)
+		s.JSONEq(`{"data": {"status": "query_context", "checks": {"database": "query_context"}}}`, w.Body.String())
+	})
+
+	s.Run("When the service is shutting down", func() {
+		s.dbErr = nil
+		s.checker.Shutdown()
+		w := s.get("/readyz")
+		s.Equal(http.StatusServiceUnavailable, w.Code)
+		s.JSONEq(`{"data": {"status": "shutting_down", "checks": {}}}`, w.Body.String())
+	})
+}
+
+func (s *SuiteHealth) TestVersion() {
+	health.Commit = "abc123"
+	defer func() { health.Commit = "unknown" }()
+	w := s.get("/version")
+	s.Equal(http.StatusOK, w.Code)
+	s.Contains(w.Body.String(), `"commit":"abc123"`)
+	s.Contains(w.Body.String(), `"go_version":"go`)
+}
+
+func TestSuiteHealth(t *testing.T) {
+	suite.Run(t, new(SuiteHealth))
+}
@@ -0,0 +1,140 @@
+package http
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	wsWriteWait  = 10 * time.Second
+	wsPongWait   = 60 * time.Second
+	wsPingPeriod = (wsPongWait * 9) / 10
+	wsMaxMessage = 64 << 10
+	wsSendQueue  = 32
+)
+
+// Hub keeps track of the open sockets so they can be counted and closed
+// together on shutdown. Events reach each socket through its own broker
+// subscription, so a slow socket never holds up the others.
+type Hub struct {
+	mu      sync.Mutex
+	clients map[*wsClient]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{clients: map[*wsClient]struct{}{}}
+}
+
+func (h *Hub) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients)
+}
+
+// Close sends a going away frame to every socket.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.clients {
+		c.close()
+	}
+}
+
+func (h *Hub) add(c *wsClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clients[c] = struct{}{}
+}
+
+func (h *Hub) remove(c *wsClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.clients, c)
+}
+
+// wsClient owns one socket. Only writePump writes to the connection;
+// everything else queues on send. Command replies wait for room in the
+// queue, which stops reading from a client that doesn't read its replies,
+// while events are dropped together with the socket when the queue is full.
+type wsClient struct {
+	conn        *websocket.Conn
+	send        chan []byte
+	done        chan struct{}
+	once        sync.Once
+	unsubscribe context.CancelFunc
+	mu          sync.Mutex
+}
+
+func newWsClient(conn *websocket.Conn) *wsClient {
+	return &wsClient{
+		conn: conn,
+		send: make(chan []byte, wsSendQueue),
+		done: make(chan struct{}),
+	}
+}
+
+func (c *wsClient) close() {
+	c.once.Do(func() {
+		close(c.done)
+		c.setSubscription(nil)
+	})
+}
+
+func (c *wsClient) setSubscription(cancel context.CancelFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.unsubscribe != nil {
+		c.unsubscribe()
+	}
+	c.unsubscribe = cancel
+}
+
+func (c *wsClient) queue(msg []byte) {
+	select {
+	case c.send <- msg:
+	case <-c.done:
+	}
+}
+
+func (c *wsClient) tryQueue(msg []byte) bool {
+	select {
+	case c.send <- msg:
+		return true
+	case <-c.done:
+		return false
+	default:
+		return false
+	}
+}
+
+func (c *wsClient) writePump() {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+	for {
+		select {
+		case msg := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				c.close()
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				c.close()
+				return
+			}
+		case <-c.done:
+			c.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, ""),
+				time.Now().Add(wsWriteWait))
+			return
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/isaias-dgr/todo/src/domain"
+)
+
+// UserMiddleware puts the caller sent by the gateway in X-User-ID, and the
+// API key it authenticated in X-API-Key-ID, into the request context.
+func UserMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if userID := r.Header.Get("X-User-ID"); userID != "" {
+			r = r.WithContext(domain.WithUser(r.Context(), userID))
+		}
+		if keyID := r.Header.Get("X-API-Key-ID"); keyID != "" {
+			r = r.WithContext(domain.WithAPIKey(r.Context(), keyID))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/isaias-dgr/todo/src/domain"
+	"go.uber.org/zap"
+)
+
+// StreamPath serves a stream, so it has no read or write deadline.
+const StreamPath = "/task/stream/"
+
+type StreamHandler struct {
+	Broker    domain.Broker
+	L         *zap.SugaredLogger
+	KeepAlive time.Duration
+}
+
+// NewStreamHandler must be registered before NewTaskHandler, otherwise
+// /task/{task_id}/ catches the stream path.
+func NewStreamHandler(r *mux.Router, broker domain.Broker, logger *zap.SugaredLogger) {
+	handler := &StreamHandler{
+		Broker:    broker,
+		L:         logger,
+		KeepAlive: 15 * time.Second,
+	}
+
+	r.HandleFunc(StreamPath, handler.Stream).Methods("GET")
+}
+
+// Stream pushes task events as Server-Sent Events. When the Last-Event-ID
+// is no longer in the replay buffer the client gets a "reset" event and
+// should reload the list before relying on the stream again.
+func (s *StreamHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		errorResponse(w, http.StatusInternalServerError, "streaming_unsupported")
+		return
+	}
+
+	ctx := r.Context()
+	userID := domain.UserFromContext(ctx)
+	reset := false
+	events, err := s.Broker.Subscribe(ctx, userID, r.Header.Get("Last-Event-ID"))
+	if errors.Is(err, domain.ErrEventExpired) {
+		reset = true
+		events, err = s.Broker.Subscribe(ctx, userID, "")
+	}
+	if errors.Is(err, domain.ErrBrokerClosed) {
+		errorResponse(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if reset {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(s.KeepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				s.L.Error(err.Error())
+				continue
+			}
+			fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+			flusher.Flush()
+		}
+	}
+}
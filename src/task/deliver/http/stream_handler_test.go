@@ -0,0 +1,111 @@
+package http_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/domain/mocks"
+	h "github.com/isaias-dgr/todo/src/task/deliver/http"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type SuiteStream struct {
+	suite.Suite
+	broker  *mocks.Broker
+	handler *h.StreamHandler
+}
+
+func (s *SuiteStream) SetupTest() {
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	s.broker = new(mocks.Broker)
+	s.handler = &h.StreamHandler{
+		Broker:    s.broker,
+		L:         logger.Sugar(),
+		KeepAlive: time.Hour,
+	}
+}
+
+func (s *SuiteStream) event() *domain.Event {
+	task := domain.NewTask("title", "description")
+	task.ID = uuid.New()
+	e, _ := domain.NewEvent(domain.TaskCreated, task)
+	return e
+}
+
+func (s *SuiteStream) TestStream() {
+	s.Run("When events are published", func() {
+		e := s.event()
+		events := make(chan *domain.Event, 1)
+		events <- e
+		close(events)
+		s.broker.On("Subscribe", mock.Anything, "user-1", "").Return((<-chan *domain.Event)(events), nil).Once()
+
+		req, _ := http.NewRequest("GET", "/task/stream/", nil)
+		req = req.WithContext(domain.WithUser(req.Context(), "user-1"))
+		w := httptest.NewRecorder()
+		s.handler.Stream(w, req)
+		s.Equal(http.StatusOK, w.Code)
+		s.Equal("text/event-stream", w.Header().Get("Content-Type"))
+		s.True(strings.HasPrefix(w.Body.String(), "id: "+e.ID.String()+"\nevent: task.created\ndata: {"))
+	})
+
+	s.Run("When the last event id expired", func() {
+		events := make(chan *domain.Event)
+		close(events)
+		s.broker.On("Subscribe", mock.Anything, "", "gone").Return(nil, domain.ErrEventExpired).Once()
+		s.broker.On("Subscribe", mock.Anything, "", "").Return((<-chan *domain.Event)(events), nil).Once()
+
+		req, _ := http.NewRequest("GET", "/task/stream/", nil)
+		req.Header.Set("Last-Event-ID", "gone")
+		w := httptest.NewRecorder()
+		s.handler.Stream(w, req)
+		s.Equal(http.StatusOK, w.Code)
+		s.Equal("event: reset\ndata: {}\n\n", w.Body.String())
+	})
+
+	s.Run("When the client goes away", func() {
+		events := make(chan *domain.Event)
+		s.broker.On("Subscribe", mock.Anything, "", "").Return((<-chan *domain.Event)(events), nil).Once()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		req, _ := http.NewRequest("GET", "/task/stream/", nil)
+		w := httptest.NewRecorder()
+		s.handler.Stream(w, req.WithContext(ctx))
+		s.Equal(http.StatusOK, w.Code)
+	})
+
+	s.Run("When the broker fails", func() {
+		s.broker.On("Subscribe", mock.Anything, "", "").Return(nil, errors.New("broker_closed")).Once()
+		req, _ := http.NewRequest("GET", "/task/stream/", nil)
+		w := httptest.NewRecorder()
+		s.handler.Stream(w, req)
+		s.Equal(http.StatusInternalServerError, w.Code)
+	})
+}
+
+func TestUserMiddleware(t *testing.T) {
+	var got string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = domain.UserFromContext(r.Context())
+	})
+	req, _ := http.NewRequest("GET", "/task/", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	h.UserMiddleware(next).ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "user-1", got)
+}
+
+func TestSuiteStream(t *testing.T) {
+	suite.Run(t, new(SuiteStream))
+}
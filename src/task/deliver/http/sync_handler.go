@@ -0,0 +1,65 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/isaias-dgr/todo/src/domain"
+	"go.uber.org/zap"
+)
+
+type SyncHandler struct {
+	SuseCase domain.SyncUseCase
+	L        *zap.SugaredLogger
+}
+
+func NewSyncHandler(r *mux.Router, syncUseCase domain.SyncUseCase, logger *zap.SugaredLogger) {
+	handler := &SyncHandler{
+		SuseCase: syncUseCase,
+		L:        logger,
+	}
+
+	r.HandleFunc("/sync/", handler.Pull).Methods("GET")
+	r.HandleFunc("/sync/", handler.Push).Methods("POST")
+}
+
+func (s *SyncHandler) Pull(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	set, err := s.SuseCase.Pull(r.Context(), qs.Get("since"), domain.GetIntDefault(qs, "limit", 100))
+	if err != nil {
+		errorResponse(w, syncStatus(err), err.Error())
+		return
+	}
+	makeResponse(w, http.StatusOK, set, nil, 0)
+}
+
+// Push answers 200 even when some changes were rejected or lost a
+// conflict; each one has its own status in the results.
+func (s *SyncHandler) Push(w http.ResponseWriter, r *http.Request) {
+	var push domain.SyncPush
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&push); err != nil {
+		errorResponse(w, http.StatusBadRequest, "bad request: "+err.Error())
+		return
+	}
+	reply, err := s.SuseCase.Push(r.Context(), &push)
+	if err != nil {
+		errorResponse(w, syncStatus(err), err.Error())
+		return
+	}
+	makeResponse(w, http.StatusOK, reply, nil, 0)
+}
+
+func syncStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrSyncToken):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrSyncBatch):
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusInternalServerError
+	}
+}
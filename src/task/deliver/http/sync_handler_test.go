@@ -0,0 +1,92 @@
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/domain/mocks"
+	h "github.com/isaias-dgr/todo/src/task/deliver/http"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type SuiteSync struct {
+	suite.Suite
+	cu      *mocks.SyncUseCase
+	handler *h.SyncHandler
+}
+
+func (s *SuiteSync) SetupTest() {
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	s.cu = new(mocks.SyncUseCase)
+	s.handler = &h.SyncHandler{
+		SuseCase: s.cu,
+		L:        logger.Sugar(),
+	}
+}
+
+func (s *SuiteSync) TestPull() {
+	s.Run("When the use case is succesful", func() {
+		id := uuid.MustParse("6f1c1b0e-3a4d-4c8e-9b7a-2d5e8f0a1b2c")
+		s.cu.On("Pull", mock.Anything, "41", 100).Return(&domain.ChangeSet{
+			Changes: []*domain.Change{{Seq: 42, Op: domain.SyncDelete, TaskID: id}},
+			Token:   "42",
+		}, nil)
+		req := httptest.NewRequest("GET", "/sync/?since=41", nil)
+		w := httptest.NewRecorder()
+		s.handler.Pull(w, req)
+		s.Equal(http.StatusOK, w.Code)
+		s.JSONEq(`{"data":{"changes":[{"op":"delete","task_id":"6f1c1b0e-3a4d-4c8e-9b7a-2d5e8f0a1b2c"}],"token":"42","has_more":false}}`, w.Body.String())
+	})
+
+	s.Run("When the token is not valid", func() {
+		s.cu.On("Pull", mock.Anything, "abc", 5).Return(nil, domain.ErrSyncToken)
+		req := httptest.NewRequest("GET", "/sync/?since=abc&limit=5", nil)
+		w := httptest.NewRecorder()
+		s.handler.Pull(w, req)
+		s.Equal(http.StatusBadRequest, w.Code)
+		s.Equal(`{"message":"sync_token"}`, w.Body.String())
+	})
+}
+
+func (s *SuiteSync) TestPush() {
+	s.Run("When the use case is succesful", func() {
+		s.cu.On("Push", mock.Anything, mock.MatchedBy(func(p *domain.SyncPush) bool {
+			return p.Since == "3" && len(p.Changes) == 1 && p.Changes[0].Op == domain.SyncUpsert
+		})).Return(&domain.SyncReply{
+			Results:   []*domain.SyncResult{{TaskID: "6f1c1b0e-3a4d-4c8e-9b7a-2d5e8f0a1b2c", Status: domain.SyncApplied}},
+			ChangeSet: &domain.ChangeSet{Changes: []*domain.Change{}, Token: "4"},
+		}, nil)
+		body := `{"since":"3","changes":[{"op":"upsert","task_id":"6f1c1b0e-3a4d-4c8e-9b7a-2d5e8f0a1b2c","title":"t","description":"d"}]}`
+		req := httptest.NewRequest("POST", "/sync/", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		s.handler.Push(w, req)
+		s.Equal(http.StatusOK, w.Code)
+		s.JSONEq(`{"data":{"results":[{"task_id":"6f1c1b0e-3a4d-4c8e-9b7a-2d5e8f0a1b2c","status":"applied"}],"changes":[],"token":"4","has_more":false}}`, w.Body.String())
+	})
+
+	s.Run("When the payload has a error", func() {
+		req := httptest.NewRequest("POST", "/sync/", strings.NewReader(`{"since":"3","deletes":[]}`))
+		w := httptest.NewRecorder()
+		s.handler.Push(w, req)
+		s.Equal(http.StatusBadRequest, w.Code)
+	})
+
+	s.Run("When the batch is too large", func() {
+		s.cu.On("Push", mock.Anything, mock.Anything).Return(nil, domain.ErrSyncBatch)
+		req := httptest.NewRequest("POST", "/sync/", strings.NewReader(`{"since":"3","changes":[]}`))
+		w := httptest.NewRecorder()
+		s.handler.Push(w, req)
+		s.Equal(http.StatusRequestEntityTooLarge, w.Code)
+	})
+}
+
+func TestSuiteSync(t *testing.T) {
+	suite.Run(t, new(SuiteSync))
+}
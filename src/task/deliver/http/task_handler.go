@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"strings"
 
@@ -19,24 +18,26 @@ type TaskHandler struct {
 	L        *zap.SugaredLogger
 }
 
-func NewTaskHandler(taskUseCase domain.TaskUseCase, logger *zap.SugaredLogger) {
+func NewTaskHandler(r *mux.Router, taskUseCase domain.TaskUseCase, logger *zap.SugaredLogger) {
 	handler := &TaskHandler{
 		TuseCase: taskUseCase,
 		L:        logger,
 	}
 
-	r := mux.NewRouter()
 	r.HandleFunc("/task/", handler.FetchTasks).Methods("GET")
 	r.HandleFunc("/task/", handler.InsertTask).Methods("POST")
 	r.HandleFunc("/task/{task_id}/", handler.GetTask).Methods("GET")
 	r.HandleFunc("/task/{task_id}/", handler.UpdateTask).Methods("PUT")
 	r.HandleFunc("/task/{task_id}/", handler.DeleteTask).Methods("DELETE")
-	log.Fatal(http.ListenAndServe(":8080", r))
+	r.HandleFunc("/task/{task_id}/complete/", handler.CompleteTask).Methods("POST")
 }
 
 func (t *TaskHandler) FetchTasks(w http.ResponseWriter, r *http.Request) {
-	t.L.Infow("Fetch", "url", r.URL, "method", r.Method)
 	filter := domain.NewFilter(r.URL.Query())
+	if err := filter.Validate(); err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	tasks, err := t.TuseCase.Fetch(r.Context(), filter)
 	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, err.Error())
@@ -46,26 +47,25 @@ func (t *TaskHandler) FetchTasks(w http.ResponseWriter, r *http.Request) {
 }
 
 func (t *TaskHandler) InsertTask(w http.ResponseWriter, r *http.Request) {
-	t.L.Infow("Insert", "url", r.URL, "method", r.Method)
 	var task domain.Task
 	if err := t.DecoderBody(r.Body, &task); err != nil {
 		errorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	if err := validate(&task); err != nil {
-		errorResponse(w, http.StatusBadRequest, err.Error())
-		return
-	}
 	if err := t.TuseCase.Insert(r.Context(), &task); err != nil {
-		errorResponse(w, http.StatusBadRequest, err.Error())
+		code := http.StatusBadRequest
+		if errors.Is(err, domain.ErrQuotaExceeded) {
+			code = http.StatusForbidden
+		}
+		writeError(w, code, err)
 		return
 	}
 	makeResponse(w, http.StatusAccepted, task, nil, 0)
 }
 
+// UpdateTask creates the task when the client picked a new id for it.
 func (t *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
-	t.L.Infow("Update", "url", r.URL, "method", r.Method)
 	var task domain.Task
 
 	if err := t.DecoderBody(r.Body, &task); err != nil {
@@ -73,17 +73,21 @@ func (t *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := validate(&task); err != nil {
-		errorResponse(w, http.StatusBadRequest, err.Error())
-		return
-	}
 	vars := mux.Vars(r)
-	err := t.TuseCase.Update(r.Context(), vars["task_id"], &task)
+	created, err := t.TuseCase.Upsert(r.Context(), vars["task_id"], &task)
 	if err != nil {
-		errorResponse(w, http.StatusBadRequest, err.Error())
+		code := taskStatus(err, http.StatusBadRequest)
+		if errors.Is(err, domain.ErrQuotaExceeded) {
+			code = http.StatusForbidden
+		}
+		writeError(w, code, err)
 		return
 	}
-	makeResponse(w, http.StatusAccepted, task, nil, 0)
+	if created {
+		makeResponse(w, http.StatusCreated, task, nil, 0)
+		return
+	}
+	makeResponse(w, http.StatusOK, task, nil, 0)
 }
 
 func (t *TaskHandler) DecoderBody(b io.ReadCloser, ta *domain.Task) error {
@@ -102,19 +106,7 @@ func (t *TaskHandler) DecoderBody(b io.ReadCloser, ta *domain.Task) error {
 	return nil
 }
 
-func validate(t *domain.Task) error {
-	if strings.TrimSpace(t.Title) == "" {
-		return errors.New("bad request: Requiered field title")
-	}
-
-	if strings.TrimSpace(t.Description) == "" {
-		return errors.New("bad request: Requiered field description")
-	}
-	return nil
-}
-
 func (t *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
-	t.L.Infow("Get by uuid", "url", r.URL, "method", r.Method)
 	vars := mux.Vars(r)
 	task, err := t.TuseCase.GetByID(r.Context(), vars["task_id"])
 	if err != nil {
@@ -125,16 +117,35 @@ func (t *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
 }
 
 func (t *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
-	t.L.Infow("Delete", "url", r.URL, "method", r.Method)
 	vars := mux.Vars(r)
 	err := t.TuseCase.Delete(r.Context(), vars["task_id"])
 	if err != nil {
-		errorResponse(w, http.StatusNotFound, err.Error())
+		errorResponse(w, taskStatus(err, http.StatusNotFound), err.Error())
 		return
 	}
 	makeResponse(w, http.StatusAccepted, nil, nil, 0)
 }
 
+// CompleteTask answers with the task, also when it was already completed.
+func (t *TaskHandler) CompleteTask(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	task, err := t.TuseCase.Complete(r.Context(), vars["task_id"])
+	if err != nil {
+		errorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+	makeResponse(w, http.StatusOK, task, nil, 0)
+}
+
+// taskStatus answers 409 when the task changed under the request, as the
+// conflict_ errors of the repositories tell, and code otherwise.
+func taskStatus(err error, code int) int {
+	if strings.HasPrefix(err.Error(), "conflict_") {
+		return http.StatusConflict
+	}
+	return code
+}
+
 func makeResponse(w http.ResponseWriter, code int, body interface{}, filter *domain.Filter, total int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
@@ -151,3 +162,20 @@ func errorResponse(w http.ResponseWriter, code int, message string) {
 	jsonResp, _ := json.Marshal(resp)
 	w.Write(jsonResp)
 }
+
+// writeError adds the violations of each field when err is a validation
+// error.
+func writeError(w http.ResponseWriter, code int, err error) {
+	var verr *domain.ValidationError
+	if !errors.As(err, &verr) {
+		errorResponse(w, code, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	jsonResp, _ := json.Marshal(map[string]interface{}{
+		"message": err.Error(),
+		"errors":  verr.Fields,
+	})
+	w.Write(jsonResp)
+}
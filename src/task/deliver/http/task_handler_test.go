@@ -1,22 +1,32 @@
 package http_test
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/isaias-dgr/todo/src/domain"
 	"github.com/isaias-dgr/todo/src/domain/mocks"
 	h "github.com/isaias-dgr/todo/src/task/deliver/http"
+	"github.com/isaias-dgr/todo/src/task/repository/memory"
+	useCase "github.com/isaias-dgr/todo/src/task/usecase"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 	"go.uber.org/zap"
 )
 
+// validate stands in for the use case, which is where tasks are validated.
+func validate(_ context.Context, t *domain.Task) error {
+	return t.Validate()
+}
+
 type SuiteTodo struct {
 	suite.Suite
 	cu      *mocks.TaskUseCase
@@ -74,6 +84,16 @@ func (s *SuiteTodo) TestFetch() {
 		msg := []byte("{\"message\":\"error\"}")
 		s.Equal(msg, w.Body.Bytes())
 	})
+
+	s.Run("When the page is negative", func() {
+		req, err := http.NewRequest("GET", "/task/?offset=-1", strings.NewReader(""))
+		s.NoError(err)
+		w := httptest.NewRecorder()
+
+		s.handler.FetchTasks(w, req)
+		s.Equal(http.StatusBadRequest, w.Code)
+		s.Contains(w.Body.String(), domain.ErrNegativePage.Error())
+	})
 }
 
 func (s *SuiteTodo) TestInsert() {
@@ -103,8 +123,18 @@ func (s *SuiteTodo) TestInsert() {
 		s.Equal(expected, w.Body.String())
 	})
 
+	s.Run("When the user has no quota left", func() {
+		task := domain.NewTask("t003", "td00003")
+		s.cu.On("Insert", mock.Anything, task).Return(domain.ErrQuotaExceeded)
+		req, err := http.NewRequest("POST", "/task/", strings.NewReader("{\"title\": \"t003\",\"description\": \"td00003\"}"))
+		s.NoError(err)
+		w := httptest.NewRecorder()
+		s.handler.InsertTask(w, req)
+		s.Equal(http.StatusForbidden, w.Code)
+		s.Equal("{\"message\":\"quota_exceeded\"}", w.Body.String())
+	})
+
 	s.Run("When the payload has a error", func() {
-		s.cu.On("Insert", mock.Anything, mock.Anything).Return(nil)
 		req, err := http.NewRequest("POST", "/task/", strings.NewReader("{\"title\": \"t002\",\"description\": \"td00002}"))
 		s.NoError(err)
 		w := httptest.NewRecorder()
@@ -116,7 +146,6 @@ func (s *SuiteTodo) TestInsert() {
 	})
 
 	s.Run("When the payload has invalid type of value", func() {
-		s.cu.On("Insert", mock.Anything, mock.Anything).Return(nil)
 		req, err := http.NewRequest("POST", "/task/", strings.NewReader("{\"title\": 1,\"description\": \"td00002\"}"))
 		s.NoError(err)
 		w := httptest.NewRecorder()
@@ -128,48 +157,92 @@ func (s *SuiteTodo) TestInsert() {
 	})
 
 	s.Run("When the payload with description empty value", func() {
-		s.cu.On("Insert", mock.Anything, mock.Anything).Return(nil)
+		s.cu.On("Insert", mock.Anything, mock.Anything).Return(validate).Once()
 		req, err := http.NewRequest("POST", "/task/", strings.NewReader("{\"title\": \"title\",\"description\": \" \"}"))
 		s.NoError(err)
 		w := httptest.NewRecorder()
 		s.handler.InsertTask(w, req)
 		s.Equal(http.StatusBadRequest, w.Code)
 		s.NoError(err)
-		expected := "{\"message\":\"bad request: Requiered field description\"}"
+		expected := "{\"errors\":{\"description\":[\"is required\"]},\"message\":\"validation_failed\"}"
 		s.Equal(expected, w.Body.String())
 	})
 
 	s.Run("When the payload with title empty value", func() {
-		s.cu.On("Insert", mock.Anything, mock.Anything).Return(nil)
+		s.cu.On("Insert", mock.Anything, mock.Anything).Return(validate).Once()
 		req, err := http.NewRequest("POST", "/task/", strings.NewReader("{\"title\": \"  \",\"description\": \"desc\"}"))
 		s.NoError(err)
 		w := httptest.NewRecorder()
 		s.handler.InsertTask(w, req)
 		s.Equal(http.StatusBadRequest, w.Code)
 		s.NoError(err)
-		expected := "{\"message\":\"bad request: Requiered field title\"}"
+		expected := "{\"errors\":{\"title\":[\"is required\"]},\"message\":\"validation_failed\"}"
+		s.Equal(expected, w.Body.String())
+	})
+
+	s.Run("When every field is invalid", func() {
+		s.cu.On("Insert", mock.Anything, mock.Anything).Return(validate).Once()
+		body := fmt.Sprintf("{\"title\": \"a\\nb\",\"description\": %q}", strings.Repeat("d", 256))
+		req, err := http.NewRequest("POST", "/task/", strings.NewReader(body))
+		s.NoError(err)
+		w := httptest.NewRecorder()
+		s.handler.InsertTask(w, req)
+		s.Equal(http.StatusBadRequest, w.Code)
+		expected := "{\"errors\":{\"description\":[\"must be at most 255 characters\"],\"title\":[\"contains characters that are not allowed\"]},\"message\":\"validation_failed\"}"
 		s.Equal(expected, w.Body.String())
 	})
 }
 
 func (s *SuiteTodo) TestUpdate() {
-	s.Run("When the use case is succesful", func() {
-		s.cu.On("Update", mock.Anything, "000000", mock.Anything).Return(nil)
+	s.Run("When the task is created", func() {
+		s.cu.On("Upsert", mock.Anything, "000000", mock.Anything).Return(true, nil)
 		req, err := http.NewRequest("PUT", "/task/000000", strings.NewReader("{\"title\": \"t001\",\"description\": \"td00001\"}"))
 		s.NoError(err)
 		vars := map[string]string{"task_id": "000000"}
 		req = mux.SetURLVars(req, vars)
 		w := httptest.NewRecorder()
 		s.handler.UpdateTask(w, req)
-		s.Equal(http.StatusAccepted, w.Code)
+		s.Equal(http.StatusCreated, w.Code)
 		s.NoError(err)
 		expected := "{\"data\":{\"id\":\"00000000-0000-0000-0000-000000000000\",\"title\":\"t001\",\"description\":\"td00001\"}}"
 		s.Equal(expected, w.Body.String())
 	})
 
+	s.Run("When the task is replaced", func() {
+		s.cu.On("Upsert", mock.Anything, "000001", mock.Anything).Return(false, nil)
+		req, err := http.NewRequest("PUT", "/task/000001", strings.NewReader("{\"title\": \"t001\",\"description\": \"td00001\"}"))
+		s.NoError(err)
+		req = mux.SetURLVars(req, map[string]string{"task_id": "000001"})
+		w := httptest.NewRecorder()
+		s.handler.UpdateTask(w, req)
+		s.Equal(http.StatusOK, w.Code)
+	})
+
+	s.Run("When the quota is reached", func() {
+		s.cu.On("Upsert", mock.Anything, "000004", mock.Anything).Return(false, domain.ErrQuotaExceeded)
+		req, err := http.NewRequest("PUT", "/task/000004", strings.NewReader("{\"title\": \"t001\",\"description\": \"td00001\"}"))
+		s.NoError(err)
+		req = mux.SetURLVars(req, map[string]string{"task_id": "000004"})
+		w := httptest.NewRecorder()
+		s.handler.UpdateTask(w, req)
+		s.Equal(http.StatusForbidden, w.Code)
+		s.Equal("{\"message\":\"quota_exceeded\"}", w.Body.String())
+	})
+
+	s.Run("When the task changed under the request", func() {
+		s.cu.On("Upsert", mock.Anything, "000005", mock.Anything).Return(false, errors.New("conflict_upsert"))
+		req, err := http.NewRequest("PUT", "/task/000005", strings.NewReader("{\"title\": \"t001\",\"description\": \"td00001\"}"))
+		s.NoError(err)
+		req = mux.SetURLVars(req, map[string]string{"task_id": "000005"})
+		w := httptest.NewRecorder()
+		s.handler.UpdateTask(w, req)
+		s.Equal(http.StatusConflict, w.Code)
+		s.Equal("{\"message\":\"conflict_upsert\"}", w.Body.String())
+	})
+
 	s.Run("When the use case return a generic error", func() {
-		s.cu.On("Update", mock.Anything, "000002", mock.Anything).
-			Return(errors.New("G error"))
+		s.cu.On("Upsert", mock.Anything, "000002", mock.Anything).
+			Return(false, errors.New("G error"))
 		req, err := http.NewRequest("PUT", "/task/000002", strings.NewReader("{\"title\": \"t001\",\"description\": \"td00001\"}"))
 		s.NoError(err)
 		vars := map[string]string{"task_id": "000002"}
@@ -183,7 +256,6 @@ func (s *SuiteTodo) TestUpdate() {
 	})
 
 	s.Run("When the payload has a error", func() {
-		s.cu.On("Update", mock.Anything, "000003", mock.Anything).Return(nil)
 		req, err := http.NewRequest("PUT", "/task/000003", strings.NewReader("{\"title\": \"t002\",\"description\": \"td00002}"))
 		s.NoError(err)
 		w := httptest.NewRecorder()
@@ -195,14 +267,16 @@ func (s *SuiteTodo) TestUpdate() {
 	})
 
 	s.Run("When the payload with description empty value", func() {
-		s.cu.On("Update", mock.Anything, "000003", mock.Anything).Return(nil)
+		s.cu.On("Upsert", mock.Anything, "000003", mock.Anything).Return(false,
+			func(_ context.Context, _ string, t *domain.Task) error { return t.Validate() })
 		req, err := http.NewRequest("PUT", "/task/000003", strings.NewReader("{\"title\": \"title\",\"description\": \" \"}"))
 		s.NoError(err)
+		req = mux.SetURLVars(req, map[string]string{"task_id": "000003"})
 		w := httptest.NewRecorder()
 		s.handler.UpdateTask(w, req)
 		s.Equal(http.StatusBadRequest, w.Code)
 		s.NoError(err)
-		expected := "{\"message\":\"bad request: Requiered field description\"}"
+		expected := "{\"errors\":{\"description\":[\"is required\"]},\"message\":\"validation_failed\"}"
 		s.Equal(expected, w.Body.String())
 	})
 }
@@ -235,6 +309,42 @@ func (s *SuiteTodo) TestDelete() {
 		expected := "{\"message\":\"G error\"}"
 		s.Equal(expected, w.Body.String())
 	})
+
+	s.Run("When the task changed under the request", func() {
+		s.cu.On("Delete", mock.Anything, "002").Return(errors.New("conflict_delete"))
+		req, err := http.NewRequest("DELETE", "/task/002", strings.NewReader(""))
+		s.NoError(err)
+		req = mux.SetURLVars(req, map[string]string{"task_id": "002"})
+		w := httptest.NewRecorder()
+		s.handler.DeleteTask(w, req)
+		s.Equal(http.StatusConflict, w.Code)
+		s.Equal("{\"message\":\"conflict_delete\"}", w.Body.String())
+	})
+}
+
+func (s *SuiteTodo) TestComplete() {
+	s.Run("When the use case is succesful", func() {
+		task := domain.NewTask("title", "description")
+		s.cu.On("Complete", mock.Anything, "000").Return(task, nil)
+		req, err := http.NewRequest("POST", "/task/000/complete/", nil)
+		s.NoError(err)
+		req = mux.SetURLVars(req, map[string]string{"task_id": "000"})
+		w := httptest.NewRecorder()
+		s.handler.CompleteTask(w, req)
+		s.Equal(http.StatusOK, w.Code)
+		s.Contains(w.Body.String(), "\"title\":\"title\"")
+	})
+
+	s.Run("When the task does not exist", func() {
+		s.cu.On("Complete", mock.Anything, "001").Return(nil, errors.New("not_found"))
+		req, err := http.NewRequest("POST", "/task/001/complete/", nil)
+		s.NoError(err)
+		req = mux.SetURLVars(req, map[string]string{"task_id": "001"})
+		w := httptest.NewRecorder()
+		s.handler.CompleteTask(w, req)
+		s.Equal(http.StatusNotFound, w.Code)
+		s.Equal("{\"message\":\"not_found\"}", w.Body.String())
+	})
 }
 
 func (s *SuiteTodo) TestGetTask() {
@@ -271,3 +381,68 @@ func (s *SuiteTodo) TestGetTask() {
 func TestSuiteTodo(t *testing.T) {
 	suite.Run(t, new(SuiteTodo))
 }
+
+// SuiteTodoMemory runs the handler over the use case and the in-memory
+// repository, so the whole life of a task goes through real code.
+type SuiteTodoMemory struct {
+	suite.Suite
+	router *mux.Router
+}
+
+func (s *SuiteTodoMemory) SetupTest() {
+	attachments := new(mocks.AttachmentUseCase)
+	attachments.On("DeleteByTask", mock.Anything, mock.Anything).Return(nil)
+	taskBroker := new(mocks.Broker)
+	taskBroker.On("Publish", mock.Anything)
+	repo := memory.NewTaskRepository(memory.NewDB(time.Now))
+	s.router = mux.NewRouter()
+	s.router.Use(h.UserMiddleware)
+	h.NewTaskHandler(s.router, useCase.NewTaskUseCase(repo, attachments, taskBroker, 2), zap.NewNop().Sugar())
+}
+
+func (s *SuiteTodoMemory) do(method, path, body string) (int, map[string]interface{}) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	var resp map[string]interface{}
+	s.Require().NoError(json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+func (s *SuiteTodoMemory) TestTaskLifecycle() {
+	code, resp := s.do("POST", "/task/", `{"title":"first","description":"description"}`)
+	s.Require().Equal(http.StatusAccepted, code)
+	id := resp["data"].(map[string]interface{})["id"].(string)
+
+	code, resp = s.do("GET", "/task/"+id+"/", "")
+	s.Equal(http.StatusOK, code)
+	s.Equal("first", resp["data"].(map[string]interface{})["title"])
+
+	other := "6f1c1b0e-3a4d-4c8e-9b7a-2d5e8f0a1b2c"
+	code, _ = s.do("PUT", "/task/"+other+"/", `{"title":"second","description":"description"}`)
+	s.Equal(http.StatusCreated, code)
+	code, _ = s.do("PUT", "/task/"+other+"/", `{"title":"second again","description":"description"}`)
+	s.Equal(http.StatusOK, code)
+
+	code, resp = s.do("POST", "/task/", `{"title":"third","description":"description"}`)
+	s.Equal(http.StatusForbidden, code)
+	s.Equal(domain.ErrQuotaExceeded.Error(), resp["message"])
+
+	code, resp = s.do("GET", "/task/?offset=1&limit=1", "")
+	s.Equal(http.StatusOK, code)
+	s.Equal(float64(2), resp["metadata"].(map[string]interface{})["total"])
+	data := resp["data"].([]interface{})
+	s.Require().Len(data, 1)
+	s.Equal("second again", data[0].(map[string]interface{})["title"])
+
+	code, _ = s.do("DELETE", "/task/"+id+"/", "")
+	s.Equal(http.StatusAccepted, code)
+	code, resp = s.do("GET", "/task/"+id+"/", "")
+	s.Equal(http.StatusNotFound, code)
+	s.Equal("not_found", resp["message"])
+}
+
+func TestSuiteTodoMemory(t *testing.T) {
+	suite.Run(t, new(SuiteTodoMemory))
+}
@@ -0,0 +1,175 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/isaias-dgr/todo/src/domain"
+	"go.uber.org/zap"
+)
+
+// WebSocketPath serves a stream, so it has no read or write deadline.
+const WebSocketPath = "/ws"
+
+type wsRequest struct {
+	ID          string       `json:"id,omitempty"`
+	Type        string       `json:"type"`
+	TaskID      string       `json:"task_id,omitempty"`
+	Task        *domain.Task `json:"task,omitempty"`
+	LastEventID string       `json:"last_event_id,omitempty"`
+}
+
+type wsResponse struct {
+	ID      string              `json:"id,omitempty"`
+	Type    string              `json:"type"`
+	Task    *domain.Task        `json:"task,omitempty"`
+	Event   *domain.Event       `json:"event,omitempty"`
+	Message string              `json:"message,omitempty"`
+	Errors  map[string][]string `json:"errors,omitempty"`
+}
+
+type WebSocketHandler struct {
+	TuseCase domain.TaskUseCase
+	Broker   domain.Broker
+	Hub      *Hub
+	L        *zap.SugaredLogger
+	Upgrader websocket.Upgrader
+}
+
+func NewWebSocketHandler(r *mux.Router, taskUseCase domain.TaskUseCase, broker domain.Broker, hub *Hub, logger *zap.SugaredLogger) {
+	handler := &WebSocketHandler{
+		TuseCase: taskUseCase,
+		Broker:   broker,
+		Hub:      hub,
+		L:        logger,
+	}
+
+	r.HandleFunc(WebSocketPath, handler.Serve).Methods("GET")
+}
+
+// Serve upgrades the connection and reads commands until the socket
+// closes. Messages are JSON objects with a "type" of subscribe,
+// unsubscribe, get, create, update or delete; replies echo the "id".
+func (h *WebSocketHandler) Serve(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.Upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	c := newWsClient(conn)
+	h.Hub.add(c)
+	defer h.Hub.remove(c)
+	defer c.close()
+	go c.writePump()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	conn.SetReadLimit(wsMaxMessage)
+	conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var req wsRequest
+		if err := json.Unmarshal(msg, &req); err != nil {
+			h.reply(c, wsResponse{Type: "error", Message: "bad request: " + err.Error()})
+			continue
+		}
+		h.dispatch(ctx, c, &req)
+	}
+}
+
+func (h *WebSocketHandler) dispatch(ctx context.Context, c *wsClient, req *wsRequest) {
+	var (
+		task *domain.Task
+		err  error
+	)
+	switch req.Type {
+	case "subscribe":
+		err = h.subscribe(ctx, c, req.LastEventID)
+	case "unsubscribe":
+		c.setSubscription(nil)
+	case "get":
+		task, err = h.TuseCase.GetByID(ctx, req.TaskID)
+	case "create":
+		task = commandTask(req)
+		err = h.TuseCase.Insert(ctx, task)
+	case "update":
+		task = commandTask(req)
+		err = h.TuseCase.Update(ctx, req.TaskID, task)
+	case "delete":
+		err = h.TuseCase.Delete(ctx, req.TaskID)
+	default:
+		h.reply(c, wsResponse{ID: req.ID, Type: "error", Message: "bad request: unknown type " + req.Type})
+		return
+	}
+	if err != nil {
+		resp := wsResponse{ID: req.ID, Type: "error", Message: err.Error()}
+		var verr *domain.ValidationError
+		if errors.As(err, &verr) {
+			resp.Errors = verr.Fields
+		}
+		h.reply(c, resp)
+		return
+	}
+	h.reply(c, wsResponse{ID: req.ID, Type: "ok", Task: task})
+}
+
+// subscribe replaces any previous subscription of the socket. A socket
+// that can't keep up with its events is closed; it reconnects and resumes
+// with last_event_id.
+func (h *WebSocketHandler) subscribe(ctx context.Context, c *wsClient, lastEventID string) error {
+	userID := domain.UserFromContext(ctx)
+	sub, cancel := context.WithCancel(ctx)
+	events, err := h.Broker.Subscribe(sub, userID, lastEventID)
+	if err == domain.ErrEventExpired {
+		h.reply(c, wsResponse{Type: "reset"})
+		events, err = h.Broker.Subscribe(sub, userID, "")
+	}
+	if err != nil {
+		cancel()
+		return err
+	}
+	c.setSubscription(cancel)
+
+	go func() {
+		for e := range events {
+			msg, _ := json.Marshal(wsResponse{Type: "event", Event: e})
+			if !c.tryQueue(msg) {
+				break
+			}
+		}
+		// Closed by the broker or the queue is full, unless the client
+		// unsubscribed on purpose.
+		if sub.Err() == nil {
+			c.close()
+		}
+	}()
+	return nil
+}
+
+func (h *WebSocketHandler) reply(c *wsClient, resp wsResponse) {
+	msg, err := json.Marshal(resp)
+	if err != nil {
+		h.L.Error(err.Error())
+		return
+	}
+	c.queue(msg)
+}
+
+// commandTask is the task sent with a create or update; the use case
+// rejects it when it is missing.
+func commandTask(req *wsRequest) *domain.Task {
+	if req.Task == nil {
+		req.Task = &domain.Task{}
+	}
+	return req.Task
+}
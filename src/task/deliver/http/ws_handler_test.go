@@ -0,0 +1,177 @@
+package http_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/domain/mocks"
+	"github.com/isaias-dgr/todo/src/task/broker"
+	h "github.com/isaias-dgr/todo/src/task/deliver/http"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type wsMessage struct {
+	ID      string              `json:"id"`
+	Type    string              `json:"type"`
+	Task    *domain.Task        `json:"task"`
+	Event   *domain.Event       `json:"event"`
+	Message string              `json:"message"`
+	Errors  map[string][]string `json:"errors"`
+}
+
+type SuiteWebSocket struct {
+	suite.Suite
+	cu     *mocks.TaskUseCase
+	broker domain.Broker
+	hub    *h.Hub
+	server *httptest.Server
+}
+
+func (s *SuiteWebSocket) SetupTest() {
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	s.cu = new(mocks.TaskUseCase)
+	s.broker = broker.NewMemoryBroker(10, 4, logger.Sugar())
+	s.hub = h.NewHub()
+	r := mux.NewRouter()
+	r.Use(h.UserMiddleware)
+	h.NewWebSocketHandler(r, s.cu, s.broker, s.hub, logger.Sugar())
+	s.server = httptest.NewServer(r)
+}
+
+func (s *SuiteWebSocket) TearDownTest() {
+	s.hub.Close()
+	s.server.Close()
+}
+
+func (s *SuiteWebSocket) dial(userID string) *websocket.Conn {
+	url := "ws" + strings.TrimPrefix(s.server.URL, "http") + "/ws"
+	header := http.Header{}
+	header.Set("X-User-ID", userID)
+	conn, _, err := websocket.DefaultDialer.Dial(url, header)
+	s.Require().NoError(err)
+	return conn
+}
+
+func (s *SuiteWebSocket) read(conn *websocket.Conn) wsMessage {
+	var msg wsMessage
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	s.Require().NoError(conn.ReadJSON(&msg))
+	return msg
+}
+
+func (s *SuiteWebSocket) TestCommands() {
+	conn := s.dial("user-1")
+	defer conn.Close()
+
+	s.Run("When a task is created", func() {
+		s.cu.On("Insert", mock.Anything, mock.Anything).Return(nil).Once()
+		conn.WriteJSON(map[string]interface{}{
+			"id": "1", "type": "create",
+			"task": map[string]string{"title": "title", "description": "description"},
+		})
+		msg := s.read(conn)
+		s.Equal("1", msg.ID)
+		s.Equal("ok", msg.Type)
+		s.Equal("title", msg.Task.Title)
+		s.cu.AssertCalled(s.T(), "Insert", mock.MatchedBy(func(ctx context.Context) bool {
+			return domain.UserFromContext(ctx) == "user-1"
+		}), mock.Anything)
+	})
+
+	s.Run("When the task is invalid", func() {
+		s.cu.On("Insert", mock.Anything, mock.Anything).Return(validate).Once()
+		conn.WriteJSON(map[string]interface{}{"id": "2", "type": "create", "task": map[string]string{"title": "title"}})
+		msg := s.read(conn)
+		s.Equal("error", msg.Type)
+		s.Equal("validation_failed", msg.Message)
+		s.Equal(map[string][]string{"description": {"is required"}}, msg.Errors)
+	})
+
+	s.Run("When the use case fails", func() {
+		s.cu.On("Delete", mock.Anything, "404").Return(errors.New("not_found")).Once()
+		conn.WriteJSON(map[string]interface{}{"id": "3", "type": "delete", "task_id": "404"})
+		msg := s.read(conn)
+		s.Equal("3", msg.ID)
+		s.Equal("error", msg.Type)
+		s.Equal("not_found", msg.Message)
+	})
+
+	s.Run("When the message is not json", func() {
+		conn.WriteMessage(websocket.TextMessage, []byte("{"))
+		msg := s.read(conn)
+		s.Equal("error", msg.Type)
+	})
+
+	s.Run("When the type is unknown", func() {
+		conn.WriteJSON(map[string]interface{}{"id": "4", "type": "archive"})
+		msg := s.read(conn)
+		s.Equal("error", msg.Type)
+	})
+}
+
+func (s *SuiteWebSocket) TestSubscribe() {
+	conn := s.dial("user-1")
+	defer conn.Close()
+	conn.WriteJSON(map[string]interface{}{"id": "1", "type": "subscribe"})
+	s.Equal("ok", s.read(conn).Type)
+
+	task := domain.NewTask("title", "description")
+	other, _ := domain.NewEvent(domain.TaskCreated, task)
+	other.UserID = "user-2"
+	mine, _ := domain.NewEvent(domain.TaskUpdated, task)
+	mine.UserID = "user-1"
+	s.broker.Publish(other)
+	s.broker.Publish(mine)
+
+	msg := s.read(conn)
+	s.Equal("event", msg.Type)
+	s.Equal(mine.ID, msg.Event.ID)
+}
+
+func (s *SuiteWebSocket) TestSlowSubscriberIsClosed() {
+	conn := s.dial("user-1")
+	defer conn.Close()
+	conn.WriteJSON(map[string]interface{}{"id": "1", "type": "subscribe"})
+	s.Equal("ok", s.read(conn).Type)
+
+	// Nothing reads the socket, so the broker queue of 4 overflows.
+	task := domain.NewTask("title", "description")
+	for i := 0; i < 200; i++ {
+		e, _ := domain.NewEvent(domain.TaskUpdated, task)
+		e.UserID = "user-1"
+		s.broker.Publish(e)
+	}
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	var err error
+	for err == nil {
+		_, _, err = conn.ReadMessage()
+	}
+	s.True(websocket.IsCloseError(err, websocket.CloseGoingAway), err.Error())
+}
+
+func (s *SuiteWebSocket) TestHubClose() {
+	conn := s.dial("user-1")
+	defer conn.Close()
+	s.Eventually(func() bool { return s.hub.Len() == 1 }, time.Second, 10*time.Millisecond)
+
+	s.hub.Close()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, _, err := conn.ReadMessage()
+	s.True(websocket.IsCloseError(err, websocket.CloseGoingAway))
+	s.Eventually(func() bool { return s.hub.Len() == 0 }, time.Second, 10*time.Millisecond)
+}
+
+func TestSuiteWebSocket(t *testing.T) {
+	suite.Run(t, new(SuiteWebSocket))
+}
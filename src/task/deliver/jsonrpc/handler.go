@@ -0,0 +1,304 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/isaias-dgr/todo/src/domain"
+	"go.uber.org/zap"
+)
+
+// Codes from the JSON-RPC 2.0 spec, plus server errors in the
+// -32000..-32099 range for repository failures.
+const (
+	ParseError     = -32700
+	InvalidRequest = -32600
+	MethodNotFound = -32601
+	InvalidParams  = -32602
+	InternalError  = -32603
+	NotFound       = -32001
+	Conflict       = -32002
+	QuotaExceeded  = -32003
+)
+
+const maxBody = 1 << 20
+
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"`
+	notify  bool
+}
+
+type response struct {
+	Result interface{}
+	Error  *Error
+	ID     json.RawMessage
+}
+
+// MarshalJSON always writes result on success, even when it is null, and
+// never writes both result and error.
+func (r response) MarshalJSON() ([]byte, error) {
+	id := r.ID
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	if r.Error != nil {
+		return json.Marshal(struct {
+			JSONRPC string          `json:"jsonrpc"`
+			Error   *Error          `json:"error"`
+			ID      json.RawMessage `json:"id"`
+		}{"2.0", r.Error, id})
+	}
+	return json.Marshal(struct {
+		JSONRPC string          `json:"jsonrpc"`
+		Result  interface{}     `json:"result"`
+		ID      json.RawMessage `json:"id"`
+	}{"2.0", r.Result, id})
+}
+
+type method func(ctx context.Context, params json.RawMessage) (interface{}, error)
+
+type RpcHandler struct {
+	TuseCase domain.TaskUseCase
+	L        *zap.SugaredLogger
+	methods  map[string]method
+}
+
+func NewRpcHandler(r *mux.Router, taskUseCase domain.TaskUseCase, logger *zap.SugaredLogger) {
+	handler := NewHandler(taskUseCase, logger)
+
+	r.Handle("/rpc", handler).Methods("POST")
+}
+
+func NewHandler(taskUseCase domain.TaskUseCase, logger *zap.SugaredLogger) *RpcHandler {
+	h := &RpcHandler{
+		TuseCase: taskUseCase,
+		L:        logger,
+	}
+	h.methods = map[string]method{
+		"task.fetch":  h.fetch,
+		"task.insert": h.insert,
+		"task.update": h.update,
+		"task.get":    h.get,
+		"task.delete": h.delete,
+	}
+	return h
+}
+
+// ServeHTTP answers a single call or a batch. Batches run in order and
+// notifications get no entry in the reply; a call made only of
+// notifications is answered with 204.
+func (h *RpcHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBody))
+	if err != nil {
+		writeJSON(w, response{Error: &Error{Code: ParseError, Message: "Parse error"}})
+		return
+	}
+	body = bytes.TrimSpace(body)
+
+	if len(body) > 0 && body[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil {
+			writeJSON(w, response{Error: &Error{Code: ParseError, Message: "Parse error"}})
+			return
+		}
+		if len(batch) == 0 {
+			writeJSON(w, response{Error: &Error{Code: InvalidRequest, Message: "Invalid Request"}})
+			return
+		}
+		replies := []response{}
+		for _, raw := range batch {
+			if reply, ok := h.call(r.Context(), raw); ok {
+				replies = append(replies, reply)
+			}
+		}
+		if len(replies) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeJSON(w, replies)
+		return
+	}
+
+	if !json.Valid(body) {
+		writeJSON(w, response{Error: &Error{Code: ParseError, Message: "Parse error"}})
+		return
+	}
+	reply, ok := h.call(r.Context(), body)
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, reply)
+}
+
+// call runs one request; ok is false for notifications.
+func (h *RpcHandler) call(ctx context.Context, raw json.RawMessage) (response, bool) {
+	req, err := parseRequest(raw)
+	if err != nil {
+		return response{Error: &Error{Code: InvalidRequest, Message: "Invalid Request"}}, true
+	}
+	fn, found := h.methods[req.Method]
+	if !found {
+		return response{Error: &Error{Code: MethodNotFound, Message: "Method not found"}, ID: req.ID}, !req.notify
+	}
+	result, err := fn(ctx, req.Params)
+	if err != nil {
+		return response{Error: rpcError(err), ID: req.ID}, !req.notify
+	}
+	return response{Result: result, ID: req.ID}, !req.notify
+}
+
+func parseRequest(raw json.RawMessage) (*request, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &members); err != nil {
+		return nil, err
+	}
+	var req request
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return nil, err
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return nil, errors.New("invalid_request")
+	}
+	id, present := members["id"]
+	req.notify = !present
+	if present {
+		switch {
+		case bytes.Equal(id, []byte("null")), id[0] == '"', id[0] == '-', id[0] >= '0' && id[0] <= '9':
+			req.ID = id
+		default:
+			return nil, errors.New("invalid_request")
+		}
+	}
+	return &req, nil
+}
+
+type fetchParams struct {
+	Offset int    `json:"offset"`
+	Limit  int    `json:"limit"`
+	SortBy string `json:"sort_by"`
+}
+
+type taskParams struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+func (h *RpcHandler) fetch(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	p := fetchParams{Limit: 10}
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	filter := &domain.Filter{Offset: p.Offset, Limit: p.Limit, SortBy: p.SortBy}
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	tasks, err := h.TuseCase.Fetch(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewResponse(tasks.Data, tasks.Total, filter, ""), nil
+}
+
+func (h *RpcHandler) insert(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p taskParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	task := domain.NewTask(p.Title, p.Description)
+	if err := h.TuseCase.Insert(ctx, task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+func (h *RpcHandler) update(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p taskParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	task := domain.NewTask(p.Title, p.Description)
+	if err := h.TuseCase.Update(ctx, p.ID, task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+func (h *RpcHandler) get(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p taskParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	return h.TuseCase.GetByID(ctx, p.ID)
+}
+
+func (h *RpcHandler) delete(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p taskParams
+	if err := decodeParams(params, &p); err != nil {
+		return nil, err
+	}
+	if err := h.TuseCase.Delete(ctx, p.ID); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+var errParams = errors.New("invalid_params")
+
+// decodeParams only accepts named params; all methods take an object.
+func decodeParams(params json.RawMessage, v interface{}) error {
+	if len(params) == 0 {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(params))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return errParams
+	}
+	return nil
+}
+
+func rpcError(err error) *Error {
+	var verr *domain.ValidationError
+	if errors.As(err, &verr) {
+		return &Error{Code: InvalidParams, Message: "Invalid params", Data: verr.Fields}
+	}
+	msg := err.Error()
+	switch {
+	case err == errParams:
+		return &Error{Code: InvalidParams, Message: "Invalid params"}
+	case msg == "uuid_format", strings.HasPrefix(msg, "bad request"):
+		return &Error{Code: InvalidParams, Message: "Invalid params", Data: msg}
+	case msg == "not_found":
+		return &Error{Code: NotFound, Message: "Not found", Data: msg}
+	case strings.HasPrefix(msg, "conflict_"):
+		return &Error{Code: Conflict, Message: "Conflict", Data: msg}
+	case errors.Is(err, domain.ErrQuotaExceeded):
+		return &Error{Code: QuotaExceeded, Message: "Quota exceeded", Data: msg}
+	default:
+		return &Error{Code: InternalError, Message: "Internal error", Data: msg}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	jsonResp, _ := json.Marshal(body)
+	w.Write(jsonResp)
+}
@@ -0,0 +1,145 @@
+package jsonrpc_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/domain/mocks"
+	"github.com/isaias-dgr/todo/src/task/deliver/jsonrpc"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type SuiteRpc struct {
+	suite.Suite
+	cu      *mocks.TaskUseCase
+	handler *jsonrpc.RpcHandler
+}
+
+func (s *SuiteRpc) SetupTest() {
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	s.cu = new(mocks.TaskUseCase)
+	s.handler = jsonrpc.NewHandler(s.cu, logger.Sugar())
+}
+
+func (s *SuiteRpc) post(body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/rpc", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	s.handler.ServeHTTP(w, req)
+	return w
+}
+
+func (s *SuiteRpc) TestCall() {
+	s.Run("When fetching tasks", func() {
+		tasks := domain.NewTasks([]*domain.Task{domain.NewTask("title", "description")}, 1)
+		s.cu.On("Fetch", mock.Anything, &domain.Filter{Offset: 0, Limit: 5}).Return(tasks, nil).Once()
+		w := s.post(`{"jsonrpc": "2.0", "method": "task.fetch", "params": {"limit": 5}, "id": 1}`)
+		s.Equal(http.StatusOK, w.Code)
+		s.JSONEq(`{"jsonrpc": "2.0", "id": 1, "result": {
+			"data": [{"id": "00000000-0000-0000-0000-000000000000", "title": "title", "description": "description"}],
+			"metadata": {"limit": 5, "total": 1}}}`, w.Body.String())
+	})
+
+	s.Run("When inserting a task", func() {
+		s.cu.On("Insert", mock.Anything, mock.Anything).Return(nil).Once()
+		w := s.post(`{"jsonrpc": "2.0", "method": "task.insert", "params": {"title": "t", "description": "d"}, "id": "a"}`)
+		s.Contains(w.Body.String(), `"result":{"id"`)
+		s.Contains(w.Body.String(), `"id":"a"`)
+	})
+
+	s.Run("When deleting a task", func() {
+		s.cu.On("Delete", mock.Anything, "1").Return(nil).Once()
+		w := s.post(`{"jsonrpc": "2.0", "method": "task.delete", "params": {"id": "1"}, "id": 2}`)
+		s.JSONEq(`{"jsonrpc": "2.0", "result": null, "id": 2}`, w.Body.String())
+	})
+}
+
+func (s *SuiteRpc) TestErrors() {
+	s.cu.On("GetByID", mock.Anything, "missing").Return(nil, errors.New("not_found"))
+	s.cu.On("GetByID", mock.Anything, "bad").Return(nil, errors.New("uuid_format"))
+	s.cu.On("Update", mock.Anything, "busy", mock.Anything).Return(errors.New("conflict_update"))
+	s.cu.On("Delete", mock.Anything, "boom").Return(errors.New("query_exec"))
+	s.cu.On("Insert", mock.Anything, mock.Anything).Return(func(_ context.Context, t *domain.Task) error {
+		return t.Validate()
+	})
+
+	cases := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"parse error", `{"jsonrpc": "2.0", "method"`,
+			`{"jsonrpc": "2.0", "error": {"code": -32700, "message": "Parse error"}, "id": null}`},
+		{"invalid request", `{"jsonrpc": "1.0", "method": "task.get", "id": 1}`,
+			`{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request"}, "id": null}`},
+		{"method not found", `{"jsonrpc": "2.0", "method": "task.archive", "id": 1}`,
+			`{"jsonrpc": "2.0", "error": {"code": -32601, "message": "Method not found"}, "id": 1}`},
+		{"invalid params", `{"jsonrpc": "2.0", "method": "task.get", "params": ["x"], "id": 1}`,
+			`{"jsonrpc": "2.0", "error": {"code": -32602, "message": "Invalid params"}, "id": 1}`},
+		{"validation", `{"jsonrpc": "2.0", "method": "task.insert", "params": {"title": "t"}, "id": 1}`,
+			`{"jsonrpc": "2.0", "error": {"code": -32602, "message": "Invalid params", "data": {"description": ["is required"]}}, "id": 1}`},
+		{"negative page", `{"jsonrpc": "2.0", "method": "task.fetch", "params": {"offset": -1}, "id": 1}`,
+			`{"jsonrpc": "2.0", "error": {"code": -32602, "message": "Invalid params", "data": "bad request: offset and limit can not be negative"}, "id": 1}`},
+		{"bad uuid", `{"jsonrpc": "2.0", "method": "task.get", "params": {"id": "bad"}, "id": 1}`,
+			`{"jsonrpc": "2.0", "error": {"code": -32602, "message": "Invalid params", "data": "uuid_format"}, "id": 1}`},
+		{"not found", `{"jsonrpc": "2.0", "method": "task.get", "params": {"id": "missing"}, "id": 1}`,
+			`{"jsonrpc": "2.0", "error": {"code": -32001, "message": "Not found", "data": "not_found"}, "id": 1}`},
+		{"conflict", `{"jsonrpc": "2.0", "method": "task.update", "params": {"id": "busy", "title": "t", "description": "d"}, "id": 1}`,
+			`{"jsonrpc": "2.0", "error": {"code": -32002, "message": "Conflict", "data": "conflict_update"}, "id": 1}`},
+		{"internal", `{"jsonrpc": "2.0", "method": "task.delete", "params": {"id": "boom"}, "id": 1}`,
+			`{"jsonrpc": "2.0", "error": {"code": -32603, "message": "Internal error", "data": "query_exec"}, "id": 1}`},
+	}
+	for _, c := range cases {
+		s.Run(c.name, func() {
+			s.JSONEq(c.want, s.post(c.body).Body.String())
+		})
+	}
+}
+
+func (s *SuiteRpc) TestNotification() {
+	s.cu.On("Delete", mock.Anything, "1").Return(nil).Once()
+	w := s.post(`{"jsonrpc": "2.0", "method": "task.delete", "params": {"id": "1"}}`)
+	s.Equal(http.StatusNoContent, w.Code)
+	s.Empty(w.Body.String())
+	s.cu.AssertCalled(s.T(), "Delete", mock.Anything, "1")
+}
+
+func (s *SuiteRpc) TestBatch() {
+	s.Run("When mixing calls and notifications", func() {
+		s.cu.On("GetByID", mock.Anything, "1").Return(domain.NewTask("title", "description"), nil).Once()
+		s.cu.On("Delete", mock.Anything, "2").Return(nil).Once()
+		w := s.post(`[
+			{"jsonrpc": "2.0", "method": "task.get", "params": {"id": "1"}, "id": 1},
+			{"jsonrpc": "2.0", "method": "task.delete", "params": {"id": "2"}},
+			{"jsonrpc": "2.0", "method": "task.nope", "id": 3},
+			1
+		]`)
+		s.JSONEq(`[
+			{"jsonrpc": "2.0", "result": {"id": "00000000-0000-0000-0000-000000000000", "title": "title", "description": "description"}, "id": 1},
+			{"jsonrpc": "2.0", "error": {"code": -32601, "message": "Method not found"}, "id": 3},
+			{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request"}, "id": null}
+		]`, w.Body.String())
+	})
+
+	s.Run("When the batch is empty", func() {
+		w := s.post(`[]`)
+		s.JSONEq(`{"jsonrpc": "2.0", "error": {"code": -32600, "message": "Invalid Request"}, "id": null}`, w.Body.String())
+	})
+
+	s.Run("When the batch only has notifications", func() {
+		s.cu.On("Delete", mock.Anything, "3").Return(nil).Once()
+		w := s.post(`[{"jsonrpc": "2.0", "method": "task.delete", "params": {"id": "3"}}]`)
+		s.Equal(http.StatusNoContent, w.Code)
+	})
+}
+
+func TestSuiteRpc(t *testing.T) {
+	suite.Run(t, new(SuiteRpc))
+}
@@ -0,0 +1,153 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/isaias-dgr/todo/src/app/router"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/domain/mocks"
+	"github.com/isaias-dgr/todo/src/health"
+	"github.com/isaias-dgr/todo/src/metrics"
+	_TaskHttp "github.com/isaias-dgr/todo/src/task/deliver/http"
+	"github.com/isaias-dgr/todo/src/task/deliver/openapi"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type spec struct {
+	OpenAPI    string                                `json:"openapi"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+type SuiteOpenAPI struct {
+	suite.Suite
+	router *mux.Router
+	spec   spec
+}
+
+// SetupTest mounts the routes main serves.
+func (s *SuiteOpenAPI) SetupTest() {
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	s.router = mux.NewRouter()
+	router.Mount(s.router, logger.Sugar(),
+		new(mocks.TaskUseCase),
+		new(mocks.AttachmentUseCase),
+		domain.NewAttachmentPolicy(1<<20),
+		new(mocks.SyncUseCase),
+		new(mocks.Broker),
+		_TaskHttp.NewHub(),
+		health.NewChecker(time.Second),
+		metrics.New())
+
+	s.Require().NoError(json.Unmarshal(openapi.Spec, &s.spec))
+}
+
+func (s *SuiteOpenAPI) TestRoutesMatchSpec() {
+	routes := []string{}
+	err := s.router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return err
+		}
+		for _, method := range methods {
+			routes = append(routes, method+" "+path)
+		}
+		return nil
+	})
+	s.Require().NoError(err)
+
+	documented := []string{}
+	for path, item := range s.spec.Paths {
+		for method := range item {
+			if method == "parameters" {
+				continue
+			}
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(routes)
+	sort.Strings(documented)
+	s.Equal(routes, documented, "openapi.json and the router are out of sync")
+}
+
+func (s *SuiteOpenAPI) TestSchemasMatchDomain() {
+	cases := map[string]interface{}{
+		"Task":         domain.Task{},
+		"Attachment":   domain.Attachment{},
+		"Metadata":     domain.Metadata{},
+		"Response":     domain.Response{},
+		"Build":        health.Build{},
+		"Change":       domain.Change{},
+		"ChangeSet":    domain.ChangeSet{},
+		"ClientChange": domain.ClientChange{},
+		"SyncPush":     domain.SyncPush{},
+		"SyncResult":   domain.SyncResult{},
+	}
+	for name, value := range cases {
+		s.Run(name, func() {
+			schema, ok := s.spec.Components.Schemas[name]
+			s.Require().True(ok)
+			documented := []string{}
+			for property := range schema.Properties {
+				documented = append(documented, property)
+			}
+			sort.Strings(documented)
+			s.Equal(jsonFields(value), documented)
+		})
+	}
+}
+
+func (s *SuiteOpenAPI) TestServe() {
+	s.Run("Spec", func() {
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+		s.Equal(http.StatusOK, w.Code)
+		s.Equal("application/json", w.Header().Get("Content-Type"))
+		s.Equal(openapi.Spec, w.Body.Bytes())
+		s.Equal("3.0.3", s.spec.OpenAPI)
+	})
+
+	s.Run("Docs", func() {
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+		s.Equal(http.StatusOK, w.Code)
+		s.Contains(w.Header().Get("Content-Type"), "text/html")
+		s.Contains(w.Body.String(), `url: "/openapi.json"`)
+	})
+}
+
+func jsonFields(value interface{}) []string {
+	fields := []string{}
+	t := reflect.TypeOf(value)
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func TestSuiteOpenAPI(t *testing.T) {
+	suite.Run(t, new(SuiteOpenAPI))
+}
@@ -0,0 +1,27 @@
+package publisher
+
+import (
+	"context"
+
+	"github.com/isaias-dgr/todo/src/domain"
+	"go.uber.org/zap"
+)
+
+type logPublisher struct {
+	l *zap.SugaredLogger
+}
+
+func NewLogPublisher(logger *zap.SugaredLogger) domain.Publisher {
+	return &logPublisher{
+		l: logger,
+	}
+}
+
+func (p *logPublisher) Publish(ctx context.Context, e *domain.Event) error {
+	p.l.Infow("Event",
+		"id", e.ID,
+		"type", e.Type,
+		"task_id", e.TaskID,
+		"payload", string(e.Payload))
+	return nil
+}
@@ -0,0 +1,82 @@
+package publisher_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/task/publisher"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type SuitePublisher struct {
+	suite.Suite
+	logger *zap.SugaredLogger
+	event  *domain.Event
+}
+
+func (s *SuitePublisher) SetupTest() {
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	s.logger = logger.Sugar()
+
+	task := domain.NewTask("title", "description")
+	task.ID = uuid.New()
+	s.event, _ = domain.NewEvent(domain.TaskCreated, task)
+}
+
+func (s *SuitePublisher) TestLogPublisher() {
+	p := publisher.NewLogPublisher(s.logger)
+	s.NoError(p.Publish(context.TODO(), s.event))
+}
+
+func (s *SuitePublisher) TestWebhookPublisher() {
+	s.Run("When the webhook accepts the event", func() {
+		var got domain.Event
+		var key, eventType string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key = r.Header.Get("Idempotency-Key")
+			eventType = r.Header.Get("X-Event-Type")
+			json.NewDecoder(r.Body).Decode(&got)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		p := publisher.NewWebhookPublisher(server.URL, nil, s.logger)
+		s.NoError(p.Publish(context.TODO(), s.event))
+		s.Equal(s.event.ID.String(), key)
+		s.Equal(domain.TaskCreated, eventType)
+		s.Equal(s.event.TaskID, got.TaskID)
+	})
+
+	s.Run("When the webhook rejects the event must return error", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		p := publisher.NewWebhookPublisher(server.URL, nil, s.logger)
+		err := p.Publish(context.TODO(), s.event)
+		s.Error(err)
+		s.Equal("webhook_rejected", err.Error())
+	})
+
+	s.Run("When the webhook is unreachable must return error", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		server.Close()
+
+		p := publisher.NewWebhookPublisher(server.URL, nil, s.logger)
+		err := p.Publish(context.TODO(), s.event)
+		s.Error(err)
+		s.Equal("webhook_unreachable", err.Error())
+	})
+}
+
+func TestSuitePublisher(t *testing.T) {
+	suite.Run(t, new(SuitePublisher))
+}
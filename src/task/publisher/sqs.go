@@ -0,0 +1,88 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/isaias-dgr/todo/src/domain"
+	"go.uber.org/zap"
+)
+
+type SQSConfig struct {
+	QueueURL string
+	Region   string
+	// Endpoint overrides the AWS endpoint, e.g. http://elasticmq:9324.
+	Endpoint string
+}
+
+type sqsPublisher struct {
+	client   sqsiface.SQSAPI
+	queueURL string
+	fifo     bool
+	l        *zap.SugaredLogger
+}
+
+func NewSQSPublisher(cfg SQSConfig, logger *zap.SugaredLogger) (domain.Publisher, error) {
+	if cfg.QueueURL == "" {
+		return nil, errors.New("sqs_queue_url")
+	}
+	awsCfg := aws.NewConfig().WithRegion(cfg.Region)
+	if cfg.Endpoint != "" {
+		awsCfg = awsCfg.WithEndpoint(cfg.Endpoint)
+	}
+	sess, err := session.NewSession(awsCfg)
+	if err != nil {
+		logger.Error(err.Error())
+		return nil, errors.New("sqs_session")
+	}
+	return NewSQSPublisherWithClient(sqs.New(sess), cfg.QueueURL, logger), nil
+}
+
+func NewSQSPublisherWithClient(client sqsiface.SQSAPI, queueURL string, logger *zap.SugaredLogger) domain.Publisher {
+	return &sqsPublisher{
+		client:   client,
+		queueURL: queueURL,
+		fifo:     strings.HasSuffix(queueURL, ".fifo"),
+		l:        logger,
+	}
+}
+
+// Publish sends the event as the message body. On FIFO queues the event id
+// is the deduplication id and the task id the group, so events of one task
+// keep their order and redeliveries inside the dedup window are dropped.
+func (p *sqsPublisher) Publish(ctx context.Context, e *domain.Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		p.l.Error(err.Error())
+		return errors.New("event_encode")
+	}
+	input := &sqs.SendMessageInput{
+		QueueUrl:    aws.String(p.queueURL),
+		MessageBody: aws.String(string(body)),
+		MessageAttributes: map[string]*sqs.MessageAttributeValue{
+			"event_type": {
+				DataType:    aws.String("String"),
+				StringValue: aws.String(e.Type),
+			},
+			"idempotency_key": {
+				DataType:    aws.String("String"),
+				StringValue: aws.String(e.ID.String()),
+			},
+		},
+	}
+	if p.fifo {
+		input.MessageGroupId = aws.String(e.TaskID.String())
+		input.MessageDeduplicationId = aws.String(e.ID.String())
+	}
+	if _, err := p.client.SendMessageWithContext(ctx, input); err != nil {
+		p.l.Error(err.Error())
+		return errors.New("sqs_send")
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package publisher_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/google/uuid"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/task/publisher"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type fakeSQS struct {
+	sqsiface.SQSAPI
+	input *sqs.SendMessageInput
+	err   error
+}
+
+func (f *fakeSQS) SendMessageWithContext(ctx aws.Context, in *sqs.SendMessageInput, opts ...request.Option) (*sqs.SendMessageOutput, error) {
+	f.input = in
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &sqs.SendMessageOutput{MessageId: aws.String("1")}, nil
+}
+
+type SuiteSQSPublisher struct {
+	suite.Suite
+	logger *zap.SugaredLogger
+	event  *domain.Event
+}
+
+func (s *SuiteSQSPublisher) SetupTest() {
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	s.logger = logger.Sugar()
+
+	task := domain.NewTask("title", "description")
+	task.ID = uuid.New()
+	s.event, _ = domain.NewEvent(domain.TaskUpdated, task)
+}
+
+func (s *SuiteSQSPublisher) TestPublish() {
+	s.Run("When the queue is standard", func() {
+		client := &fakeSQS{}
+		p := publisher.NewSQSPublisherWithClient(client, "http://localhost:9324/queue/tasks", s.logger)
+		s.NoError(p.Publish(context.TODO(), s.event))
+
+		var body domain.Event
+		s.NoError(json.Unmarshal([]byte(*client.input.MessageBody), &body))
+		s.Equal(s.event.ID, body.ID)
+		s.Equal(domain.TaskUpdated, *client.input.MessageAttributes["event_type"].StringValue)
+		s.Equal(s.event.ID.String(), *client.input.MessageAttributes["idempotency_key"].StringValue)
+		s.Nil(client.input.MessageDeduplicationId)
+		s.Nil(client.input.MessageGroupId)
+	})
+
+	s.Run("When the queue is fifo", func() {
+		client := &fakeSQS{}
+		p := publisher.NewSQSPublisherWithClient(client, "http://localhost:9324/queue/tasks.fifo", s.logger)
+		s.NoError(p.Publish(context.TODO(), s.event))
+		s.Equal(s.event.ID.String(), *client.input.MessageDeduplicationId)
+		s.Equal(s.event.TaskID.String(), *client.input.MessageGroupId)
+	})
+
+	s.Run("When the send fails must return error", func() {
+		client := &fakeSQS{err: errors.New("unavailable")}
+		p := publisher.NewSQSPublisherWithClient(client, "http://localhost:9324/queue/tasks", s.logger)
+		err := p.Publish(context.TODO(), s.event)
+		s.Error(err)
+		s.Equal("sqs_send", err.Error())
+	})
+}
+
+func (s *SuiteSQSPublisher) TestNewSQSPublisherWithoutQueue() {
+	_, err := publisher.NewSQSPublisher(publisher.SQSConfig{Region: "us-east-1"}, s.logger)
+	s.Error(err)
+	s.Equal("sqs_queue_url", err.Error())
+}
+
+// TestElasticMQ runs only when SQS_ENDPOINT points to a local SQS stand-in,
+// e.g. the elasticmq service from docker-compose.
+func (s *SuiteSQSPublisher) TestElasticMQ() {
+	endpoint := os.Getenv("SQS_ENDPOINT")
+	if endpoint == "" {
+		s.T().Skip("SQS_ENDPOINT not set")
+	}
+	sess := session.Must(session.NewSession(aws.NewConfig().
+		WithRegion("elasticmq").
+		WithEndpoint(endpoint).
+		WithCredentials(credentials.NewStaticCredentials("x", "x", ""))))
+	client := sqs.New(sess)
+	queue, err := client.CreateQueue(&sqs.CreateQueueInput{QueueName: aws.String("publisher-test")})
+	s.Require().NoError(err)
+
+	p := publisher.NewSQSPublisherWithClient(client, *queue.QueueUrl, s.logger)
+	s.Require().NoError(p.Publish(context.TODO(), s.event))
+
+	out, err := client.ReceiveMessage(&sqs.ReceiveMessageInput{
+		QueueUrl:        queue.QueueUrl,
+		WaitTimeSeconds: aws.Int64(1),
+	})
+	s.Require().NoError(err)
+	s.Require().Len(out.Messages, 1)
+	var body domain.Event
+	s.NoError(json.Unmarshal([]byte(*out.Messages[0].Body), &body))
+	s.Equal(s.event.ID, body.ID)
+}
+
+func TestSuiteSQSPublisher(t *testing.T) {
+	suite.Run(t, new(SuiteSQSPublisher))
+}
@@ -0,0 +1,60 @@
+package publisher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/isaias-dgr/todo/src/domain"
+	"go.uber.org/zap"
+)
+
+type webhookPublisher struct {
+	url    string
+	client *http.Client
+	l      *zap.SugaredLogger
+}
+
+func NewWebhookPublisher(url string, client *http.Client, logger *zap.SugaredLogger) domain.Publisher {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &webhookPublisher{
+		url:    url,
+		client: client,
+		l:      logger,
+	}
+}
+
+// Publish posts the event as JSON. The event id travels in the
+// Idempotency-Key header so the receiver can drop redeliveries.
+func (p *webhookPublisher) Publish(ctx context.Context, e *domain.Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		p.l.Error(err.Error())
+		return errors.New("event_encode")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		p.l.Error(err.Error())
+		return errors.New("webhook_request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", e.ID.String())
+	req.Header.Set("X-Event-Type", e.Type)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		p.l.Error(err.Error())
+		return errors.New("webhook_unreachable")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		p.l.Errorf("Webhook rejected event %s with status %d", e.ID, resp.StatusCode)
+		return errors.New("webhook_rejected")
+	}
+	return nil
+}
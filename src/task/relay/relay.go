@@ -0,0 +1,111 @@
+package relay
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/isaias-dgr/todo/src/domain"
+	"go.uber.org/zap"
+)
+
+// Relay drains the outbox into a Publisher. An event is only marked as
+// published after the publisher accepted it, so delivery is at least once.
+// An event that fails maxAttempts times is marked dead and skipped, so it
+// can not hold back the ones after it.
+type Relay struct {
+	repo        domain.OutboxRepository
+	pub         domain.Publisher
+	l           *zap.SugaredLogger
+	interval    time.Duration
+	batch       int
+	maxAttempts int
+
+	mu       sync.Mutex
+	running  bool
+	lastTick time.Time
+}
+
+func NewRelay(repo domain.OutboxRepository, pub domain.Publisher, logger *zap.SugaredLogger, interval time.Duration) *Relay {
+	return &Relay{
+		repo:        repo,
+		pub:         pub,
+		l:           logger,
+		interval:    interval,
+		batch:       100,
+		maxAttempts: 10,
+	}
+}
+
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	r.tick(true)
+	defer r.tick(false)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.tick(true)
+			if _, err := r.Drain(ctx); err != nil {
+				r.l.Errorw("Relay", "error", err.Error())
+			}
+		}
+	}
+}
+
+// Check reports whether Run is still looping. A failing publisher is not a
+// failure of the relay; the events wait in the outbox.
+func (r *Relay) Check(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.running {
+		return errors.New("relay_stopped")
+	}
+	// A drain may take a while; only a loop that missed several ticks is
+	// stuck.
+	if time.Since(r.lastTick) > 3*r.interval+10*time.Second {
+		return errors.New("relay_stalled")
+	}
+	return nil
+}
+
+func (r *Relay) tick(running bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.running = running
+	r.lastTick = time.Now()
+}
+
+// Drain publishes pending events in order and stops at the first failure
+// so later events are never delivered ahead of an earlier one, unless the
+// failed event ran out of attempts: it is marked dead and the rest go on.
+func (r *Relay) Drain(ctx context.Context) (int, error) {
+	events, err := r.repo.Pending(ctx, r.batch)
+	if err != nil {
+		return 0, err
+	}
+	published := 0
+	for _, e := range events {
+		if err := r.pub.Publish(ctx, e); err != nil {
+			if e.Attempts+1 >= r.maxAttempts {
+				if markErr := r.repo.MarkDead(ctx, e.ID, err.Error()); markErr != nil {
+					return published, markErr
+				}
+				r.l.Errorw("Event dead", "event", e.ID.String(), "type", e.Type, "error", err.Error())
+				continue
+			}
+			if markErr := r.repo.MarkFailed(ctx, e.ID, err.Error()); markErr != nil {
+				r.l.Error(markErr.Error())
+			}
+			return published, err
+		}
+		if err := r.repo.MarkPublished(ctx, e.ID); err != nil {
+			return published, err
+		}
+		published++
+	}
+	return published, nil
+}
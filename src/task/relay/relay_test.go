@@ -0,0 +1,128 @@
+package relay_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/domain/mocks"
+	"github.com/isaias-dgr/todo/src/task/relay"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type SuiteRelay struct {
+	suite.Suite
+	repo   *mocks.OutboxRepository
+	pub    *mocks.Publisher
+	relay  *relay.Relay
+	events []*domain.Event
+}
+
+func (s *SuiteRelay) SetupTest() {
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	s.repo = new(mocks.OutboxRepository)
+	s.pub = new(mocks.Publisher)
+	s.relay = relay.NewRelay(s.repo, s.pub, logger.Sugar(), time.Millisecond)
+
+	task := domain.NewTask("title", "description")
+	task.ID = uuid.New()
+	created, _ := domain.NewEvent(domain.TaskCreated, task)
+	updated, _ := domain.NewEvent(domain.TaskUpdated, task)
+	s.events = []*domain.Event{created, updated}
+}
+
+func (s *SuiteRelay) TestDrain() {
+	s.repo.On("Pending", mock.Anything, mock.Anything).Return(s.events, nil)
+	s.pub.On("Publish", mock.Anything, mock.Anything).Return(nil)
+	s.repo.On("MarkPublished", mock.Anything, mock.Anything).Return(nil)
+
+	published, err := s.relay.Drain(context.TODO())
+	s.NoError(err)
+	s.Equal(2, published)
+	s.repo.AssertCalled(s.T(), "MarkPublished", mock.Anything, s.events[0].ID)
+	s.repo.AssertCalled(s.T(), "MarkPublished", mock.Anything, s.events[1].ID)
+}
+
+func (s *SuiteRelay) TestDrainStopsOnPublishError() {
+	s.repo.On("Pending", mock.Anything, mock.Anything).Return(s.events, nil)
+	s.pub.On("Publish", mock.Anything, s.events[0]).Return(errors.New("webhook_rejected"))
+	s.repo.On("MarkFailed", mock.Anything, s.events[0].ID, "webhook_rejected").Return(nil)
+
+	published, err := s.relay.Drain(context.TODO())
+	s.Error(err)
+	s.Equal(0, published)
+	s.pub.AssertNumberOfCalls(s.T(), "Publish", 1)
+	s.repo.AssertNotCalled(s.T(), "MarkPublished", mock.Anything, mock.Anything)
+}
+
+func (s *SuiteRelay) TestDrainSkipsDeadEvent() {
+	poison, next := s.events[0], s.events[1]
+	dead := false
+	s.repo.On("Pending", mock.Anything, mock.Anything).Return(func(context.Context, int) []*domain.Event {
+		if dead {
+			return []*domain.Event{next}
+		}
+		return []*domain.Event{poison, next}
+	}, nil)
+	s.pub.On("Publish", mock.Anything, poison).Return(errors.New("payload_rejected"))
+	s.pub.On("Publish", mock.Anything, next).Return(nil)
+	s.repo.On("MarkFailed", mock.Anything, poison.ID, "payload_rejected").
+		Run(func(mock.Arguments) { poison.Attempts++ }).Return(nil)
+	s.repo.On("MarkDead", mock.Anything, poison.ID, "payload_rejected").
+		Run(func(mock.Arguments) { dead = true }).Return(nil)
+	s.repo.On("MarkPublished", mock.Anything, next.ID).Return(nil)
+
+	for i := 0; i < 9; i++ {
+		published, err := s.relay.Drain(context.TODO())
+		s.Error(err)
+		s.Equal(0, published, "the events after the failing one wait")
+	}
+	published, err := s.relay.Drain(context.TODO())
+	s.NoError(err)
+	s.Equal(1, published, "the last attempt marks it dead and the rest go on")
+	published, err = s.relay.Drain(context.TODO())
+	s.NoError(err)
+	s.Equal(1, published)
+
+	s.repo.AssertNumberOfCalls(s.T(), "MarkFailed", 9)
+	s.repo.AssertNumberOfCalls(s.T(), "MarkDead", 1)
+	s.pub.AssertNumberOfCalls(s.T(), "Publish", 12)
+}
+
+func (s *SuiteRelay) TestDrainPendingError() {
+	s.repo.On("Pending", mock.Anything, mock.Anything).Return(nil, errors.New("query_context"))
+
+	published, err := s.relay.Drain(context.TODO())
+	s.Error(err)
+	s.Equal(0, published)
+	s.pub.AssertNotCalled(s.T(), "Publish", mock.Anything, mock.Anything)
+}
+
+func (s *SuiteRelay) TestRunStopsWithContext() {
+	s.repo.On("Pending", mock.Anything, mock.Anything).Return([]*domain.Event{}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.relay.Run(ctx)
+		close(done)
+	}()
+	time.Sleep(5 * time.Millisecond)
+	s.NoError(s.relay.Check(context.TODO()))
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		s.Fail("relay did not stop")
+	}
+	s.EqualError(s.relay.Check(context.TODO()), "relay_stopped")
+}
+
+func TestSuiteRelay(t *testing.T) {
+	suite.Run(t, new(SuiteRelay))
+}
@@ -0,0 +1,62 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/task/repository/cache"
+	"github.com/stretchr/testify/suite"
+)
+
+type SuiteLRU struct {
+	suite.Suite
+	now   time.Time
+	cache domain.Cache
+}
+
+func (s *SuiteLRU) SetupTest() {
+	s.now = time.Date(2021, 10, 18, 12, 0, 0, 0, time.UTC)
+	s.cache = cache.NewLRU(2, func() time.Time { return s.now })
+}
+
+func (s *SuiteLRU) get(key string) string {
+	value, err := s.cache.Get(context.TODO(), key)
+	if err != nil {
+		return err.Error()
+	}
+	return string(value)
+}
+
+func (s *SuiteLRU) TestEvictsTheLeastRecentlyUsed() {
+	ctx := context.TODO()
+	s.NoError(s.cache.Set(ctx, "a", []byte("1"), time.Minute))
+	s.NoError(s.cache.Set(ctx, "b", []byte("2"), time.Minute))
+	s.Equal("1", s.get("a"))
+	s.NoError(s.cache.Set(ctx, "c", []byte("3"), time.Minute))
+	s.Equal("1", s.get("a"))
+	s.Equal("cache_miss", s.get("b"))
+	s.Equal("3", s.get("c"))
+
+	s.NoError(s.cache.Set(ctx, "a", []byte("4"), time.Minute))
+	s.Equal("4", s.get("a"))
+	s.NoError(s.cache.Delete(ctx, "a", "missing"))
+	s.Equal("cache_miss", s.get("a"))
+}
+
+func (s *SuiteLRU) TestExpires() {
+	ctx := context.TODO()
+	s.NoError(s.cache.Set(ctx, "a", []byte("1"), time.Minute))
+	s.NoError(s.cache.Set(ctx, "forever", []byte("2"), 0))
+	s.now = s.now.Add(59 * time.Second)
+	s.Equal("1", s.get("a"))
+	s.now = s.now.Add(time.Second)
+	s.Equal("cache_miss", s.get("a"))
+	s.now = s.now.Add(24 * time.Hour)
+	s.Equal("2", s.get("forever"))
+}
+
+func TestSuiteLRU(t *testing.T) {
+	suite.Run(t, new(SuiteLRU))
+}
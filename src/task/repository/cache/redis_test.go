@@ -0,0 +1,71 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/task/repository/cache"
+	"github.com/stretchr/testify/suite"
+)
+
+type SuiteRedis struct {
+	suite.Suite
+	server *miniredis.Miniredis
+	client *redis.Client
+	cache  domain.Cache
+}
+
+func (s *SuiteRedis) SetupTest() {
+	server, err := miniredis.Run()
+	s.Require().NoError(err)
+	s.server = server
+	s.client = redis.NewClient(&redis.Options{Addr: server.Addr()})
+	s.cache = cache.NewRedis(s.client, "todo:")
+}
+
+func (s *SuiteRedis) TearDownTest() {
+	s.client.Close()
+	s.server.Close()
+}
+
+func (s *SuiteRedis) TestGetSetDelete() {
+	ctx := context.TODO()
+	_, err := s.cache.Get(ctx, "a")
+	s.Equal(domain.ErrCacheMiss, err)
+
+	s.NoError(s.cache.Set(ctx, "a", []byte("1"), time.Minute))
+	s.NoError(s.cache.Set(ctx, "b", []byte("2"), 0))
+	value, err := s.cache.Get(ctx, "a")
+	s.NoError(err)
+	s.Equal([]byte("1"), value)
+	s.True(s.server.Exists("todo:a"))
+	s.Equal(time.Minute, s.server.TTL("todo:a"))
+	s.Equal(time.Duration(0), s.server.TTL("todo:b"))
+
+	s.NoError(s.cache.Delete(ctx, "a", "b"))
+	_, err = s.cache.Get(ctx, "b")
+	s.Equal(domain.ErrCacheMiss, err)
+}
+
+func (s *SuiteRedis) TestExpires() {
+	ctx := context.TODO()
+	s.NoError(s.cache.Set(ctx, "a", []byte("1"), time.Minute))
+	s.server.FastForward(time.Minute)
+	_, err := s.cache.Get(ctx, "a")
+	s.Equal(domain.ErrCacheMiss, err)
+}
+
+func (s *SuiteRedis) TestServerDown() {
+	s.server.Close()
+	_, err := s.cache.Get(context.TODO(), "a")
+	s.Error(err)
+	s.NotEqual(domain.ErrCacheMiss, err)
+}
+
+func TestSuiteRedis(t *testing.T) {
+	suite.Run(t, new(SuiteRedis))
+}
@@ -0,0 +1,176 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/logging"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+// generationKey names the current set of cached pages of Fetch. Writes
+// delete it, which leaves the old pages to expire unread.
+const generationKey = "tasks:generation"
+
+type taskRepository struct {
+	next  domain.TaskRepository
+	cache domain.Cache
+	ttl   time.Duration
+	l     *zap.SugaredLogger
+	group singleflight.Group
+}
+
+type page struct {
+	Data  []*domain.Task `json:"data"`
+	Total int            `json:"total"`
+}
+
+// NewTaskRepository reads GetByID and Fetch through c and drops what a
+// write may have changed. A read that started before a write may still
+// store what it read, which lives until ttl. When c fails, reads and
+// writes go on against next.
+func NewTaskRepository(next domain.TaskRepository, c domain.Cache, ttl time.Duration, logger *zap.SugaredLogger) domain.TaskRepository {
+	return &taskRepository{
+		next:  next,
+		cache: c,
+		ttl:   ttl,
+		l:     logger,
+	}
+}
+
+func (r *taskRepository) Fetch(ctx context.Context, f *domain.Filter) (*domain.Tasks, error) {
+	key := fmt.Sprintf("tasks:%s:%d:%d:%s", r.generation(ctx), f.Offset, f.Limit, f.SortBy)
+	var p page
+	err := r.load(ctx, key, &p, func() (interface{}, error) {
+		tasks, err := r.next.Fetch(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		return page{Data: tasks.Data, Total: tasks.Total}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewTasks(p.Data, p.Total), nil
+}
+
+func (r *taskRepository) GetByID(ctx context.Context, id string) (*domain.Task, error) {
+	raw_uuid, err := uuid.Parse(id)
+	if err != nil {
+		return r.next.GetByID(ctx, id)
+	}
+	task := &domain.Task{}
+	err = r.load(ctx, taskKey(raw_uuid), task, func() (interface{}, error) {
+		return r.next.GetByID(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+func (r *taskRepository) CountByUser(ctx context.Context, userID string) (int, error) {
+	return r.next.CountByUser(ctx, userID)
+}
+
+func (r *taskRepository) Insert(ctx context.Context, t *domain.Task) error {
+	err := r.next.Insert(ctx, t)
+	r.invalidate(ctx)
+	return err
+}
+
+func (r *taskRepository) Update(ctx context.Context, id string, t *domain.Task) error {
+	err := r.next.Update(ctx, id, t)
+	r.invalidate(ctx, id)
+	return err
+}
+
+func (r *taskRepository) Upsert(ctx context.Context, id string, t *domain.Task) (bool, error) {
+	created, err := r.next.Upsert(ctx, id, t)
+	r.invalidate(ctx, id)
+	return created, err
+}
+
+func (r *taskRepository) Delete(ctx context.Context, id string) error {
+	err := r.next.Delete(ctx, id)
+	r.invalidate(ctx, id)
+	return err
+}
+
+func (r *taskRepository) Complete(ctx context.Context, id string) (*domain.Task, bool, error) {
+	t, completed, err := r.next.Complete(ctx, id)
+	r.invalidate(ctx, id)
+	return t, completed, err
+}
+
+// load fills out from the cache or, on a miss, from fetch. Concurrent
+// misses of a key share one fetch, run with the context of the first.
+func (r *taskRepository) load(ctx context.Context, key string, out interface{}, fetch func() (interface{}, error)) error {
+	value, err := r.cache.Get(ctx, key)
+	if err == nil && json.Unmarshal(value, out) == nil {
+		return nil
+	}
+	if err != nil && !errors.Is(err, domain.ErrCacheMiss) {
+		r.log(ctx).Warnw("Cache not read", "key", key, "error", err.Error())
+	}
+
+	shared, err, _ := r.group.Do(key, func() (interface{}, error) {
+		fetched, err := fetch()
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(fetched)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.cache.Set(ctx, key, value, r.ttl); err != nil {
+			r.log(ctx).Warnw("Cache not written", "key", key, "error", err.Error())
+		}
+		return value, nil
+	})
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(shared.([]byte), out)
+}
+
+// generation is random, so a generation that was deleted or evicted is
+// never picked again.
+func (r *taskRepository) generation(ctx context.Context) string {
+	if value, err := r.cache.Get(ctx, generationKey); err == nil {
+		return string(value)
+	}
+	generation := uuid.New().String()
+	if err := r.cache.Set(ctx, generationKey, []byte(generation), 0); err != nil {
+		r.log(ctx).Warnw("Cache not written", "key", generationKey, "error", err.Error())
+	}
+	return generation
+}
+
+// invalidate runs after failed writes too, since a failed commit may have
+// changed the task anyway.
+func (r *taskRepository) invalidate(ctx context.Context, ids ...string) {
+	keys := []string{generationKey}
+	for _, id := range ids {
+		if raw_uuid, err := uuid.Parse(id); err == nil {
+			keys = append(keys, taskKey(raw_uuid))
+		}
+	}
+	if err := r.cache.Delete(ctx, keys...); err != nil {
+		r.log(ctx).Errorw("Cache not invalidated", "keys", keys, "error", err.Error())
+	}
+}
+
+func (r *taskRepository) log(ctx context.Context) *zap.SugaredLogger {
+	return logging.FromContext(ctx, r.l)
+}
+
+func taskKey(id uuid.UUID) string {
+	return "task:" + id.String()
+}
@@ -0,0 +1,146 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/domain/mocks"
+	"github.com/isaias-dgr/todo/src/task/repository/cache"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type SuiteCachedRepository struct {
+	suite.Suite
+	next *mocks.TaskRepository
+	repo domain.TaskRepository
+	task *domain.Task
+}
+
+func (s *SuiteCachedRepository) SetupTest() {
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	s.next = new(mocks.TaskRepository)
+	s.repo = cache.NewTaskRepository(s.next, cache.NewLRU(100, time.Now), time.Minute, logger.Sugar())
+	s.task = domain.NewTask("title", "description")
+	s.task.ID = uuid.New()
+}
+
+func (s *SuiteCachedRepository) TestGetByID() {
+	id := s.task.ID.String()
+	s.next.On("GetByID", mock.Anything, id).Return(s.task, nil)
+	s.next.On("Update", mock.Anything, id, mock.Anything).Return(nil)
+	s.next.On("GetByID", mock.Anything, "missing").Return(nil, errors.New("uuid_format"))
+
+	for i := 0; i < 3; i++ {
+		task, err := s.repo.GetByID(context.TODO(), id)
+		s.NoError(err)
+		s.Equal(s.task, task)
+	}
+	s.next.AssertNumberOfCalls(s.T(), "GetByID", 1)
+
+	s.NoError(s.repo.Update(context.TODO(), id, domain.NewTask("new", "new")))
+	_, err := s.repo.GetByID(context.TODO(), id)
+	s.NoError(err)
+	s.next.AssertNumberOfCalls(s.T(), "GetByID", 2)
+
+	_, err = s.repo.GetByID(context.TODO(), "missing")
+	s.EqualError(err, "uuid_format")
+}
+
+func (s *SuiteCachedRepository) TestErrorsAreNotCached() {
+	id := s.task.ID.String()
+	s.next.On("GetByID", mock.Anything, id).Return(nil, errors.New("not_found")).Once()
+	s.next.On("GetByID", mock.Anything, id).Return(s.task, nil).Once()
+	_, err := s.repo.GetByID(context.TODO(), id)
+	s.EqualError(err, "not_found")
+	task, err := s.repo.GetByID(context.TODO(), id)
+	s.NoError(err)
+	s.Equal(s.task, task)
+}
+
+func (s *SuiteCachedRepository) TestFetch() {
+	filter := &domain.Filter{Offset: 0, Limit: 10}
+	other := &domain.Filter{Offset: 10, Limit: 10}
+	s.next.On("Fetch", mock.Anything, filter).Return(domain.NewTasks([]*domain.Task{s.task}, 1), nil)
+	s.next.On("Fetch", mock.Anything, other).Return(domain.NewTasks([]*domain.Task{}, 1), nil)
+	s.next.On("Insert", mock.Anything, mock.Anything).Return(nil)
+	s.next.On("Delete", mock.Anything, s.task.ID.String()).Return(errors.New("tx_commit"))
+
+	fetch := func(f *domain.Filter) *domain.Tasks {
+		tasks, err := s.repo.Fetch(context.TODO(), f)
+		s.Require().NoError(err)
+		return tasks
+	}
+	s.Equal(1, fetch(filter).Total)
+	s.Equal(s.task, fetch(filter).Data[0])
+	s.Empty(fetch(other).Data)
+	s.next.AssertNumberOfCalls(s.T(), "Fetch", 2)
+
+	s.NoError(s.repo.Insert(context.TODO(), domain.NewTask("new", "new")))
+	fetch(filter)
+	fetch(filter)
+	s.next.AssertNumberOfCalls(s.T(), "Fetch", 3)
+
+	s.Error(s.repo.Delete(context.TODO(), s.task.ID.String()))
+	fetch(filter)
+	s.next.AssertNumberOfCalls(s.T(), "Fetch", 4)
+}
+
+func (s *SuiteCachedRepository) TestConcurrentMissesShareOneRead() {
+	id := s.task.ID.String()
+	release := make(chan struct{})
+	s.next.On("GetByID", mock.Anything, id).
+		Run(func(mock.Arguments) { <-release }).
+		Return(s.task, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			task, err := s.repo.GetByID(context.TODO(), id)
+			s.NoError(err)
+			s.Equal(s.task.Title, task.Title)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	s.next.AssertNumberOfCalls(s.T(), "GetByID", 1)
+}
+
+func (s *SuiteCachedRepository) TestBrokenCacheFallsBackToNext() {
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	broken := new(mocks.Cache)
+	broken.On("Get", mock.Anything, mock.Anything).Return(nil, errors.New("connection refused"))
+	broken.On("Set", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("connection refused"))
+	broken.On("Delete", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("connection refused"))
+	repo := cache.NewTaskRepository(s.next, broken, time.Minute, logger.Sugar())
+	id := s.task.ID.String()
+	s.next.On("GetByID", mock.Anything, id).Return(s.task, nil)
+	s.next.On("Upsert", mock.Anything, id, mock.Anything).Return(true, nil)
+	s.next.On("CountByUser", mock.Anything, "user-1").Return(3, nil)
+
+	task, err := repo.GetByID(context.TODO(), id)
+	s.NoError(err)
+	s.Equal(s.task, task)
+	created, err := repo.Upsert(context.TODO(), id, domain.NewTask("new", "new"))
+	s.NoError(err)
+	s.True(created)
+	total, err := repo.CountByUser(context.TODO(), "user-1")
+	s.NoError(err)
+	s.Equal(3, total)
+	broken.AssertCalled(s.T(), "Delete", mock.Anything, "tasks:generation", "task:"+id)
+}
+
+func TestSuiteCachedRepository(t *testing.T) {
+	suite.Run(t, new(SuiteCachedRepository))
+}
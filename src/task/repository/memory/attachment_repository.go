@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/isaias-dgr/todo/src/domain"
+)
+
+type attachmentRepository struct {
+	db *DB
+}
+
+func NewAttachmentRepository(db *DB) domain.AttachmentRepository {
+	return &attachmentRepository{db: db}
+}
+
+func (m *attachmentRepository) Fetch(ctx context.Context, taskID string) ([]*domain.Attachment, error) {
+	raw_task, err := parseUUID(taskID)
+	if err != nil {
+		return nil, err
+	}
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+	attachments := []*domain.Attachment{}
+	for _, a := range m.db.attachments {
+		if a.TaskID == raw_task {
+			attachments = append(attachments, copyAttachment(a))
+		}
+	}
+	sort.SliceStable(attachments, func(i, j int) bool {
+		return attachments[i].CreatedAt.Before(*attachments[j].CreatedAt)
+	})
+	return attachments, nil
+}
+
+func (m *attachmentRepository) GetByID(ctx context.Context, taskID string, id string) (*domain.Attachment, error) {
+	raw_task, err := parseUUID(taskID)
+	if err != nil {
+		return nil, err
+	}
+	raw_uuid, err := parseUUID(id)
+	if err != nil {
+		return nil, err
+	}
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+	for _, a := range m.db.attachments {
+		if a.ID == raw_uuid && a.TaskID == raw_task {
+			return copyAttachment(a), nil
+		}
+	}
+	return nil, errors.New("not_found")
+}
+
+// Insert fails like the primary key of MySQL would when the id is taken. It
+// runs check under the lock, so a Release can not drop the blob meanwhile.
+func (m *attachmentRepository) Insert(ctx context.Context, a *domain.Attachment, check func(context.Context) error) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+	for _, stored := range m.db.attachments {
+		if stored.ID == a.ID {
+			return errors.New("query_exec")
+		}
+	}
+	if err := check(ctx); err != nil {
+		return err
+	}
+	created_at := m.db.now()
+	a.CreatedAt = &created_at
+	stored := copyAttachment(a)
+	stored.URL = ""
+	m.db.attachments = append(m.db.attachments, stored)
+	return nil
+}
+
+func (m *attachmentRepository) Delete(ctx context.Context, id string) error {
+	raw_uuid, err := parseUUID(id)
+	if err != nil {
+		return err
+	}
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+	for i, a := range m.db.attachments {
+		if a.ID == raw_uuid {
+			m.db.attachments = append(m.db.attachments[:i], m.db.attachments[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("conflict_delete")
+}
+
+func (m *attachmentRepository) Release(ctx context.Context, key string, drop func(context.Context) error) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+	for _, a := range m.db.attachments {
+		if a.Key == key {
+			return nil
+		}
+	}
+	return drop(ctx)
+}
+
+func copyAttachment(a *domain.Attachment) *domain.Attachment {
+	c := *a
+	c.CreatedAt = timeRef(a.CreatedAt)
+	return &c
+}
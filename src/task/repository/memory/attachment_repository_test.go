@@ -0,0 +1,93 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/task/repository/memory"
+	"github.com/stretchr/testify/suite"
+)
+
+type SuiteAttachmentRepository struct {
+	suite.Suite
+	now  time.Time
+	repo domain.AttachmentRepository
+	task uuid.UUID
+}
+
+func (s *SuiteAttachmentRepository) SetupTest() {
+	s.now = time.Date(2021, 10, 18, 12, 0, 0, 0, time.UTC)
+	s.repo = memory.NewAttachmentRepository(memory.NewDB(func() time.Time { return s.now }))
+	s.task = uuid.New()
+}
+
+func (s *SuiteAttachmentRepository) found(context.Context) error { return nil }
+
+func (s *SuiteAttachmentRepository) insert(name string, key string) *domain.Attachment {
+	a := &domain.Attachment{ID: uuid.New(), TaskID: s.task, Name: name, Key: key, URL: "/files/" + key}
+	s.Require().NoError(s.repo.Insert(context.TODO(), a, s.found))
+	s.now = s.now.Add(time.Second)
+	return a
+}
+
+func (s *SuiteAttachmentRepository) TestFetchByTask() {
+	ctx := context.TODO()
+	first := s.insert("a.txt", "k1")
+	s.insert("b.txt", "k2")
+	s.Require().NoError(s.repo.Insert(ctx, &domain.Attachment{ID: uuid.New(), TaskID: uuid.New(), Key: "k3"}, s.found))
+
+	attachments, err := s.repo.Fetch(ctx, s.task.String())
+	s.NoError(err)
+	s.Require().Len(attachments, 2)
+	s.Equal("a.txt", attachments[0].Name)
+	s.Equal("b.txt", attachments[1].Name)
+	s.Empty(attachments[0].URL, "the url is not stored")
+
+	got, err := s.repo.GetByID(ctx, s.task.String(), first.ID.String())
+	s.NoError(err)
+	s.Equal(first.Key, got.Key)
+	_, err = s.repo.GetByID(ctx, uuid.New().String(), first.ID.String())
+	s.EqualError(err, "not_found")
+	_, err = s.repo.Fetch(ctx, "000-0000")
+	s.EqualError(err, "uuid_format")
+}
+
+func (s *SuiteAttachmentRepository) TestInsertTakenID() {
+	a := s.insert("a.txt", "k1")
+	s.EqualError(s.repo.Insert(context.TODO(), a, s.found), "query_exec")
+}
+
+func (s *SuiteAttachmentRepository) TestInsertFailedCheck() {
+	a := &domain.Attachment{ID: uuid.New(), TaskID: s.task, Key: "k1"}
+	err := s.repo.Insert(context.TODO(), a, func(context.Context) error { return domain.ErrAttachmentConflict })
+	s.Equal(domain.ErrAttachmentConflict, err)
+	attachments, _ := s.repo.Fetch(context.TODO(), s.task.String())
+	s.Empty(attachments, "nothing is saved")
+}
+
+func (s *SuiteAttachmentRepository) TestDeleteAndRelease() {
+	ctx := context.TODO()
+	a := s.insert("a.txt", "shared")
+	b := s.insert("b.txt", "shared")
+	dropped := 0
+	drop := func(context.Context) error {
+		dropped++
+		return nil
+	}
+
+	s.NoError(s.repo.Delete(ctx, a.ID.String()))
+	s.NoError(s.repo.Release(ctx, "shared", drop))
+	s.Equal(0, dropped, "b still points at the blob")
+	s.EqualError(s.repo.Delete(ctx, a.ID.String()), "conflict_delete")
+
+	s.NoError(s.repo.Delete(ctx, b.ID.String()))
+	s.NoError(s.repo.Release(ctx, "shared", drop))
+	s.Equal(1, dropped)
+}
+
+func TestSuiteAttachmentRepository(t *testing.T) {
+	suite.Run(t, new(SuiteAttachmentRepository))
+}
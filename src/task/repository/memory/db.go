@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/isaias-dgr/todo/src/domain"
+)
+
+// DB holds in this process what MySQL holds otherwise: the tasks, their
+// attachments, the outbox and the idempotency keys. Its repositories
+// answer like the ones of the mysql package, error values included, so the
+// service can run without a database and tests can use them as fakes.
+type DB struct {
+	mu          sync.RWMutex
+	now         func() time.Time
+	inserted    int64
+	tasks       map[uuid.UUID]*taskRow
+	outbox      []*outboxRow
+	attachments []*domain.Attachment
+	keys        map[string]*keyRow
+}
+
+type taskRow struct {
+	task   domain.Task
+	userID string
+	// order breaks the ties of created_at by insertion, as the primary
+	// key does in practice for MySQL.
+	order int64
+}
+
+type outboxRow struct {
+	seq       int64
+	event     domain.Event
+	published bool
+	dead      bool
+	attempts  int
+	lastError string
+}
+
+type keyRow struct {
+	record    domain.IdempotencyRecord
+	token     string
+	expiresAt time.Time
+}
+
+func NewDB(now func() time.Time) *DB {
+	return &DB{
+		now:   now,
+		tasks: map[uuid.UUID]*taskRow{},
+		keys:  map[string]*keyRow{},
+	}
+}
+
+// saveEvent appends to the outbox; the caller holds the write lock, so the
+// change and its event are seen together.
+func (db *DB) saveEvent(ctx context.Context, eventType string, ta *domain.Task) error {
+	event, err := domain.NewEvent(eventType, ta)
+	if err != nil {
+		return errors.New("event_encode")
+	}
+	event.UserID = domain.UserFromContext(ctx)
+	db.outbox = append(db.outbox, &outboxRow{
+		seq:   int64(len(db.outbox)) + 1,
+		event: *event,
+	})
+	return nil
+}
+
+func parseUUID(id string) (uuid.UUID, error) {
+	raw_uuid, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, errors.New("uuid_format")
+	}
+	return raw_uuid, nil
+}
+
+// timeRef copies t, so callers can not change what is stored.
+func timeRef(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/isaias-dgr/todo/src/domain"
+)
+
+type idempotencyRepository struct {
+	db *DB
+}
+
+func NewIdempotencyRepository(db *DB) domain.IdempotencyStore {
+	return &idempotencyRepository{db: db}
+}
+
+func (m *idempotencyRepository) Reserve(ctx context.Context, key string, token string, requestHash string, lease time.Duration) (*domain.IdempotencyRecord, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+	now := m.db.now()
+	if row, ok := m.db.keys[key]; ok && row.expiresAt.After(now) {
+		return row.copy(), nil
+	}
+	m.db.keys[key] = &keyRow{
+		record:    domain.IdempotencyRecord{RequestHash: requestHash},
+		token:     token,
+		expiresAt: now.Add(lease),
+	}
+	return nil, nil
+}
+
+func (m *idempotencyRepository) Complete(ctx context.Context, key string, token string, record *domain.IdempotencyRecord, ttl time.Duration) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+	row, ok := m.db.keys[key]
+	if !ok || !row.held(token) {
+		return domain.ErrLeaseLost
+	}
+	row.expiresAt = m.db.now().Add(ttl)
+	row.record.StatusCode = record.StatusCode
+	row.record.ContentType = record.ContentType
+	row.record.Body = append([]byte(nil), record.Body...)
+	return nil
+}
+
+func (m *idempotencyRepository) Release(ctx context.Context, key string, token string) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+	if row, ok := m.db.keys[key]; ok && row.held(token) {
+		delete(m.db.keys, key)
+	}
+	return nil
+}
+
+func (m *idempotencyRepository) Purge(ctx context.Context) (int64, error) {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+	now := m.db.now()
+	var purged int64
+	for key, row := range m.db.keys {
+		if row.expiresAt.Before(now) {
+			delete(m.db.keys, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// held tells whether the row is still pending with the token it was
+// reserved with.
+func (r *keyRow) held(token string) bool {
+	return r.token == token && r.record.Pending()
+}
+
+func (r *keyRow) copy() *domain.IdempotencyRecord {
+	record := r.record
+	record.Body = append([]byte(nil), r.record.Body...)
+	return &record
+}
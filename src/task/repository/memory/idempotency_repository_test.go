@@ -0,0 +1,91 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/task/repository/memory"
+	"github.com/stretchr/testify/suite"
+)
+
+type SuiteIdempotencyRepository struct {
+	suite.Suite
+	now   time.Time
+	store domain.IdempotencyStore
+}
+
+func (s *SuiteIdempotencyRepository) SetupTest() {
+	s.now = time.Date(2021, 10, 18, 12, 0, 0, 0, time.UTC)
+	s.store = memory.NewIdempotencyRepository(memory.NewDB(func() time.Time { return s.now }))
+}
+
+func (s *SuiteIdempotencyRepository) TestReserveCompleteAndExpire() {
+	ctx := context.TODO()
+	record, err := s.store.Reserve(ctx, "key", "t1", "hash", time.Minute)
+	s.NoError(err)
+	s.Nil(record, "a new key is free")
+
+	record, err = s.store.Reserve(ctx, "key", "t1", "other", time.Minute)
+	s.NoError(err)
+	s.Require().NotNil(record)
+	s.True(record.Pending())
+	s.Equal("hash", record.RequestHash)
+
+	s.NoError(s.store.Complete(ctx, "key", "t1", &domain.IdempotencyRecord{StatusCode: 201, ContentType: "application/json", Body: []byte(`{}`)}, time.Hour))
+	s.now = s.now.Add(30 * time.Minute)
+	record, _ = s.store.Reserve(ctx, "key", "t1", "hash", time.Minute)
+	s.Require().NotNil(record, "the answer outlives the lease")
+	s.Equal(201, record.StatusCode)
+	s.Equal([]byte(`{}`), record.Body)
+
+	s.now = s.now.Add(2 * time.Hour)
+	purged, err := s.store.Purge(ctx)
+	s.NoError(err)
+	s.Equal(int64(1), purged)
+	record, _ = s.store.Reserve(ctx, "key", "t1", "hash", time.Hour)
+	s.Nil(record, "an expired key is free again")
+}
+
+func (s *SuiteIdempotencyRepository) TestLeaseExpires() {
+	ctx := context.TODO()
+	_, err := s.store.Reserve(ctx, "key", "t1", "hash", time.Minute)
+	s.NoError(err)
+	s.now = s.now.Add(2 * time.Minute)
+	record, err := s.store.Reserve(ctx, "key", "t1", "hash", time.Minute)
+	s.NoError(err)
+	s.Nil(record, "a pending key is free once its lease is over")
+}
+
+func (s *SuiteIdempotencyRepository) TestRelease() {
+	ctx := context.TODO()
+	_, err := s.store.Reserve(ctx, "key", "t1", "hash", time.Hour)
+	s.NoError(err)
+	s.NoError(s.store.Release(ctx, "key", "t1"))
+	record, _ := s.store.Reserve(ctx, "key", "t1", "hash", time.Hour)
+	s.Nil(record)
+}
+
+func (s *SuiteIdempotencyRepository) TestAnExpiredLeaseIsNotTouched() {
+	ctx := context.TODO()
+	_, err := s.store.Reserve(ctx, "key", "t1", "hash", time.Minute)
+	s.NoError(err)
+	s.now = s.now.Add(2 * time.Minute)
+	_, err = s.store.Reserve(ctx, "key", "t2", "hash", time.Minute)
+	s.NoError(err)
+
+	s.NoError(s.store.Release(ctx, "key", "t1"))
+	s.Equal(domain.ErrLeaseLost, s.store.Complete(ctx, "key", "t1", &domain.IdempotencyRecord{StatusCode: 201}, time.Hour))
+	record, _ := s.store.Reserve(ctx, "key", "t3", "hash", time.Minute)
+	s.Require().NotNil(record, "the second request still holds the key")
+	s.True(record.Pending())
+
+	s.NoError(s.store.Complete(ctx, "key", "t2", &domain.IdempotencyRecord{StatusCode: 201}, time.Hour))
+	s.Equal(domain.ErrLeaseLost, s.store.Complete(ctx, "key", "t2", &domain.IdempotencyRecord{StatusCode: 201}, time.Hour),
+		"an answer is stored once")
+}
+
+func TestSuiteIdempotencyRepository(t *testing.T) {
+	suite.Run(t, new(SuiteIdempotencyRepository))
+}
@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/isaias-dgr/todo/src/domain"
+)
+
+type outboxRepository struct {
+	db *DB
+}
+
+// NewOutboxRepository hands the events of db to the relay. Published
+// events are kept, since sync reads them too.
+func NewOutboxRepository(db *DB) domain.OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+func (m *outboxRepository) Pending(ctx context.Context, limit int) ([]*domain.Event, error) {
+	if limit < 0 {
+		return nil, errors.New("query_context")
+	}
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+	events := []*domain.Event{}
+	for _, row := range m.db.outbox {
+		if len(events) == limit {
+			break
+		}
+		if row.published || row.dead {
+			continue
+		}
+		event := row.event
+		event.Attempts = row.attempts
+		event.Payload = append([]byte(nil), row.event.Payload...)
+		event.CreatedAt = timeRef(row.event.CreatedAt)
+		events = append(events, &event)
+	}
+	return events, nil
+}
+
+func (m *outboxRepository) MarkPublished(ctx context.Context, id uuid.UUID) error {
+	return m.update(id, func(row *outboxRow) {
+		row.published = true
+	})
+}
+
+func (m *outboxRepository) MarkFailed(ctx context.Context, id uuid.UUID, reason string) error {
+	if len(reason) > 255 {
+		reason = reason[:255]
+	}
+	return m.update(id, func(row *outboxRow) {
+		row.attempts++
+		row.lastError = reason
+	})
+}
+
+func (m *outboxRepository) MarkDead(ctx context.Context, id uuid.UUID, reason string) error {
+	if len(reason) > 255 {
+		reason = reason[:255]
+	}
+	return m.update(id, func(row *outboxRow) {
+		row.attempts++
+		row.lastError = reason
+		row.dead = true
+	})
+}
+
+func (m *outboxRepository) update(id uuid.UUID, fn func(row *outboxRow)) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+	for _, row := range m.db.outbox {
+		if row.event.ID == id {
+			fn(row)
+			return nil
+		}
+	}
+	return errors.New("not_found")
+}
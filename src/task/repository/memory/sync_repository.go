@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/isaias-dgr/todo/src/domain"
+)
+
+type syncRepository struct {
+	db *DB
+}
+
+// NewSyncRepository reads the changes from the outbox of db, whose
+// position is the change sequence. A change and its position are stored
+// under the same lock, so positions become visible in order.
+func NewSyncRepository(db *DB) domain.SyncRepository {
+	return &syncRepository{db: db}
+}
+
+func (m *syncRepository) Changes(ctx context.Context, userID string, since int64, limit int) ([]*domain.Change, error) {
+	if limit < 0 {
+		return nil, errors.New("query_context")
+	}
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+	changes := []*domain.Change{}
+	for _, row := range m.db.outbox {
+		if len(changes) == limit {
+			break
+		}
+		if row.seq <= since || row.event.UserID != userID {
+			continue
+		}
+		change, err := row.change()
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+func (m *syncRepository) LastChange(ctx context.Context, taskID uuid.UUID) (*domain.Change, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+	for i := len(m.db.outbox) - 1; i >= 0; i-- {
+		if row := m.db.outbox[i]; row.event.TaskID == taskID {
+			return row.change()
+		}
+	}
+	return nil, nil
+}
+
+func (r *outboxRow) change() (*domain.Change, error) {
+	change := &domain.Change{
+		Seq:       r.seq,
+		Op:        domain.SyncDelete,
+		TaskID:    r.event.TaskID,
+		ChangedAt: timeRef(r.event.CreatedAt),
+	}
+	if r.event.Type != domain.TaskDeleted {
+		change.Op = domain.SyncUpsert
+		change.Task = &domain.Task{}
+		if err := json.Unmarshal(r.event.Payload, change.Task); err != nil {
+			return nil, errors.New("row_data_types")
+		}
+	}
+	return change, nil
+}
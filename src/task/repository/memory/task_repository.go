@@ -0,0 +1,185 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/isaias-dgr/todo/src/domain"
+)
+
+type taskRepository struct {
+	db *DB
+}
+
+func NewTaskRepository(db *DB) domain.TaskRepository {
+	return &taskRepository{db: db}
+}
+
+// Fetch pages through the tasks by created_at, oldest first. Like MySQL
+// it ignores the sort of the filter and fails on a negative limit or
+// offset.
+func (m *taskRepository) Fetch(ctx context.Context, f *domain.Filter) (*domain.Tasks, error) {
+	if f.Limit < 0 || f.Offset < 0 {
+		return nil, errors.New("query_context")
+	}
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+
+	rows := make([]*taskRow, 0, len(m.db.tasks))
+	for _, row := range m.db.tasks {
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i].task.CreatedAt, rows[j].task.CreatedAt
+		if !a.Equal(*b) {
+			return a.Before(*b)
+		}
+		return rows[i].order < rows[j].order
+	})
+
+	tasks := []*domain.Task{}
+	for i := f.Offset; i < len(rows) && len(tasks) < f.Limit; i++ {
+		tasks = append(tasks, rows[i].copy())
+	}
+	return domain.NewTasks(tasks, len(rows)), nil
+}
+
+func (m *taskRepository) CountByUser(ctx context.Context, userID string) (int, error) {
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+	total := 0
+	for _, row := range m.db.tasks {
+		if row.userID == userID {
+			total++
+		}
+	}
+	return total, nil
+}
+
+func (m *taskRepository) GetByID(ctx context.Context, id string) (*domain.Task, error) {
+	raw_uuid, err := parseUUID(id)
+	if err != nil {
+		return nil, err
+	}
+	m.db.mu.RLock()
+	defer m.db.mu.RUnlock()
+	row, ok := m.db.tasks[raw_uuid]
+	if !ok {
+		return nil, errors.New("not_found")
+	}
+	return row.copy(), nil
+}
+
+func (m *taskRepository) Insert(ctx context.Context, ta *domain.Task) error {
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+	created_at := m.db.now()
+	ta.ID = uuid.New()
+	ta.CreatedAt = &created_at
+	ta.UpdatedAt = ta.CreatedAt
+	m.db.store(ta, domain.UserFromContext(ctx))
+	return m.db.saveEvent(ctx, domain.TaskCreated, ta)
+}
+
+func (m *taskRepository) Update(ctx context.Context, id string, ta *domain.Task) error {
+	raw_uuid, err := parseUUID(id)
+	if err != nil {
+		return err
+	}
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+	updated_at := m.db.now()
+	ta.ID = raw_uuid
+	ta.UpdatedAt = &updated_at
+
+	row, ok := m.db.tasks[raw_uuid]
+	if !ok {
+		return errors.New("conflict_update")
+	}
+	row.task.Title = ta.Title
+	row.task.Description = ta.Description
+	row.task.UpdatedAt = timeRef(ta.UpdatedAt)
+	return m.db.saveEvent(ctx, domain.TaskUpdated, ta)
+}
+
+// Upsert keeps the owner, created_at and completed_at of a task that
+// already exists, and reports them on ta.
+func (m *taskRepository) Upsert(ctx context.Context, id string, ta *domain.Task) (bool, error) {
+	raw_uuid, err := parseUUID(id)
+	if err != nil {
+		return false, err
+	}
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+	now := m.db.now()
+	ta.ID = raw_uuid
+	ta.UpdatedAt = &now
+
+	if row, ok := m.db.tasks[raw_uuid]; ok {
+		row.task.Title = ta.Title
+		row.task.Description = ta.Description
+		row.task.UpdatedAt = timeRef(ta.UpdatedAt)
+		ta.CreatedAt = timeRef(row.task.CreatedAt)
+		ta.CompletedAt = timeRef(row.task.CompletedAt)
+		return false, m.db.saveEvent(ctx, domain.TaskUpdated, ta)
+	}
+	ta.CreatedAt = &now
+	ta.CompletedAt = nil
+	m.db.store(ta, domain.UserFromContext(ctx))
+	return true, m.db.saveEvent(ctx, domain.TaskCreated, ta)
+}
+
+func (m *taskRepository) Delete(ctx context.Context, id string) error {
+	raw_uuid, err := parseUUID(id)
+	if err != nil {
+		return err
+	}
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+	if _, ok := m.db.tasks[raw_uuid]; !ok {
+		return errors.New("conflict_delete")
+	}
+	delete(m.db.tasks, raw_uuid)
+	return m.db.saveEvent(ctx, domain.TaskDeleted, &domain.Task{ID: raw_uuid})
+}
+
+func (m *taskRepository) Complete(ctx context.Context, id string) (*domain.Task, bool, error) {
+	raw_uuid, err := parseUUID(id)
+	if err != nil {
+		return nil, false, err
+	}
+	m.db.mu.Lock()
+	defer m.db.mu.Unlock()
+	row, ok := m.db.tasks[raw_uuid]
+	if !ok {
+		return nil, false, errors.New("not_found")
+	}
+	if row.task.CompletedAt != nil {
+		return row.copy(), false, nil
+	}
+	now := m.db.now()
+	row.task.CompletedAt = &now
+	row.task.UpdatedAt = timeRef(&now)
+	t := row.copy()
+	return t, true, m.db.saveEvent(ctx, domain.TaskCompleted, t)
+}
+
+// store saves a copy of ta; the caller holds the write lock.
+func (db *DB) store(ta *domain.Task, userID string) {
+	db.inserted++
+	row := &taskRow{task: *ta, userID: userID, order: db.inserted}
+	row.task.CreatedAt = timeRef(ta.CreatedAt)
+	row.task.UpdatedAt = timeRef(ta.UpdatedAt)
+	row.task.CompletedAt = timeRef(ta.CompletedAt)
+	db.tasks[ta.ID] = row
+}
+
+func (r *taskRow) copy() *domain.Task {
+	t := r.task
+	t.CreatedAt = timeRef(r.task.CreatedAt)
+	t.UpdatedAt = timeRef(r.task.UpdatedAt)
+	t.CompletedAt = timeRef(r.task.CompletedAt)
+	return &t
+}
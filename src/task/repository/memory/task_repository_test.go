@@ -0,0 +1,264 @@
+package memory_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/task/repository/memory"
+	"github.com/stretchr/testify/suite"
+)
+
+type SuiteTaskRepository struct {
+	suite.Suite
+	now  time.Time
+	db   *memory.DB
+	repo domain.TaskRepository
+}
+
+func (s *SuiteTaskRepository) SetupTest() {
+	s.now = time.Date(2021, 10, 18, 12, 0, 0, 0, time.UTC)
+	s.db = memory.NewDB(func() time.Time { return s.now })
+	s.repo = memory.NewTaskRepository(s.db)
+}
+
+func (s *SuiteTaskRepository) insert(title string) *domain.Task {
+	t := domain.NewTask(title, "description")
+	s.Require().NoError(s.repo.Insert(context.TODO(), t))
+	s.now = s.now.Add(time.Second)
+	return t
+}
+
+func (s *SuiteTaskRepository) titles(ts *domain.Tasks) []string {
+	titles := []string{}
+	for _, t := range ts.Data {
+		titles = append(titles, t.Title)
+	}
+	return titles
+}
+
+func (s *SuiteTaskRepository) TestFetch() {
+	ctx := context.TODO()
+	s.Run("When it is empty", func() {
+		ts, err := s.repo.Fetch(ctx, &domain.Filter{Limit: 10})
+		s.NoError(err)
+		s.Equal(0, ts.Total)
+		s.Equal([]*domain.Task{}, ts.Data)
+	})
+
+	s.insert("a")
+	s.insert("b")
+	s.insert("c")
+	s.Run("Pages by created_at", func() {
+		ts, err := s.repo.Fetch(ctx, &domain.Filter{Offset: 1, Limit: 10})
+		s.NoError(err)
+		s.Equal(3, ts.Total)
+		s.Equal([]string{"b", "c"}, s.titles(ts))
+
+		ts, err = s.repo.Fetch(ctx, &domain.Filter{Limit: 2, SortBy: "title"})
+		s.NoError(err)
+		s.Equal([]string{"a", "b"}, s.titles(ts))
+
+		ts, err = s.repo.Fetch(ctx, &domain.Filter{Offset: 5, Limit: 2})
+		s.NoError(err)
+		s.Equal(3, ts.Total)
+		s.Empty(ts.Data)
+	})
+
+	s.Run("When the page is malformed", func() {
+		_, err := s.repo.Fetch(ctx, &domain.Filter{Limit: -1})
+		s.EqualError(err, "query_context")
+		_, err = s.repo.Fetch(ctx, &domain.Filter{Offset: -1, Limit: 1})
+		s.EqualError(err, "query_context")
+	})
+}
+
+func (s *SuiteTaskRepository) TestGetByID() {
+	ctx := context.TODO()
+	t := s.insert("a")
+
+	got, err := s.repo.GetByID(ctx, t.ID.String())
+	s.NoError(err)
+	s.Equal(t, got)
+
+	got.Title = "changed"
+	*got.CreatedAt = got.CreatedAt.Add(time.Hour)
+	again, _ := s.repo.GetByID(ctx, t.ID.String())
+	s.Equal("a", again.Title, "the stored task is a copy")
+	s.Equal(*t.CreatedAt, *again.CreatedAt)
+
+	_, err = s.repo.GetByID(ctx, "000-0000")
+	s.EqualError(err, "uuid_format")
+	_, err = s.repo.GetByID(ctx, uuid.New().String())
+	s.EqualError(err, "not_found")
+}
+
+func (s *SuiteTaskRepository) TestUpdate() {
+	ctx := context.TODO()
+	t := s.insert("a")
+	created := *t.CreatedAt
+
+	changed := domain.NewTask("b", "other")
+	s.NoError(s.repo.Update(ctx, t.ID.String(), changed))
+	s.Equal(t.ID, changed.ID)
+	s.Equal(s.now, *changed.UpdatedAt)
+
+	got, _ := s.repo.GetByID(ctx, t.ID.String())
+	s.Equal("b", got.Title)
+	s.Equal("other", got.Description)
+	s.Equal(created, *got.CreatedAt)
+	s.Equal(s.now, *got.UpdatedAt)
+
+	s.EqualError(s.repo.Update(ctx, "000-0000", changed), "uuid_format")
+	s.EqualError(s.repo.Update(ctx, uuid.New().String(), changed), "conflict_update")
+}
+
+func (s *SuiteTaskRepository) TestUpsert() {
+	id := uuid.New().String()
+	owner := domain.WithUser(context.TODO(), "user-1")
+
+	created, err := s.repo.Upsert(owner, id, domain.NewTask("a", "description"))
+	s.NoError(err)
+	s.True(created)
+	first := s.now
+	s.now = s.now.Add(time.Minute)
+
+	replaced := domain.NewTask("b", "description")
+	created, err = s.repo.Upsert(domain.WithUser(context.TODO(), "user-2"), id, replaced)
+	s.NoError(err)
+	s.False(created)
+	s.Equal(first, *replaced.CreatedAt, "a replaced task keeps created_at")
+
+	got, _ := s.repo.GetByID(context.TODO(), id)
+	s.Equal("b", got.Title)
+	s.Equal(first, *got.CreatedAt)
+	s.Equal(s.now, *got.UpdatedAt)
+	total, _ := s.repo.CountByUser(context.TODO(), "user-1")
+	s.Equal(1, total, "the owner is kept")
+
+	_, err = s.repo.Upsert(owner, "000-0000", replaced)
+	s.EqualError(err, "uuid_format")
+}
+
+func (s *SuiteTaskRepository) TestDelete() {
+	ctx := context.TODO()
+	t := s.insert("a")
+	s.NoError(s.repo.Delete(ctx, t.ID.String()))
+	_, err := s.repo.GetByID(ctx, t.ID.String())
+	s.EqualError(err, "not_found")
+	s.EqualError(s.repo.Delete(ctx, t.ID.String()), "conflict_delete")
+	s.EqualError(s.repo.Delete(ctx, "000-0000"), "uuid_format")
+}
+
+func (s *SuiteTaskRepository) TestComplete() {
+	ctx := context.TODO()
+	t := s.insert("a")
+
+	done, completed, err := s.repo.Complete(ctx, t.ID.String())
+	s.NoError(err)
+	s.True(completed)
+	s.Equal(s.now, *done.CompletedAt)
+	s.Equal(s.now, *done.UpdatedAt)
+	first := s.now
+
+	s.now = s.now.Add(time.Minute)
+	again, completed, err := s.repo.Complete(ctx, t.ID.String())
+	s.NoError(err)
+	s.False(completed, "a completed task is not completed again")
+	s.Equal(first, *again.CompletedAt)
+
+	replaced := domain.NewTask("b", "description")
+	_, err = s.repo.Upsert(ctx, t.ID.String(), replaced)
+	s.NoError(err)
+	s.Equal(first, *replaced.CompletedAt, "a replaced task keeps completed_at")
+
+	events, _ := memory.NewOutboxRepository(s.db).Pending(ctx, 10)
+	s.Require().Len(events, 3)
+	s.Equal(domain.TaskCompleted, events[1].Type)
+
+	_, _, err = s.repo.Complete(ctx, uuid.New().String())
+	s.EqualError(err, "not_found")
+	_, _, err = s.repo.Complete(ctx, "000-0000")
+	s.EqualError(err, "uuid_format")
+}
+
+func (s *SuiteTaskRepository) TestCountByUser() {
+	for _, user := range []string{"user-1", "user-1", "user-2", ""} {
+		s.NoError(s.repo.Insert(domain.WithUser(context.TODO(), user), domain.NewTask("a", "description")))
+	}
+	total, err := s.repo.CountByUser(context.TODO(), "user-1")
+	s.NoError(err)
+	s.Equal(2, total)
+	total, _ = s.repo.CountByUser(context.TODO(), "user-3")
+	s.Equal(0, total)
+}
+
+func (s *SuiteTaskRepository) TestChangesGoToTheOutbox() {
+	ctx := context.TODO()
+	outbox := memory.NewOutboxRepository(s.db)
+	changes := memory.NewSyncRepository(s.db)
+	t := s.insert("a")
+	s.NoError(s.repo.Update(ctx, t.ID.String(), domain.NewTask("b", "description")))
+	s.NoError(s.repo.Delete(ctx, t.ID.String()))
+
+	events, err := outbox.Pending(ctx, 2)
+	s.NoError(err)
+	s.Require().Len(events, 2)
+	s.Equal(domain.TaskCreated, events[0].Type)
+	s.Equal(domain.TaskUpdated, events[1].Type)
+	s.NoError(outbox.MarkPublished(ctx, events[0].ID))
+	s.NoError(outbox.MarkFailed(ctx, events[1].ID, "timeout"))
+	s.EqualError(outbox.MarkPublished(ctx, uuid.New()), "not_found")
+	events, _ = outbox.Pending(ctx, 10)
+	s.Len(events, 2)
+	s.Equal(domain.TaskUpdated, events[0].Type)
+	s.Equal(1, events[0].Attempts)
+	s.NoError(outbox.MarkDead(ctx, events[0].ID, "timeout"))
+	events, _ = outbox.Pending(ctx, 10)
+	s.Require().Len(events, 1, "dead events are skipped")
+	s.Equal(domain.TaskDeleted, events[0].Type)
+
+	all, err := changes.Changes(ctx, "", 1, 10)
+	s.NoError(err)
+	s.Require().Len(all, 2)
+	s.Equal(int64(2), all[0].Seq)
+	s.Equal(domain.SyncUpsert, all[0].Op)
+	s.Equal("b", all[0].Task.Title)
+	s.Equal(domain.SyncDelete, all[1].Op)
+	s.Nil(all[1].Task)
+	other, err := changes.Changes(ctx, "user-2", 0, 10)
+	s.NoError(err)
+	s.Empty(other, "the changes of other users are left out")
+
+	last, err := changes.LastChange(ctx, t.ID)
+	s.NoError(err)
+	s.Equal(int64(3), last.Seq)
+	last, err = changes.LastChange(ctx, uuid.New())
+	s.NoError(err)
+	s.Nil(last)
+}
+
+func (s *SuiteTaskRepository) TestConcurrentWrites() {
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			t := domain.NewTask("a", "description")
+			s.NoError(s.repo.Insert(context.TODO(), t))
+			_, err := s.repo.Fetch(context.TODO(), &domain.Filter{Limit: 10})
+			s.NoError(err)
+		}()
+	}
+	wg.Wait()
+	ts, err := s.repo.Fetch(context.TODO(), &domain.Filter{Limit: 0})
+	s.NoError(err)
+	s.Equal(50, ts.Total)
+}
+
+func TestSuiteTaskRepository(t *testing.T) {
+	suite.Run(t, new(SuiteTaskRepository))
+}
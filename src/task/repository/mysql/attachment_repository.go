@@ -0,0 +1,182 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/logging"
+	"go.uber.org/zap"
+)
+
+type attachmentRepository struct {
+	Conn *sql.DB
+	l    *zap.SugaredLogger
+}
+
+func NewAttachmentRepository(Conn *sql.DB, logger *zap.SugaredLogger) domain.AttachmentRepository {
+	return &attachmentRepository{
+		Conn: Conn,
+		l:    logger,
+	}
+}
+
+func (m *attachmentRepository) log(ctx context.Context) *zap.SugaredLogger {
+	return logging.FromContext(ctx, m.l)
+}
+
+func (m *attachmentRepository) Fetch(ctx context.Context, taskID string) ([]*domain.Attachment, error) {
+	_, binary_task, err := parseUUID(m.l, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return m.fetch(ctx, `WHERE task_id=? ORDER BY created_at ASC`, []interface{}{binary_task})
+}
+
+func (m *attachmentRepository) GetByID(ctx context.Context, taskID string, id string) (*domain.Attachment, error) {
+	_, binary_task, err := parseUUID(m.l, taskID)
+	if err != nil {
+		return nil, err
+	}
+	_, binary_uuid, err := parseUUID(m.l, id)
+	if err != nil {
+		return nil, err
+	}
+	attachments, err := m.fetch(ctx, `WHERE id=? AND task_id=?`, []interface{}{binary_uuid, binary_task})
+	if err != nil {
+		return nil, err
+	}
+	if len(attachments) == 0 {
+		m.log(ctx).Error("Not Found")
+		return nil, errors.New("not_found")
+	}
+	return attachments[0], nil
+}
+
+func (m *attachmentRepository) fetch(ctx context.Context, stmt string, filters []interface{}) ([]*domain.Attachment, error) {
+	query := `SELECT id, task_id, name, content_type, size, storage_key, created_at
+		FROM task_attachment ` + stmt
+	rows, err := m.Conn.QueryContext(ctx, query, filters...)
+	if err != nil {
+		m.log(ctx).Error(err.Error())
+		return nil, errors.New("query_context")
+	}
+	defer rows.Close()
+
+	attachments := []*domain.Attachment{}
+	for rows.Next() {
+		a := &domain.Attachment{}
+		err := rows.Scan(&a.ID, &a.TaskID, &a.Name, &a.ContentType, &a.Size, &a.Key, &a.CreatedAt)
+		if err != nil {
+			m.log(ctx).Error(err.Error())
+			return nil, errors.New("row_data_types")
+		}
+		attachments = append(attachments, a)
+	}
+	if err := rows.Err(); err != nil {
+		m.log(ctx).Error(err.Error())
+		return nil, errors.New("row_corrupt")
+	}
+	return attachments, nil
+}
+
+// Insert saves the row before running check, so a Release of the same key
+// either waits for the commit or holds the insert until drop is done.
+func (m *attachmentRepository) Insert(ctx context.Context, a *domain.Attachment, check func(context.Context) error) error {
+	created_at := time.Now()
+	a.CreatedAt = &created_at
+	binary_uuid, _ := a.ID.MarshalBinary()
+	binary_task, _ := a.TaskID.MarshalBinary()
+
+	query := `INSERT task_attachment SET
+		id=?,
+		task_id=?,
+		name=?,
+		content_type=?,
+		size=?,
+		storage_key=?,
+		created_at=?`
+	tx, err := m.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		m.log(ctx).Error(err.Error())
+		return errors.New("tx_begin")
+	}
+	defer tx.Rollback()
+	res, err := tx.ExecContext(ctx, query,
+		binary_uuid, binary_task, a.Name, a.ContentType, a.Size, a.Key, a.CreatedAt)
+	if err != nil {
+		m.log(ctx).Error(err.Error())
+		return errors.New("query_exec")
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		m.log(ctx).Error(err.Error())
+		return errors.New("query_exec")
+	}
+	if affect != 1 {
+		m.log(ctx).Errorf("Weird  Behavior. Total Affected: %d", affect)
+		return errors.New("conflict_insert")
+	}
+	if err := check(ctx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		m.log(ctx).Error(err.Error())
+		return errors.New("tx_commit")
+	}
+	return nil
+}
+
+func (m *attachmentRepository) Delete(ctx context.Context, id string) error {
+	_, binary_uuid, err := parseUUID(m.l, id)
+	if err != nil {
+		return err
+	}
+	res, err := m.Conn.ExecContext(ctx, `DELETE FROM task_attachment WHERE id=?`, binary_uuid)
+	if err != nil {
+		m.log(ctx).Error(err.Error())
+		return errors.New("query_exec")
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		m.log(ctx).Error(err.Error())
+		return errors.New("query_exec_delete")
+	}
+	if affect != 1 {
+		m.log(ctx).Errorf("Weird  Behavior. Total Affected: %d", affect)
+		return errors.New("conflict_delete")
+	}
+	return nil
+}
+
+// Release runs drop once no attachment points at a blob. Counting with FOR
+// UPDATE locks the rows of the key on its index, and the gap where new ones
+// would go, so an Insert of the same key waits until drop is done.
+func (m *attachmentRepository) Release(ctx context.Context, key string, drop func(context.Context) error) error {
+	tx, err := m.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		m.log(ctx).Error(err.Error())
+		return errors.New("tx_begin")
+	}
+	defer tx.Rollback()
+
+	var total int
+	row := tx.QueryRowContext(ctx, `SELECT count(*) FROM task_attachment WHERE storage_key=? FOR UPDATE`, key)
+	if err := row.Scan(&total); err != nil {
+		m.log(ctx).Error(err.Error())
+		return errors.New("query_context")
+	}
+	if total > 0 {
+		return nil
+	}
+	if err := drop(ctx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		m.log(ctx).Error(err.Error())
+		return errors.New("tx_commit")
+	}
+	return nil
+}
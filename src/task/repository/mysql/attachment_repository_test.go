@@ -0,0 +1,200 @@
+package mysql_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/task/repository/mysql"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+type SuiteAttachmentRepository struct {
+	suite.Suite
+	db      *sql.DB
+	mockSQL sqlmock.Sqlmock
+	repo    domain.AttachmentRepository
+}
+
+func (s *SuiteAttachmentRepository) SetupTest() {
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	sugar := logger.Sugar()
+
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	s.db = db
+	s.mockSQL = mockSQL
+	s.repo = mysql.NewAttachmentRepository(db, sugar)
+}
+
+func (s *SuiteAttachmentRepository) rows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "task_id", "name", "content_type", "size", "storage_key", "created_at"})
+}
+
+func (s *SuiteAttachmentRepository) TestFetch() {
+	q := "SELECT id, task_id, name, content_type, size, storage_key, created_at FROM task_attachment WHERE task_id=\\? ORDER BY created_at ASC"
+
+	s.Run("Success test return the task attachments", func() {
+		task_uuid := uuid.New()
+		binary_task, _ := task_uuid.MarshalBinary()
+		binary_uuid, _ := uuid.New().MarshalBinary()
+		data := s.rows().
+			AddRow(binary_uuid, binary_task, "spec.pdf", "application/pdf", 10, "tasks/1/spec.pdf", time.Now()).
+			AddRow(binary_uuid, binary_task, "shot.png", "image/png", 20, "tasks/1/shot.png", time.Now())
+		s.mockSQL.ExpectQuery(q).WithArgs(binary_task).WillReturnRows(data)
+
+		attachments, err := s.repo.Fetch(context.TODO(), task_uuid.String())
+		s.NoError(err)
+		s.Len(attachments, 2)
+		s.Equal("spec.pdf", attachments[0].Name)
+		s.Equal("tasks/1/shot.png", attachments[1].Key)
+		s.Equal(task_uuid, attachments[1].TaskID)
+	})
+
+	s.Run("When test uuid without format return error", func() {
+		attachments, err := s.repo.Fetch(context.TODO(), "0000")
+		s.Error(err)
+		s.Equal("uuid_format", err.Error())
+		s.Nil(attachments)
+	})
+
+	s.Run("When the query fails return error", func() {
+		s.mockSQL.ExpectQuery(q).WillReturnError(errors.New("D error"))
+		attachments, err := s.repo.Fetch(context.TODO(), uuid.New().String())
+		s.Error(err)
+		s.Equal("query_context", err.Error())
+		s.Nil(attachments)
+	})
+}
+
+func (s *SuiteAttachmentRepository) TestGetByID() {
+	q := "SELECT id, task_id, name, content_type, size, storage_key, created_at FROM task_attachment WHERE id=\\? AND task_id=\\?"
+
+	s.Run("Success test return the attachment", func() {
+		task_uuid, raw_uuid := uuid.New(), uuid.New()
+		binary_task, _ := task_uuid.MarshalBinary()
+		binary_uuid, _ := raw_uuid.MarshalBinary()
+		data := s.rows().
+			AddRow(binary_uuid, binary_task, "spec.pdf", "application/pdf", 10, "tasks/1/spec.pdf", time.Now())
+		s.mockSQL.ExpectQuery(q).WithArgs(binary_uuid, binary_task).WillReturnRows(data)
+
+		a, err := s.repo.GetByID(context.TODO(), task_uuid.String(), raw_uuid.String())
+		s.NoError(err)
+		s.Equal(raw_uuid, a.ID)
+		s.Equal(int64(10), a.Size)
+	})
+
+	s.Run("When the attachment does not exist return error", func() {
+		s.mockSQL.ExpectQuery(q).WillReturnRows(s.rows())
+		a, err := s.repo.GetByID(context.TODO(), uuid.New().String(), uuid.New().String())
+		s.Error(err)
+		s.Equal("not_found", err.Error())
+		s.Nil(a)
+	})
+}
+
+func (s *SuiteAttachmentRepository) TestInsert() {
+	q := "INSERT task_attachment SET id=\\?, task_id=\\?, name=\\?, content_type=\\?, size=\\?, storage_key=\\?, created_at=\\?"
+
+	found := func(context.Context) error { return nil }
+
+	s.Run("Success test", func() {
+		a := domain.NewAttachment(uuid.New(), "spec.pdf", "application/pdf")
+		a.Size, a.Key = 10, "tasks/1/spec.pdf"
+		s.mockSQL.ExpectBegin()
+		s.mockSQL.ExpectExec(q).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), a.Name, a.ContentType, a.Size, a.Key, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		s.mockSQL.ExpectCommit()
+		s.NoError(s.repo.Insert(context.TODO(), a, found))
+		s.NotNil(a.CreatedAt)
+	})
+
+	s.Run("When the check fails the row is rolled back", func() {
+		a := domain.NewAttachment(uuid.New(), "spec.pdf", "application/pdf")
+		s.mockSQL.ExpectBegin()
+		s.mockSQL.ExpectExec(q).WillReturnResult(sqlmock.NewResult(1, 1))
+		s.mockSQL.ExpectRollback()
+		err := s.repo.Insert(context.TODO(), a, func(context.Context) error { return domain.ErrAttachmentConflict })
+		s.Equal(domain.ErrAttachmentConflict, err)
+	})
+
+	s.Run("When the exec fails must return error", func() {
+		a := domain.NewAttachment(uuid.New(), "spec.pdf", "application/pdf")
+		s.mockSQL.ExpectBegin()
+		s.mockSQL.ExpectExec(q).WillReturnError(errors.New("exec error"))
+		s.mockSQL.ExpectRollback()
+		err := s.repo.Insert(context.TODO(), a, found)
+		s.Error(err)
+		s.Equal("query_exec", err.Error())
+	})
+	s.NoError(s.mockSQL.ExpectationsWereMet())
+}
+
+func (s *SuiteAttachmentRepository) TestDelete() {
+	q := "DELETE FROM task_attachment WHERE id=\\?"
+
+	s.Run("Success test", func() {
+		raw_uuid := uuid.New()
+		binary_uuid, _ := raw_uuid.MarshalBinary()
+		s.mockSQL.ExpectExec(q).WithArgs(binary_uuid).WillReturnResult(sqlmock.NewResult(1, 1))
+		s.NoError(s.repo.Delete(context.TODO(), raw_uuid.String()))
+	})
+
+	s.Run("When nothing was deleted must return error", func() {
+		s.mockSQL.ExpectExec(q).WillReturnResult(sqlmock.NewResult(0, 0))
+		err := s.repo.Delete(context.TODO(), uuid.New().String())
+		s.Error(err)
+		s.Equal("conflict_delete", err.Error())
+	})
+}
+
+func (s *SuiteAttachmentRepository) TestRelease() {
+	q := "SELECT count\\(\\*\\) FROM task_attachment WHERE storage_key=\\? FOR UPDATE"
+	dropped := 0
+	drop := func(context.Context) error {
+		dropped++
+		return nil
+	}
+
+	s.Run("When the blob is shared it is kept", func() {
+		s.mockSQL.ExpectBegin()
+		s.mockSQL.ExpectQuery(q).WithArgs("abc").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		s.mockSQL.ExpectRollback()
+		s.NoError(s.repo.Release(context.TODO(), "abc", drop))
+		s.Equal(0, dropped)
+	})
+
+	s.Run("When nothing points at the blob it is dropped under the lock", func() {
+		s.mockSQL.ExpectBegin()
+		s.mockSQL.ExpectQuery(q).WithArgs("abc").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		s.mockSQL.ExpectCommit()
+		s.NoError(s.repo.Release(context.TODO(), "abc", drop))
+		s.Equal(1, dropped)
+	})
+
+	s.Run("When the query fails return error", func() {
+		s.mockSQL.ExpectBegin()
+		s.mockSQL.ExpectQuery(q).WillReturnError(errors.New("D error"))
+		s.mockSQL.ExpectRollback()
+		err := s.repo.Release(context.TODO(), "abc", drop)
+		s.Error(err)
+		s.Equal("query_context", err.Error())
+	})
+	s.NoError(s.mockSQL.ExpectationsWereMet())
+}
+
+func TestSuiteAttachmentRepository(t *testing.T) {
+	suite.Run(t, new(SuiteAttachmentRepository))
+}
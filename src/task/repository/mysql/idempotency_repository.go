@@ -0,0 +1,111 @@
+package mysql
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/logging"
+	"go.uber.org/zap"
+)
+
+type idempotencyRepository struct {
+	Conn *sql.DB
+	l    *zap.SugaredLogger
+}
+
+func NewIdempotencyRepository(Conn *sql.DB, logger *zap.SugaredLogger) domain.IdempotencyStore {
+	return &idempotencyRepository{
+		Conn: Conn,
+		l:    logger,
+	}
+}
+
+func (m *idempotencyRepository) log(ctx context.Context) *zap.SugaredLogger {
+	return logging.FromContext(ctx, m.l)
+}
+
+// Reserve locks the row of the key, so of two requests racing with the
+// same key only one finds it free.
+func (m *idempotencyRepository) Reserve(ctx context.Context, key string, token string, requestHash string, lease time.Duration) (*domain.IdempotencyRecord, error) {
+	scope := sha256.Sum256([]byte(key))
+	tx, err := m.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		m.log(ctx).Error(err.Error())
+		return nil, errors.New("tx_begin")
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	record := &domain.IdempotencyRecord{}
+	var expiresAt time.Time
+	err = tx.QueryRowContext(ctx, `SELECT request_hash, status_code, content_type, body, expires_at
+		FROM idempotency_key WHERE scope_key=? FOR UPDATE`, scope[:]).
+		Scan(&record.RequestHash, &record.StatusCode, &record.ContentType, &record.Body, &expiresAt)
+	switch {
+	case err == nil && expiresAt.After(now):
+		return record, nil
+	case err != nil && err != sql.ErrNoRows:
+		m.log(ctx).Error(err.Error())
+		return nil, errors.New("query_context")
+	}
+
+	query := `INSERT INTO idempotency_key (scope_key, request_hash, lease_token, status_code, content_type, body, created_at, expires_at)
+		VALUES (?, ?, ?, 0, '', NULL, ?, ?)
+		ON DUPLICATE KEY UPDATE request_hash=VALUES(request_hash), lease_token=VALUES(lease_token), status_code=0,
+			content_type='', body=NULL, created_at=VALUES(created_at), expires_at=VALUES(expires_at)`
+	if _, err := tx.ExecContext(ctx, query, scope[:], requestHash, token, now, now.Add(lease)); err != nil {
+		m.log(ctx).Error(err.Error())
+		return nil, errors.New("query_exec")
+	}
+	if err := tx.Commit(); err != nil {
+		m.log(ctx).Error(err.Error())
+		return nil, errors.New("tx_commit")
+	}
+	return nil, nil
+}
+
+func (m *idempotencyRepository) Complete(ctx context.Context, key string, token string, record *domain.IdempotencyRecord, ttl time.Duration) error {
+	scope := sha256.Sum256([]byte(key))
+	query := `UPDATE idempotency_key SET status_code=?, content_type=?, body=?, expires_at=?
+		WHERE scope_key=? AND lease_token=? AND status_code=0`
+	res, err := m.Conn.ExecContext(ctx, query, record.StatusCode, record.ContentType, record.Body, time.Now().Add(ttl), scope[:], token)
+	if err != nil {
+		m.log(ctx).Error(err.Error())
+		return errors.New("query_exec")
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		m.log(ctx).Error(err.Error())
+		return errors.New("rows_affected")
+	}
+	if affected == 0 {
+		return domain.ErrLeaseLost
+	}
+	return nil
+}
+
+func (m *idempotencyRepository) Release(ctx context.Context, key string, token string) error {
+	scope := sha256.Sum256([]byte(key))
+	query := `DELETE FROM idempotency_key WHERE scope_key=? AND lease_token=? AND status_code=0`
+	if _, err := m.Conn.ExecContext(ctx, query, scope[:], token); err != nil {
+		m.log(ctx).Error(err.Error())
+		return errors.New("query_exec")
+	}
+	return nil
+}
+
+// Purge deletes the expired keys a batch at a time, to keep the locks
+// short.
+func (m *idempotencyRepository) Purge(ctx context.Context) (int64, error) {
+	res, err := m.Conn.ExecContext(ctx,
+		`DELETE FROM idempotency_key WHERE expires_at < ? LIMIT 1000`, time.Now())
+	if err != nil {
+		m.log(ctx).Error(err.Error())
+		return 0, errors.New("query_exec")
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,110 @@
+package mysql_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/task/repository/mysql"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+type SuiteIdempotencyRepository struct {
+	suite.Suite
+	mockSQL sqlmock.Sqlmock
+	repo    domain.IdempotencyStore
+}
+
+func (s *SuiteIdempotencyRepository) SetupTest() {
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	db, mockSQL, err := sqlmock.New()
+	s.Require().NoError(err)
+	s.mockSQL = mockSQL
+	s.repo = mysql.NewIdempotencyRepository(db, logger.Sugar())
+}
+
+func (s *SuiteIdempotencyRepository) TestReserve() {
+	q := "SELECT request_hash, status_code, content_type, body, expires_at FROM idempotency_key WHERE scope_key=\\? FOR UPDATE"
+	qSave := "INSERT INTO idempotency_key \\(scope_key, request_hash, lease_token, status_code, content_type, body, created_at, expires_at\\)"
+	columns := []string{"request_hash", "status_code", "content_type", "body", "expires_at"}
+
+	s.Run("When the key is new", func() {
+		s.mockSQL.ExpectBegin()
+		s.mockSQL.ExpectQuery(q).WillReturnRows(sqlmock.NewRows(columns))
+		s.mockSQL.ExpectExec(qSave).WithArgs(sqlmock.AnyArg(), "hash", "t1", sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		s.mockSQL.ExpectCommit()
+		record, err := s.repo.Reserve(context.TODO(), "user-1|k1", "t1", "hash", time.Hour)
+		s.NoError(err)
+		s.Nil(record)
+	})
+
+	s.Run("When the key is live", func() {
+		s.mockSQL.ExpectBegin()
+		s.mockSQL.ExpectQuery(q).WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("hash", 202, "application/json", []byte(`{}`), time.Now().Add(time.Hour)))
+		s.mockSQL.ExpectRollback()
+		record, err := s.repo.Reserve(context.TODO(), "user-1|k1", "t1", "other", time.Hour)
+		s.NoError(err)
+		s.Equal(&domain.IdempotencyRecord{
+			RequestHash: "hash",
+			StatusCode:  202,
+			ContentType: "application/json",
+			Body:        []byte(`{}`),
+		}, record)
+	})
+
+	s.Run("When the key expired", func() {
+		s.mockSQL.ExpectBegin()
+		s.mockSQL.ExpectQuery(q).WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("hash", 202, "application/json", []byte(`{}`), time.Now().Add(-time.Minute)))
+		s.mockSQL.ExpectExec(qSave).WillReturnResult(sqlmock.NewResult(1, 2))
+		s.mockSQL.ExpectCommit()
+		record, err := s.repo.Reserve(context.TODO(), "user-1|k1", "t1", "other", time.Hour)
+		s.NoError(err)
+		s.Nil(record)
+	})
+
+	s.Run("When the query fails", func() {
+		s.mockSQL.ExpectBegin()
+		s.mockSQL.ExpectQuery(q).WillReturnError(errors.New("deadlock"))
+		s.mockSQL.ExpectRollback()
+		_, err := s.repo.Reserve(context.TODO(), "user-1|k1", "t1", "hash", time.Hour)
+		s.EqualError(err, "query_context")
+	})
+	s.NoError(s.mockSQL.ExpectationsWereMet())
+}
+
+func (s *SuiteIdempotencyRepository) TestCompleteReleasePurge() {
+	qComplete := "UPDATE idempotency_key SET status_code=\\?, content_type=\\?, body=\\?, expires_at=\\? " +
+		"WHERE scope_key=\\? AND lease_token=\\? AND status_code=0"
+	s.mockSQL.ExpectExec(qComplete).
+		WithArgs(201, "application/json", []byte(`{}`), sqlmock.AnyArg(), sqlmock.AnyArg(), "t1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mockSQL.ExpectExec(qComplete).
+		WithArgs(201, "application/json", []byte(`{}`), sqlmock.AnyArg(), sqlmock.AnyArg(), "t0").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.mockSQL.ExpectExec("DELETE FROM idempotency_key WHERE scope_key=\\? AND lease_token=\\? AND status_code=0").
+		WithArgs(sqlmock.AnyArg(), "t1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.mockSQL.ExpectExec("DELETE FROM idempotency_key WHERE expires_at < \\? LIMIT 1000").
+		WillReturnResult(sqlmock.NewResult(0, 7))
+
+	record := &domain.IdempotencyRecord{StatusCode: 201, ContentType: "application/json", Body: []byte(`{}`)}
+	s.NoError(s.repo.Complete(context.TODO(), "user-1|k1", "t1", record, time.Hour))
+	s.Equal(domain.ErrLeaseLost, s.repo.Complete(context.TODO(), "user-1|k1", "t0", record, time.Hour))
+	s.NoError(s.repo.Release(context.TODO(), "user-1|k1", "t1"))
+	purged, err := s.repo.Purge(context.TODO())
+	s.NoError(err)
+	s.Equal(int64(7), purged)
+	s.NoError(s.mockSQL.ExpectationsWereMet())
+}
+
+func TestSuiteIdempotencyRepository(t *testing.T) {
+	suite.Run(t, new(SuiteIdempotencyRepository))
+}
@@ -0,0 +1,97 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/isaias-dgr/todo/src/domain"
+	"go.uber.org/zap"
+)
+
+type outboxRepository struct {
+	Conn *sql.DB
+	l    *zap.SugaredLogger
+}
+
+func NewOutboxRepository(Conn *sql.DB, logger *zap.SugaredLogger) domain.OutboxRepository {
+	return &outboxRepository{
+		Conn: Conn,
+		l:    logger,
+	}
+}
+
+func (m *outboxRepository) Pending(ctx context.Context, limit int) ([]*domain.Event, error) {
+	query := `SELECT id, event_type, task_id, payload, created_at, attempts FROM task_outbox
+		WHERE published_at IS NULL AND dead_at IS NULL ORDER BY seq ASC LIMIT ?`
+	rows, err := m.Conn.QueryContext(ctx, query, limit)
+	if err != nil {
+		m.l.Error(err.Error())
+		return nil, errors.New("query_context")
+	}
+	defer rows.Close()
+
+	events := []*domain.Event{}
+	for rows.Next() {
+		event := &domain.Event{}
+		var payload []byte
+		err := rows.Scan(&event.ID, &event.Type, &event.TaskID, &payload, &event.CreatedAt, &event.Attempts)
+		if err != nil {
+			m.l.Error(err.Error())
+			return nil, errors.New("row_data_types")
+		}
+		event.Payload = payload
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		m.l.Error(err.Error())
+		return nil, errors.New("row_corrupt")
+	}
+	return events, nil
+}
+
+func (m *outboxRepository) MarkPublished(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE task_outbox SET published_at=? WHERE id=?`
+	return m.exec(ctx, query, time.Now(), m.binary(id))
+}
+
+func (m *outboxRepository) MarkFailed(ctx context.Context, id uuid.UUID, reason string) error {
+	if len(reason) > 255 {
+		reason = reason[:255]
+	}
+	query := `UPDATE task_outbox SET attempts=attempts+1, last_error=? WHERE id=?`
+	return m.exec(ctx, query, reason, m.binary(id))
+}
+
+func (m *outboxRepository) MarkDead(ctx context.Context, id uuid.UUID, reason string) error {
+	if len(reason) > 255 {
+		reason = reason[:255]
+	}
+	query := `UPDATE task_outbox SET attempts=attempts+1, last_error=?, dead_at=? WHERE id=?`
+	return m.exec(ctx, query, reason, time.Now(), m.binary(id))
+}
+
+func (m *outboxRepository) exec(ctx context.Context, query string, args ...interface{}) error {
+	res, err := m.Conn.ExecContext(ctx, query, args...)
+	if err != nil {
+		m.l.Error(err.Error())
+		return errors.New("query_exec")
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		m.l.Error(err.Error())
+		return errors.New("query_exec")
+	}
+	if affect != 1 {
+		m.l.Errorf("Weird  Behavior. Total Affected: %d", affect)
+		return errors.New("not_found")
+	}
+	return nil
+}
+
+func (m *outboxRepository) binary(id uuid.UUID) []byte {
+	binary_uuid, _ := id.MarshalBinary()
+	return binary_uuid
+}
@@ -0,0 +1,133 @@
+package mysql_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/task/repository/mysql"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+type SuiteOutboxRepository struct {
+	suite.Suite
+	db      *sql.DB
+	mockSQL sqlmock.Sqlmock
+	repo    domain.OutboxRepository
+}
+
+func (s *SuiteOutboxRepository) SetupTest() {
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	sugar := logger.Sugar()
+
+	db, mockSQL, err := sqlmock.New()
+	if err != nil {
+		s.Failf("an error '%s' was not expected when opening a stub database connection", err.Error())
+	}
+	s.db = db
+	s.mockSQL = mockSQL
+	s.repo = mysql.NewOutboxRepository(db, sugar)
+}
+
+func (s *SuiteOutboxRepository) TestPending() {
+	q := "SELECT id, event_type, task_id, payload, created_at, attempts FROM task_outbox WHERE published_at IS NULL AND dead_at IS NULL ORDER BY seq ASC LIMIT \\?"
+
+	s.Run("Success test return the pending events in order", func() {
+		event_uuid, _ := uuid.New().MarshalBinary()
+		task_uuid, _ := uuid.New().MarshalBinary()
+		now := time.Now()
+		rows := []string{"id", "event_type", "task_id", "payload", "created_at", "attempts"}
+		data := sqlmock.NewRows(rows).
+			AddRow(event_uuid, domain.TaskCreated, task_uuid, []byte(`{"title":"t"}`), now, 3).
+			AddRow(event_uuid, domain.TaskUpdated, task_uuid, []byte(`{"title":"t2"}`), now, 0)
+		s.mockSQL.ExpectQuery(q).WithArgs(10).WillReturnRows(data)
+
+		events, err := s.repo.Pending(context.TODO(), 10)
+		s.NoError(err)
+		s.Len(events, 2)
+		s.Equal(domain.TaskCreated, events[0].Type)
+		s.Equal(domain.TaskUpdated, events[1].Type)
+		s.JSONEq(`{"title":"t2"}`, string(events[1].Payload))
+		s.Equal(3, events[0].Attempts)
+	})
+
+	s.Run("When the query fails must return error", func() {
+		s.mockSQL.ExpectQuery(q).WithArgs(10).WillReturnError(errors.New("D error"))
+		events, err := s.repo.Pending(context.TODO(), 10)
+		s.Error(err)
+		s.Equal("query_context", err.Error())
+		s.Nil(events)
+	})
+
+	s.Run("When db return incorrect type data", func() {
+		rows := []string{"id", "event_type", "task_id", "payload", "created_at", "attempts"}
+		data := sqlmock.NewRows(rows).AddRow("uuid", "T", "uuid", "P", "C", "A")
+		s.mockSQL.ExpectQuery(q).WithArgs(10).WillReturnRows(data)
+		events, err := s.repo.Pending(context.TODO(), 10)
+		s.Error(err)
+		s.Equal("row_data_types", err.Error())
+		s.Nil(events)
+	})
+}
+
+func (s *SuiteOutboxRepository) TestMarkPublished() {
+	q := "UPDATE task_outbox SET published_at=\\? WHERE id=\\?"
+
+	s.Run("Success test", func() {
+		raw_uuid := uuid.New()
+		binary_uuid, _ := raw_uuid.MarshalBinary()
+		s.mockSQL.ExpectExec(q).
+			WithArgs(sqlmock.AnyArg(), binary_uuid).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		s.NoError(s.repo.MarkPublished(context.TODO(), raw_uuid))
+	})
+
+	s.Run("When the event does not exist must return error", func() {
+		s.mockSQL.ExpectExec(q).WillReturnResult(sqlmock.NewResult(0, 0))
+		err := s.repo.MarkPublished(context.TODO(), uuid.New())
+		s.Error(err)
+		s.Equal("not_found", err.Error())
+	})
+
+	s.Run("When the exec fails must return error", func() {
+		s.mockSQL.ExpectExec(q).WillReturnError(errors.New("exec error"))
+		err := s.repo.MarkPublished(context.TODO(), uuid.New())
+		s.Error(err)
+		s.Equal("query_exec", err.Error())
+	})
+}
+
+func (s *SuiteOutboxRepository) TestMarkFailed() {
+	q := "UPDATE task_outbox SET attempts=attempts\\+1, last_error=\\? WHERE id=\\?"
+
+	s.Run("Success test", func() {
+		raw_uuid := uuid.New()
+		binary_uuid, _ := raw_uuid.MarshalBinary()
+		s.mockSQL.ExpectExec(q).
+			WithArgs("publish error", binary_uuid).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		s.NoError(s.repo.MarkFailed(context.TODO(), raw_uuid, "publish error"))
+	})
+}
+
+func (s *SuiteOutboxRepository) TestMarkDead() {
+	q := "UPDATE task_outbox SET attempts=attempts\\+1, last_error=\\?, dead_at=\\? WHERE id=\\?"
+	raw_uuid := uuid.New()
+	binary_uuid, _ := raw_uuid.MarshalBinary()
+	s.mockSQL.ExpectExec(q).
+		WithArgs("publish error", sqlmock.AnyArg(), binary_uuid).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.NoError(s.repo.MarkDead(context.TODO(), raw_uuid, "publish error"))
+	s.NoError(s.mockSQL.ExpectationsWereMet())
+}
+
+func TestSuiteOutboxRepository(t *testing.T) {
+	suite.Run(t, new(SuiteOutboxRepository))
+}
@@ -0,0 +1,76 @@
+package mysql
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/isaias-dgr/todo/src/domain"
+	"go.uber.org/zap"
+)
+
+type rateLimitRepository struct {
+	Conn *sql.DB
+	l    *zap.SugaredLogger
+}
+
+// NewRateLimitRepository keeps the buckets in MySQL so every replica sees
+// the same ones. The row of a bucket is locked while a token is taken, and
+// full_at records when the bucket is full again so Purge can drop it.
+func NewRateLimitRepository(Conn *sql.DB, logger *zap.SugaredLogger) domain.RateLimitStore {
+	return &rateLimitRepository{
+		Conn: Conn,
+		l:    logger,
+	}
+}
+
+func (m *rateLimitRepository) Take(ctx context.Context, key string, limit domain.RateLimit) (domain.RateLimitResult, error) {
+	sum := sha256.Sum256([]byte(key))
+	tx, err := m.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		m.l.Error(err.Error())
+		return domain.RateLimitResult{}, errors.New("tx_begin")
+	}
+	defer tx.Rollback()
+
+	var state *domain.Bucket
+	current := domain.Bucket{}
+	err = tx.QueryRowContext(ctx,
+		`SELECT tokens, updated_at FROM rate_limit_bucket WHERE bucket_key=? FOR UPDATE`, sum[:]).
+		Scan(&current.Tokens, &current.UpdatedAt)
+	switch {
+	case err == nil:
+		state = &current
+	case err != sql.ErrNoRows:
+		m.l.Error(err.Error())
+		return domain.RateLimitResult{}, errors.New("query_context")
+	}
+
+	next, res := limit.Take(state, time.Now())
+	query := `INSERT INTO rate_limit_bucket (bucket_key, tokens, updated_at, full_at) VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE tokens=VALUES(tokens), updated_at=VALUES(updated_at), full_at=VALUES(full_at)`
+	full_at := next.UpdatedAt.Add(res.Reset)
+	if _, err := tx.ExecContext(ctx, query, sum[:], next.Tokens, next.UpdatedAt, full_at); err != nil {
+		m.l.Error(err.Error())
+		return domain.RateLimitResult{}, errors.New("query_exec")
+	}
+	if err := tx.Commit(); err != nil {
+		m.l.Error(err.Error())
+		return domain.RateLimitResult{}, errors.New("tx_commit")
+	}
+	return res, nil
+}
+
+// Purge deletes the buckets that are full again a batch at a time, to keep
+// the locks short.
+func (m *rateLimitRepository) Purge(ctx context.Context) (int64, error) {
+	res, err := m.Conn.ExecContext(ctx,
+		`DELETE FROM rate_limit_bucket WHERE full_at <= ? LIMIT 1000`, time.Now())
+	if err != nil {
+		m.l.Error(err.Error())
+		return 0, errors.New("query_exec")
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,94 @@
+package mysql_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/task/repository/mysql"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+type SuiteRateLimitRepository struct {
+	suite.Suite
+	mockSQL sqlmock.Sqlmock
+	repo    domain.RateLimitStore
+}
+
+func (s *SuiteRateLimitRepository) SetupTest() {
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	db, mockSQL, err := sqlmock.New()
+	s.Require().NoError(err)
+	s.mockSQL = mockSQL
+	s.repo = mysql.NewRateLimitRepository(db, logger.Sugar())
+}
+
+func (s *SuiteRateLimitRepository) TestTake() {
+	q := "SELECT tokens, updated_at FROM rate_limit_bucket WHERE bucket_key=\\? FOR UPDATE"
+	qSave := "INSERT INTO rate_limit_bucket \\(bucket_key, tokens, updated_at, full_at\\) VALUES \\(\\?, \\?, \\?, \\?\\) ON DUPLICATE KEY UPDATE"
+	limit := domain.RateLimit{Rate: 1, Burst: 5}
+
+	s.Run("When the bucket is new", func() {
+		s.mockSQL.ExpectBegin()
+		s.mockSQL.ExpectQuery(q).WillReturnRows(sqlmock.NewRows([]string{"tokens", "updated_at"}))
+		s.mockSQL.ExpectExec(qSave).WithArgs(sqlmock.AnyArg(), 4.0, sqlmock.AnyArg(), fullIn(time.Second)).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		s.mockSQL.ExpectCommit()
+		res, err := s.repo.Take(context.TODO(), "POST /task/|user:1", limit)
+		s.NoError(err)
+		s.True(res.Allowed)
+		s.Equal(4, res.Remaining)
+	})
+
+	s.Run("When the bucket is empty", func() {
+		s.mockSQL.ExpectBegin()
+		s.mockSQL.ExpectQuery(q).
+			WillReturnRows(sqlmock.NewRows([]string{"tokens", "updated_at"}).AddRow(0.0, time.Now().Add(time.Hour)))
+		s.mockSQL.ExpectExec(qSave).WillReturnResult(sqlmock.NewResult(1, 1))
+		s.mockSQL.ExpectCommit()
+		res, err := s.repo.Take(context.TODO(), "POST /task/|user:1", limit)
+		s.NoError(err)
+		s.False(res.Allowed)
+		s.Equal(time.Second, res.RetryAfter)
+	})
+
+	s.Run("When the query fails", func() {
+		s.mockSQL.ExpectBegin()
+		s.mockSQL.ExpectQuery(q).WillReturnError(errors.New("lock wait timeout"))
+		s.mockSQL.ExpectRollback()
+		_, err := s.repo.Take(context.TODO(), "POST /task/|user:1", limit)
+		s.EqualError(err, "query_context")
+	})
+	s.NoError(s.mockSQL.ExpectationsWereMet())
+}
+
+func (s *SuiteRateLimitRepository) TestPurge() {
+	q := "DELETE FROM rate_limit_bucket WHERE full_at <= \\? LIMIT 1000"
+	s.mockSQL.ExpectExec(q).WillReturnResult(sqlmock.NewResult(0, 3))
+	purged, err := s.repo.Purge(context.TODO())
+	s.NoError(err)
+	s.Equal(int64(3), purged)
+
+	s.mockSQL.ExpectExec(q).WillReturnError(errors.New("lock wait timeout"))
+	_, err = s.repo.Purge(context.TODO())
+	s.EqualError(err, "query_exec")
+	s.NoError(s.mockSQL.ExpectationsWereMet())
+}
+
+// fullIn matches a full_at about d from now.
+type fullIn time.Duration
+
+func (d fullIn) Match(v driver.Value) bool {
+	t, ok := v.(time.Time)
+	return ok && time.Until(t) > 0 && time.Until(t) <= time.Duration(d)
+}
+
+func TestSuiteRateLimitRepository(t *testing.T) {
+	suite.Run(t, new(SuiteRateLimitRepository))
+}
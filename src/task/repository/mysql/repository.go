@@ -8,34 +8,69 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/logging"
+	"github.com/isaias-dgr/todo/src/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+// Observer is told how long each repository method took and whether it
+// failed.
+type Observer func(method string, elapsed time.Duration, err error)
+
 type taskRepository struct {
-	Conn *sql.DB
-	l    *zap.SugaredLogger
+	Conn      *sql.DB
+	l         *zap.SugaredLogger
+	observers []Observer
 }
 
-func NewtaskRepository(Conn *sql.DB, logger *zap.SugaredLogger) domain.TaskRepository {
+func NewtaskRepository(Conn *sql.DB, logger *zap.SugaredLogger, observers ...Observer) domain.TaskRepository {
 	return &taskRepository{
-		Conn: Conn,
-		l:    logger,
+		Conn:      Conn,
+		l:         logger,
+		observers: observers,
 	}
 }
 
+// start opens the span of one repository method. The returned func ends
+// it and reports the duration to the observers.
+func (m *taskRepository) start(ctx context.Context, method string, statement string) (context.Context, func(err *error)) {
+	begin := time.Now()
+	ctx, span := tracing.Start(ctx, "taskRepository."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemMySQL,
+			semconv.DBSQLTableKey.String("task"),
+			semconv.DBStatementKey.String(statement)))
+	return ctx, func(err *error) {
+		tracing.End(span, *err)
+		elapsed := time.Since(begin)
+		for _, observer := range m.observers {
+			observer(method, elapsed, *err)
+		}
+	}
+}
+
+func (m *taskRepository) log(ctx context.Context) *zap.SugaredLogger {
+	return logging.FromContext(ctx, m.l)
+}
+
 func (m *taskRepository) Fetch(ctx context.Context, f *domain.Filter) (ts *domain.Tasks, err error) {
 	query := `ORDER BY created_at ASC LIMIT ? OFFSET ?`
+	ctx, end := m.start(ctx, "Fetch", "")
+	defer end(&err)
 	filter := []interface{}{f.Limit, f.Offset}
 
 	tasks, err := m.fetch(ctx, query, filter)
 	if err != nil {
-		m.l.Error(err.Error())
+		m.log(ctx).Error(err.Error())
 		return nil, err
 	}
 
 	total, err := m.count(ctx)
 	if err != nil {
-		m.l.Error(err.Error())
+		m.log(ctx).Error(err.Error())
 		return nil, err
 	}
 	return domain.NewTasks(tasks, total), nil
@@ -43,25 +78,27 @@ func (m *taskRepository) Fetch(ctx context.Context, f *domain.Filter) (ts *domai
 
 func (m *taskRepository) fetch(ctx context.Context, stmt string, filters []interface{}) (ts []*domain.Task, err error) {
 	tasks := []*domain.Task{}
-	query := `SELECT * FROM task ` + stmt
+	query := `SELECT id, title, description, created_at, updated_at, completed_at FROM task ` + stmt
+	ctx, end := m.start(ctx, "select", query)
+	defer end(&err)
 	rows, err := m.Conn.QueryContext(ctx, query, filters...)
 	if err != nil {
-		m.l.Error(err.Error())
+		m.log(ctx).Error(err.Error())
 		return nil, errors.New("query_context")
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		task := &domain.Task{}
-		err := rows.Scan(&task.ID, &task.Title, &task.Description, &task.CreatedAt, &task.UpdatedAt)
+		err := rows.Scan(&task.ID, &task.Title, &task.Description, &task.CreatedAt, &task.UpdatedAt, &task.CompletedAt)
 		if err != nil {
-			m.l.Error(err.Error())
+			m.log(ctx).Error(err.Error())
 			return nil, errors.New("row_data_types")
 		}
 		tasks = append(tasks, task)
 	}
 	if err := rows.Err(); err != nil {
-		m.l.Error(err.Error())
+		m.log(ctx).Error(err.Error())
 		return nil, errors.New("row_corrupt")
 	}
 
@@ -70,46 +107,70 @@ func (m *taskRepository) fetch(ctx context.Context, stmt string, filters []inter
 
 func (m *taskRepository) count(ctx context.Context) (total int, err error) {
 	query := `SELECT count(*) FROM task`
-	rows, err := m.Conn.Query(query)
+	ctx, end := m.start(ctx, "count", query)
+	defer end(&err)
+	rows, err := m.Conn.QueryContext(ctx, query)
 	if err != nil {
-		m.l.Error(err.Error())
+		m.log(ctx).Error(err.Error())
 		return 0, errors.New("query_context")
 	}
 	defer rows.Close()
 	for rows.Next() {
 		err := rows.Scan(&total)
 		if err != nil {
-			m.l.Error(err.Error())
+			m.log(ctx).Error(err.Error())
 			return 0, errors.New("row_data_types")
 		}
 	}
 	if err := rows.Err(); err != nil {
-		m.l.Error(err.Error())
+		m.log(ctx).Error(err.Error())
 		return 0, errors.New("row_corrupt")
 	}
 	return total, nil
 }
 
+func (m *taskRepository) CountByUser(ctx context.Context, userID string) (total int, err error) {
+	query := `SELECT count(*) FROM task WHERE user_id=?`
+	ctx, end := m.start(ctx, "CountByUser", query)
+	defer end(&err)
+	if err := m.Conn.QueryRowContext(ctx, query, userID).Scan(&total); err != nil {
+		m.log(ctx).Error(err.Error())
+		return 0, errors.New("query_context")
+	}
+	return total, nil
+}
 
 func (m *taskRepository) GetByID(ctx context.Context, id string) (t *domain.Task, err error) {
+	ctx, end := m.start(ctx, "GetByID", "")
+	defer end(&err)
 	_, binary_uuid, err := m.parse(id)
-	if err != nil{
+	if err != nil {
 		return nil, err
 	}
 
 	tasks, err := m.fetch(ctx, `WHERE id=? `, []interface{}{binary_uuid})
 	if err != nil {
-		m.l.Error(err.Error())
+		m.log(ctx).Error(err.Error())
 		return nil, err
 	}
 	if len(tasks) == 0 {
-		m.l.Error("Not Found")
+		m.log(ctx).Error("Not Found")
 		return nil, errors.New("not_found")
 	}
 	return tasks[0], nil
 }
 
 func (m *taskRepository) Insert(ctx context.Context, ta *domain.Task) (err error) {
+	query := `INSERT task SET 
+		id=?,
+		title=?, 
+		description=?,
+		created_at=?,
+		updated_at=?,
+		user_id=?`
+	ctx, end := m.start(ctx, "Insert", query)
+	defer end(&err)
+
 	created_at := time.Now()
 	ta.ID = uuid.New()
 	binary_uuid, err := ta.ID.MarshalBinary()
@@ -118,115 +179,296 @@ func (m *taskRepository) Insert(ctx context.Context, ta *domain.Task) (err error
 	}
 	ta.CreatedAt = &created_at
 	ta.UpdatedAt = ta.CreatedAt
-	
-	query := `INSERT task SET 
-		id=?,
-		title=?, 
-		description=?,
-		created_at=?,
-		updated_at=?`
 
-	stmt, err := m.Conn.PrepareContext(ctx, query)
-	if err != nil {
-		m.l.Error(err.Error())
-		return errors.New("query_prepare_ctx")
-	}
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx, query)
+		if err != nil {
+			m.log(ctx).Error(err.Error())
+			return errors.New("query_prepare_ctx")
+		}
 
-	res, err := stmt.ExecContext(ctx,
-		binary_uuid, ta.Title, ta.Description, ta.CreatedAt, ta.UpdatedAt)
-	if err != nil {
-		m.l.Error(err.Error())
-		return errors.New("query_exec")
-	}
-	affect, err := res.RowsAffected()
-	if err != nil {
-		m.l.Error(err.Error())
-		return errors.New("query_exec")
-	}
-	if affect != 1 {
-		m.l.Errorf("Weird  Behavior. Total Affected: %d", affect)
-		return errors.New("conflict_insert")
-	}
-	return
+		res, err := stmt.ExecContext(ctx,
+			binary_uuid, ta.Title, ta.Description, ta.CreatedAt, ta.UpdatedAt, domain.UserFromContext(ctx))
+		if err != nil {
+			m.log(ctx).Error(err.Error())
+			return errors.New("query_exec")
+		}
+		affect, err := res.RowsAffected()
+		if err != nil {
+			m.log(ctx).Error(err.Error())
+			return errors.New("query_exec")
+		}
+		if affect != 1 {
+			m.log(ctx).Errorf("Weird  Behavior. Total Affected: %d", affect)
+			return errors.New("conflict_insert")
+		}
+		return m.saveEvent(ctx, tx, domain.TaskCreated, ta)
+	})
 }
 
 func (m *taskRepository) Update(ctx context.Context, id string, ta *domain.Task) (err error) {
+	query := `UPDATE task set title=?, description=?, updated_at=? WHERE ID = ?`
+	ctx, end := m.start(ctx, "Update", query)
+	defer end(&err)
 	raw_uuid, binary_uuid, err := m.parse(id)
-	if err != nil{
+	if err != nil {
 		return err
 	}
 	updated_at := time.Now()
 	ta.ID = *raw_uuid
 	ta.UpdatedAt = &updated_at
 
-	query := `UPDATE task set title=?, description=?, updated_at=? WHERE ID = ?`
-	stmt, err := m.Conn.PrepareContext(ctx, query)
-	if err != nil {
-		m.l.Error(err.Error())
-		return errors.New("query_prepare_ctx")
-	}
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx, query)
+		if err != nil {
+			m.log(ctx).Error(err.Error())
+			return errors.New("query_prepare_ctx")
+		}
 
-	res, err := stmt.ExecContext(ctx, ta.Title, ta.Description, ta.UpdatedAt, binary_uuid)
+		res, err := stmt.ExecContext(ctx, ta.Title, ta.Description, ta.UpdatedAt, binary_uuid)
+		if err != nil {
+			m.log(ctx).Error(err.Error())
+			return errors.New("query_exec")
+		}
+		affect, err := res.RowsAffected()
+		if err != nil {
+			m.log(ctx).Error(err.Error())
+			return errors.New("not_found")
+		}
+		if affect != 1 {
+			m.log(ctx).Errorf("Weird  Behavior. Total Affected: %d", affect)
+			return errors.New("conflict_update")
+		}
+		return m.saveEvent(ctx, tx, domain.TaskUpdated, ta)
+	})
+}
+
+// Upsert keeps the owner, created_at and completed_at of a task that
+// already exists, and reads them back so a replaced task still reports
+// them. MySQL reports one affected row for an insert, two for an update
+// and none when a replace within the same second changed nothing.
+func (m *taskRepository) Upsert(ctx context.Context, id string, ta *domain.Task) (created bool, err error) {
+	query := `INSERT INTO task (id, title, description, created_at, updated_at, user_id)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+		title=VALUES(title),
+		description=VALUES(description),
+		updated_at=VALUES(updated_at)`
+	ctx, end := m.start(ctx, "Upsert", query)
+	defer end(&err)
+	raw_uuid, binary_uuid, err := m.parse(id)
 	if err != nil {
-		m.l.Error(err.Error())
-		return errors.New("query_exec")
+		return false, err
 	}
-	affect, err := res.RowsAffected()
+	now := time.Now()
+	ta.ID = *raw_uuid
+	ta.UpdatedAt = &now
+	ta.CreatedAt = nil
+
+	var inserted bool
+	err = m.withTx(ctx, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx, query)
+		if err != nil {
+			m.log(ctx).Error(err.Error())
+			return errors.New("query_prepare_ctx")
+		}
+
+		res, err := stmt.ExecContext(ctx,
+			binary_uuid, ta.Title, ta.Description, now, now, domain.UserFromContext(ctx))
+		if err != nil {
+			m.log(ctx).Error(err.Error())
+			return errors.New("query_exec")
+		}
+		affect, err := res.RowsAffected()
+		if err != nil {
+			m.log(ctx).Error(err.Error())
+			return errors.New("query_exec")
+		}
+		switch affect {
+		case 1:
+			inserted = true
+			ta.CreatedAt = &now
+			return m.saveEvent(ctx, tx, domain.TaskCreated, ta)
+		case 0, 2:
+			var created_at time.Time
+			err := tx.QueryRowContext(ctx, `SELECT created_at, completed_at FROM task WHERE id=?`, binary_uuid).
+				Scan(&created_at, &ta.CompletedAt)
+			if err != nil {
+				m.log(ctx).Error(err.Error())
+				return errors.New("query_context")
+			}
+			ta.CreatedAt = &created_at
+			return m.saveEvent(ctx, tx, domain.TaskUpdated, ta)
+		default:
+			m.log(ctx).Errorf("Weird  Behavior. Total Affected: %d", affect)
+			return errors.New("conflict_upsert")
+		}
+	})
 	if err != nil {
-		m.l.Error(err.Error())
-		return errors.New("not_found")
-	}
-	if affect != 1 {
-		m.l.Errorf("Weird  Behavior. Total Affected: %d", affect)
-		return errors.New("conflict_update")
+		return false, err
 	}
-	return
+	return inserted, nil
 }
 
 func (m *taskRepository) Delete(ctx context.Context, id string) (err error) {
 	query := "DELETE FROM task WHERE id=?"
-	_, binary_uuid, err := m.parse(id)
-	if err != nil{
+	ctx, end := m.start(ctx, "Delete", query)
+	defer end(&err)
+	raw_uuid, binary_uuid, err := m.parse(id)
+	if err != nil {
 		return err
 	}
-	
-	stmt, err := m.Conn.PrepareContext(ctx, query)
+
+	return m.withTx(ctx, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx, query)
+		if err != nil {
+			m.log(ctx).Error(err.Error())
+			return errors.New("query_prepare_ctx")
+		}
+
+		res, err := stmt.ExecContext(ctx, binary_uuid)
+		if err != nil {
+			m.log(ctx).Error(err.Error())
+			return errors.New("query_exec")
+		}
+
+		rowsAfected, err := res.RowsAffected()
+		if err != nil {
+			m.log(ctx).Error(err.Error())
+			return errors.New("query_exec_delete")
+		}
+
+		if rowsAfected != 1 {
+			m.log(ctx).Errorf("Weird  Behavior. Total Affected: %d", rowsAfected)
+			return errors.New("conflict_delete")
+		}
+		return m.saveEvent(ctx, tx, domain.TaskDeleted, &domain.Task{ID: *raw_uuid})
+	})
+}
+
+// Complete locks the row so two concurrent calls record a single
+// completion and a single event.
+func (m *taskRepository) Complete(ctx context.Context, id string) (t *domain.Task, completed bool, err error) {
+	query := `UPDATE task SET completed_at=?, updated_at=? WHERE id=?`
+	ctx, end := m.start(ctx, "Complete", query)
+	defer end(&err)
+	_, binary_uuid, err := m.parse(id)
 	if err != nil {
-		m.l.Error(err.Error())
-		return errors.New("query_prepare_ctx")
+		return nil, false, err
 	}
 
-	res, err := stmt.ExecContext(ctx, binary_uuid)
+	err = m.withTx(ctx, func(tx *sql.Tx) error {
+		t = &domain.Task{}
+		err := tx.QueryRowContext(ctx,
+			`SELECT id, title, description, created_at, updated_at, completed_at FROM task WHERE id=? FOR UPDATE`,
+			binary_uuid).Scan(&t.ID, &t.Title, &t.Description, &t.CreatedAt, &t.UpdatedAt, &t.CompletedAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("not_found")
+		}
+		if err != nil {
+			m.log(ctx).Error(err.Error())
+			return errors.New("query_context")
+		}
+		if t.CompletedAt != nil {
+			return nil
+		}
+
+		now := time.Now()
+		if _, err := tx.ExecContext(ctx, query, now, now, binary_uuid); err != nil {
+			m.log(ctx).Error(err.Error())
+			return errors.New("query_exec")
+		}
+		t.CompletedAt = &now
+		t.UpdatedAt = &now
+		completed = true
+		return m.saveEvent(ctx, tx, domain.TaskCompleted, t)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return t, completed, nil
+}
+
+// withTx runs fn inside a transaction so the task change and its outbox
+// event are committed or discarded together.
+func (m *taskRepository) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := m.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		m.log(ctx).Error(err.Error())
+		return errors.New("tx_begin")
+	}
+	if err := fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			m.log(ctx).Error(rbErr.Error())
+		}
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		m.log(ctx).Error(err.Error())
+		return errors.New("tx_commit")
+	}
+	return nil
+}
+
+// saveEvent numbers the event in the change sequence read by sync. Unlike
+// the AUTO_INCREMENT seq, taken at insert time, the number comes from a
+// counter row that stays locked until the transaction ends, so numbers
+// become visible in order and a reader never skips one still uncommitted.
+// Keep it the last statement of the transaction to hold the lock briefly.
+func (m *taskRepository) saveEvent(ctx context.Context, tx *sql.Tx, eventType string, ta *domain.Task) error {
+	event, err := domain.NewEvent(eventType, ta)
 	if err != nil {
-		m.l.Error(err.Error())
-		return errors.New("query_exec")
+		m.log(ctx).Error(err.Error())
+		return errors.New("event_encode")
 	}
+	event_uuid, _ := event.ID.MarshalBinary()
+	task_uuid, _ := event.TaskID.MarshalBinary()
 
-	rowsAfected, err := res.RowsAffected()
+	res, err := tx.ExecContext(ctx, `UPDATE task_change_seq SET seq=LAST_INSERT_ID(seq+1) WHERE id=1`)
 	if err != nil {
-		m.l.Error(err.Error())
-		return errors.New("query_exec_delete")
+		m.log(ctx).Error(err.Error())
+		return errors.New("change_seq")
+	}
+	change_seq, err := res.LastInsertId()
+	if err != nil {
+		m.log(ctx).Error(err.Error())
+		return errors.New("change_seq")
 	}
 
-	if rowsAfected != 1 {
-		m.l.Errorf("Weird  Behavior. Total Affected: %d", rowsAfected)
-		return errors.New("conflict_delete")
+	query := `INSERT task_outbox SET
+		id=?,
+		event_type=?,
+		task_id=?,
+		payload=?,
+		created_at=?,
+		change_seq=?,
+		user_id=?`
+	_, err = tx.ExecContext(ctx, query,
+		event_uuid, event.Type, task_uuid, []byte(event.Payload), event.CreatedAt, change_seq,
+		domain.UserFromContext(ctx))
+	if err != nil {
+		m.log(ctx).Error(err.Error())
+		return errors.New("outbox_insert")
 	}
-	return
+	return nil
 }
 
 func (m *taskRepository) parse(id string) (*uuid.UUID, []byte, error) {
+	return parseUUID(m.l, id)
+}
+
+func parseUUID(l *zap.SugaredLogger, id string) (*uuid.UUID, []byte, error) {
 	raw_uuid, err := uuid.Parse(id)
 	if err != nil {
-		m.l.Error(err.Error())
-		return nil,nil, errors.New("uuid_format")
+		l.Error(err.Error())
+		return nil, nil, errors.New("uuid_format")
 	}
 
 	binary_uuid, err := raw_uuid.MarshalBinary()
 	if err != nil {
-		m.l.Error(err.Error())
+		l.Error(err.Error())
 		return nil, nil, errors.New("uuid_format")
 	}
 
 	return &raw_uuid, binary_uuid, err
-}
\ No newline at end of file
+}
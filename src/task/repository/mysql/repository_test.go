@@ -5,11 +5,18 @@ import (
 	"database/sql"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/isaias-dgr/todo/src/domain"
 	"github.com/isaias-dgr/todo/src/task/repository/mysql"
 	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
 )
@@ -35,6 +42,14 @@ func (s *SuiteRepository) SetupTest() {
 	s.repo = mysql.NewtaskRepository(db, sugar)
 }
 
+// changeSeq is the number the counter of the change sequence hands out.
+const changeSeq = int64(7)
+
+func (s *SuiteRepository) expectChangeSeq() {
+	s.mockSQL.ExpectExec("UPDATE task_change_seq SET seq=LAST_INSERT_ID\\(seq\\+1\\) WHERE id=1").
+		WillReturnResult(sqlmock.NewResult(changeSeq, 1))
+}
+
 func (s *SuiteRepository) TestFetch() {
 
 	s.Run("Success test", func(){
@@ -44,14 +59,14 @@ func (s *SuiteRepository) TestFetch() {
 		}
 
 		binary_uuid, _ := uuid.New().MarshalBinary()
-		rows := []string{"id", "title", "description", "updated_at", "created_at"}
+		rows := []string{"id", "title", "description", "updated_at", "created_at", "completed_at"}
 		data := sqlmock.NewRows(rows).
 			AddRow(binary_uuid, mockTask[0].Title, mockTask[0].Description,
-				mockTask[0].CreatedAt, mockTask[0].UpdatedAt).
+				mockTask[0].CreatedAt, mockTask[0].UpdatedAt, nil).
 			AddRow(binary_uuid, mockTask[1].Title, mockTask[1].Description,
-				mockTask[1].CreatedAt, mockTask[1].UpdatedAt)
+				mockTask[1].CreatedAt, mockTask[1].UpdatedAt, nil)
 
-		q := "SELECT \\* FROM task ORDER BY created_at ASC LIMIT \\? OFFSET \\?"
+		q := "SELECT id, title, description, created_at, updated_at, completed_at FROM task ORDER BY created_at ASC LIMIT \\? OFFSET \\?"
 		s.mockSQL.ExpectQuery(q).WithArgs(3, 0).WillReturnRows(data)
 
 		query_count := "SELECT count\\(\\*\\) FROM task"
@@ -73,7 +88,7 @@ func (s *SuiteRepository) TestFetch() {
 	})
 
 	s.Run("When exec query fails must return error", func(){
-		q := "SELECT \\* FROM task ORDER BY created_at ASC LIMIT \\? OFFSET \\?"
+		q := "SELECT id, title, description, created_at, updated_at, completed_at FROM task ORDER BY created_at ASC LIMIT \\? OFFSET \\?"
 		s.mockSQL.ExpectQuery(q).WithArgs(3, 0).WillReturnError(errors.New("D error"))
 		filter := &domain.Filter{
 			Offset: 0,
@@ -87,9 +102,9 @@ func (s *SuiteRepository) TestFetch() {
 	})
 
 	s.Run("When db return incorrect type data", func(){
-		rows := []string{"id", "title", "description", "updated_at", "created_at"}
-		data := sqlmock.NewRows(rows).AddRow("uuid",  "T", "D", "C", "U")
-		q := "SELECT \\* FROM task ORDER BY created_at ASC LIMIT \\? OFFSET \\?"
+		rows := []string{"id", "title", "description", "updated_at", "created_at", "completed_at"}
+		data := sqlmock.NewRows(rows).AddRow("uuid",  "T", "D", "C", "U", nil)
+		q := "SELECT id, title, description, created_at, updated_at, completed_at FROM task ORDER BY created_at ASC LIMIT \\? OFFSET \\?"
 		s.mockSQL.ExpectQuery(q).WithArgs(3, 0).WillReturnRows(data)
 
 		filter := &domain.Filter{
@@ -110,15 +125,15 @@ func (s *SuiteRepository) TestFetch() {
 		}
 
 		binary_uuid, _ := uuid.New().MarshalBinary()
-		rows := []string{"id", "title", "description", "updated_at", "created_at"}
+		rows := []string{"id", "title", "description", "updated_at", "created_at", "completed_at"}
 		data := sqlmock.NewRows(rows).
 			AddRow(binary_uuid, mockTask[0].Title, mockTask[0].Description,
-				mockTask[0].CreatedAt, mockTask[0].UpdatedAt).
+				mockTask[0].CreatedAt, mockTask[0].UpdatedAt, nil).
 			AddRow(binary_uuid, mockTask[1].Title, mockTask[1].Description,
-				mockTask[1].CreatedAt, mockTask[1].UpdatedAt).
+				mockTask[1].CreatedAt, mockTask[1].UpdatedAt, nil).
 			RowError(1, errors.New("row_error"))
 
-		q := "SELECT \\* FROM task ORDER BY created_at ASC LIMIT \\? OFFSET \\?"
+		q := "SELECT id, title, description, created_at, updated_at, completed_at FROM task ORDER BY created_at ASC LIMIT \\? OFFSET \\?"
 		s.mockSQL.ExpectQuery(q).WithArgs(3, 0).WillReturnRows(data)
 
 		filter := &domain.Filter{
@@ -138,14 +153,14 @@ func (s *SuiteRepository) TestFetch() {
 		}
 
 		binary_uuid, _ := uuid.New().MarshalBinary()
-		rows := []string{"id", "title", "description", "updated_at", "created_at"}
+		rows := []string{"id", "title", "description", "updated_at", "created_at", "completed_at"}
 		data := sqlmock.NewRows(rows).
 			AddRow(binary_uuid, mockTask[0].Title, mockTask[0].Description,
-				mockTask[0].CreatedAt, mockTask[0].UpdatedAt).
+				mockTask[0].CreatedAt, mockTask[0].UpdatedAt, nil).
 			AddRow(binary_uuid, mockTask[1].Title, mockTask[1].Description,
-				mockTask[1].CreatedAt, mockTask[1].UpdatedAt)
+				mockTask[1].CreatedAt, mockTask[1].UpdatedAt, nil)
 
-		q := "SELECT \\* FROM task ORDER BY created_at ASC LIMIT \\? OFFSET \\?"
+		q := "SELECT id, title, description, created_at, updated_at, completed_at FROM task ORDER BY created_at ASC LIMIT \\? OFFSET \\?"
 		s.mockSQL.ExpectQuery(q).WithArgs(3, 0).WillReturnRows(data)
 
 		query_count := "SELECT count\\(\\*\\) FROM task"
@@ -171,14 +186,14 @@ func (s *SuiteRepository) TestFetch() {
 		}
 
 		binary_uuid, _ := uuid.New().MarshalBinary()
-		rows := []string{"id", "title", "description", "updated_at", "created_at"}
+		rows := []string{"id", "title", "description", "updated_at", "created_at", "completed_at"}
 		data := sqlmock.NewRows(rows).
 			AddRow(binary_uuid, mockTask[0].Title, mockTask[0].Description,
-				mockTask[0].CreatedAt, mockTask[0].UpdatedAt).
+				mockTask[0].CreatedAt, mockTask[0].UpdatedAt, nil).
 			AddRow(binary_uuid, mockTask[1].Title, mockTask[1].Description,
-				mockTask[1].CreatedAt, mockTask[1].UpdatedAt)
+				mockTask[1].CreatedAt, mockTask[1].UpdatedAt, nil)
 
-		q := "SELECT \\* FROM task ORDER BY created_at ASC LIMIT \\? OFFSET \\?"
+		q := "SELECT id, title, description, created_at, updated_at, completed_at FROM task ORDER BY created_at ASC LIMIT \\? OFFSET \\?"
 		s.mockSQL.ExpectQuery(q).WithArgs(3, 0).WillReturnRows(data)
 
 		query_count := "SELECT count\\(\\*\\) FROM task"
@@ -203,14 +218,14 @@ func (s *SuiteRepository) TestFetch() {
 		}
 
 		binary_uuid, _ := uuid.New().MarshalBinary()
-		rows := []string{"id", "title", "description", "updated_at", "created_at"}
+		rows := []string{"id", "title", "description", "updated_at", "created_at", "completed_at"}
 		data := sqlmock.NewRows(rows).
 			AddRow(binary_uuid, mockTask[0].Title, mockTask[0].Description,
-				mockTask[0].CreatedAt, mockTask[0].UpdatedAt).
+				mockTask[0].CreatedAt, mockTask[0].UpdatedAt, nil).
 			AddRow(binary_uuid, mockTask[1].Title, mockTask[1].Description,
-				mockTask[1].CreatedAt, mockTask[1].UpdatedAt)
+				mockTask[1].CreatedAt, mockTask[1].UpdatedAt, nil)
 
-		q := "SELECT \\* FROM task ORDER BY created_at ASC LIMIT \\? OFFSET \\?"
+		q := "SELECT id, title, description, created_at, updated_at, completed_at FROM task ORDER BY created_at ASC LIMIT \\? OFFSET \\?"
 		s.mockSQL.ExpectQuery(q).WithArgs(3, 0).WillReturnRows(data)
 
 		query_count := "SELECT count\\(\\*\\) FROM task"
@@ -236,12 +251,12 @@ func (s *SuiteRepository) TestGetByID() {
 		mockTask := domain.NewTask("title test 01", "description test 01")
 		raw_uuid := uuid.New()
 		binary_uuid, _ := raw_uuid.MarshalBinary()
-		rows := []string{"id", "title", "description", "updated_at", "created_at"}
+		rows := []string{"id", "title", "description", "updated_at", "created_at", "completed_at"}
 		data := sqlmock.NewRows(rows).
 			AddRow(binary_uuid, mockTask.Title, mockTask.Description,
-				mockTask.CreatedAt, mockTask.UpdatedAt)
+				mockTask.CreatedAt, mockTask.UpdatedAt, nil)
 
-		q := "SELECT \\* FROM task WHERE id=\\? "
+		q := "SELECT id, title, description, created_at, updated_at, completed_at FROM task WHERE id=\\? "
 		s.mockSQL.ExpectQuery(q).WithArgs(binary_uuid).WillReturnRows(data)
 
 		task, err := s.repo.GetByID(context.TODO(), raw_uuid.String())
@@ -260,7 +275,7 @@ func (s *SuiteRepository) TestGetByID() {
 		raw_uuid := uuid.New()
 		binary_uuid, _ := raw_uuid.MarshalBinary()
 
-		q := "SELECT \\* FROM task WHERE id=\\? "
+		q := "SELECT id, title, description, created_at, updated_at, completed_at FROM task WHERE id=\\? "
 		s.mockSQL.ExpectQuery(q).WithArgs(binary_uuid).WillReturnError(errors.New("generic error"))
 
 		task, err := s.repo.GetByID(context.TODO(), raw_uuid.String())
@@ -272,10 +287,10 @@ func (s *SuiteRepository) TestGetByID() {
 	s.Run("When the query not found task", func() {
 		raw_uuid := uuid.New()
 		binary_uuid, _ := raw_uuid.MarshalBinary()
-		rows := []string{"title", "description", "updated_at", "created_at"}
+		rows := []string{"title", "description", "updated_at", "created_at", "completed_at"}
 		data := sqlmock.NewRows(rows)
 
-		q := "SELECT \\* FROM task WHERE id=\\? "
+		q := "SELECT id, title, description, created_at, updated_at, completed_at FROM task WHERE id=\\? "
 		s.mockSQL.ExpectQuery(q).WithArgs(binary_uuid).WillReturnRows(data)
 
 		task, err := s.repo.GetByID(context.TODO(), raw_uuid.String())
@@ -286,26 +301,46 @@ func (s *SuiteRepository) TestGetByID() {
 }
 
 func (s *SuiteRepository) TestInsert() {
+	q := "INSERT task SET id=\\?, title=\\?, description=\\?, created_at=\\?, updated_at=\\?, user_id=\\?"
+	qOutbox := "INSERT task_outbox SET id=\\?, event_type=\\?, task_id=\\?, payload=\\?, created_at=\\?"
+
 	s.Run("Success test return a task", func() {
 		task := domain.NewTask("Title new", "Description new")
-		q := "INSERT task SET id=\\?, title=\\?, description=\\?, created_at=\\?, updated_at=\\?"
+		s.mockSQL.ExpectBegin()
 		s.mockSQL.
 			ExpectPrepare(q).
 			ExpectExec().
 			WithArgs(sqlmock.AnyArg(), task.Title, task.Description,
-				sqlmock.AnyArg(), sqlmock.AnyArg()).
+				sqlmock.AnyArg(), sqlmock.AnyArg(), "").
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		s.expectChangeSeq()
+		s.mockSQL.ExpectExec(qOutbox).
+			WithArgs(sqlmock.AnyArg(), domain.TaskCreated, sqlmock.AnyArg(),
+				sqlmock.AnyArg(), sqlmock.AnyArg(), changeSeq, "").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		s.mockSQL.ExpectCommit()
 
 		err := s.repo.Insert(context.TODO(),task)
 		s.Nil(err)
+		s.NoError(s.mockSQL.ExpectationsWereMet())
+	})
+
+	s.Run("When the transaction can not begin must return error", func() {
+		task := domain.NewTask("Title new", "Description new")
+		s.mockSQL.ExpectBegin().WillReturnError(errors.New("begin error"))
+
+		err := s.repo.Insert(context.TODO(), task)
+		s.Error(err)
+		s.Equal("tx_begin", err.Error())
 	})
 
 	s.Run("When the prepare context faild must return error", func() {
 		task := domain.NewTask("Title new", "Description new")
-		q := "INSERT task SET id=\\?, title=\\?, description=\\?, created_at=\\?, updated_at=\\?"
+		s.mockSQL.ExpectBegin()
 		s.mockSQL.
 			ExpectPrepare(q).
 			WillReturnError(errors.New("prepare error"))
+		s.mockSQL.ExpectRollback()
 
 		err := s.repo.Insert(context.TODO(),task)
 		s.Error(err)
@@ -315,13 +350,14 @@ func (s *SuiteRepository) TestInsert() {
 	s.Run("When the Exec stmt faild must return error", func() {
 		task := domain.NewTask("Title new", "Description new")
 
-		q := "INSERT task SET id=\\?, title=\\?, description=\\?, created_at=\\?, updated_at=\\?"
+		s.mockSQL.ExpectBegin()
 		s.mockSQL.
 			ExpectPrepare(q).
 			ExpectExec().
 			WithArgs(sqlmock.AnyArg(), task.Title, task.Description,
-				sqlmock.AnyArg(), sqlmock.AnyArg()).
+				sqlmock.AnyArg(), sqlmock.AnyArg(), "").
 			WillReturnError(errors.New("exec error"))
+		s.mockSQL.ExpectRollback()
 
 		err := s.repo.Insert(context.TODO(),task)
 		s.Error(err)
@@ -331,11 +367,12 @@ func (s *SuiteRepository) TestInsert() {
 	s.Run("When the Exec result send error must return error", func() {
 		task := domain.NewTask("title test 01", "description test 01")
 
-		q := "INSERT task SET id=\\?, title=\\?, description=\\?, created_at=\\?, updated_at=\\?"
+		s.mockSQL.ExpectBegin()
 		s.mockSQL.ExpectPrepare(q).
 			ExpectExec().
-			WithArgs(sqlmock.AnyArg(), task.Title, task.Description, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WithArgs(sqlmock.AnyArg(), task.Title, task.Description, sqlmock.AnyArg(), sqlmock.AnyArg(), "").
 			WillReturnResult(sqlmock.NewErrorResult(errors.New("not_found")))
+		s.mockSQL.ExpectRollback()
 
 		err := s.repo.Insert(context.TODO(),task)
 		s.NotNil(err)
@@ -345,32 +382,90 @@ func (s *SuiteRepository) TestInsert() {
 	s.Run("When the Exec insert more than one task must return error", func() {
 		task := domain.NewTask("title test 01", "description test 01")
 
-		q := "INSERT task SET id=\\?, title=\\?, description=\\?, created_at=\\?, updated_at=\\?"
+		s.mockSQL.ExpectBegin()
 		s.mockSQL.ExpectPrepare(q).
 			ExpectExec().
-			WithArgs(sqlmock.AnyArg(), task.Title, task.Description, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WithArgs(sqlmock.AnyArg(), task.Title, task.Description, sqlmock.AnyArg(), sqlmock.AnyArg(), "").
 			WillReturnResult(sqlmock.NewResult(1, 2))
+		s.mockSQL.ExpectRollback()
 		err := s.repo.Insert(context.TODO(),task)
 		s.NotNil(err)
 		s.Equal("conflict_insert", err.Error())
 	})
+
+	s.Run("When the outbox insert fails the task is rolled back", func() {
+		task := domain.NewTask("title test 01", "description test 01")
+
+		s.mockSQL.ExpectBegin()
+		s.mockSQL.ExpectPrepare(q).
+			ExpectExec().
+			WithArgs(sqlmock.AnyArg(), task.Title, task.Description, sqlmock.AnyArg(), sqlmock.AnyArg(), "").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		s.expectChangeSeq()
+		s.mockSQL.ExpectExec(qOutbox).WillReturnError(errors.New("outbox error"))
+		s.mockSQL.ExpectRollback()
+		err := s.repo.Insert(context.TODO(), task)
+		s.NotNil(err)
+		s.Equal("outbox_insert", err.Error())
+		s.NoError(s.mockSQL.ExpectationsWereMet())
+	})
+
+	s.Run("When the change sequence can not be advanced the task is rolled back", func() {
+		task := domain.NewTask("title test 01", "description test 01")
+
+		s.mockSQL.ExpectBegin()
+		s.mockSQL.ExpectPrepare(q).
+			ExpectExec().
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		s.mockSQL.ExpectExec("UPDATE task_change_seq").WillReturnError(errors.New("lock wait timeout"))
+		s.mockSQL.ExpectRollback()
+		err := s.repo.Insert(context.TODO(), task)
+		s.EqualError(err, "change_seq")
+		s.NoError(s.mockSQL.ExpectationsWereMet())
+	})
+
+	s.Run("When the commit fails must return error", func() {
+		task := domain.NewTask("title test 01", "description test 01")
+
+		s.mockSQL.ExpectBegin()
+		s.mockSQL.ExpectPrepare(q).
+			ExpectExec().
+			WithArgs(sqlmock.AnyArg(), task.Title, task.Description, sqlmock.AnyArg(), sqlmock.AnyArg(), "").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		s.expectChangeSeq()
+		s.mockSQL.ExpectExec(qOutbox).WillReturnResult(sqlmock.NewResult(1, 1))
+		s.mockSQL.ExpectCommit().WillReturnError(errors.New("commit error"))
+		err := s.repo.Insert(context.TODO(), task)
+		s.NotNil(err)
+		s.Equal("tx_commit", err.Error())
+	})
 }
 
 func (s *SuiteRepository) TestUpdate() {
+	q := "UPDATE task set title=\\?, description=\\?, updated_at=\\? WHERE ID = \\?"
+	qOutbox := "INSERT task_outbox SET id=\\?, event_type=\\?, task_id=\\?, payload=\\?, created_at=\\?"
+
 	s.Run("Success test return a task", func() {
 		task := domain.NewTask("title test 01", "description test 01")
 		raw_uuid := uuid.New()
 		binary_uuid, _ := raw_uuid.MarshalBinary()
 
-		q := "UPDATE task set title=\\?, description=\\?, updated_at=\\? WHERE ID = \\?"
+		s.mockSQL.ExpectBegin()
 		s.mockSQL.
 			ExpectPrepare(q).
 			ExpectExec().
 			WithArgs(task.Title, task.Description, sqlmock.AnyArg(), binary_uuid).
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		s.expectChangeSeq()
+		s.mockSQL.ExpectExec(qOutbox).
+			WithArgs(sqlmock.AnyArg(), domain.TaskUpdated, binary_uuid,
+				sqlmock.AnyArg(), sqlmock.AnyArg(), changeSeq, "").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		s.mockSQL.ExpectCommit()
 
 		err := s.repo.Update(context.TODO(), raw_uuid.String(), task)
 		s.Nil(err)
+		s.NoError(s.mockSQL.ExpectationsWereMet())
 	})
 
 	s.Run("When test uuid without format return error", func() {
@@ -384,8 +479,9 @@ func (s *SuiteRepository) TestUpdate() {
 		task := domain.NewTask("title test 01", "description test 01")
 		raw_uuid := uuid.New()
 
-		q := "UPDATE task set title=\\?, description=\\?, updated_at=\\? WHERE ID = \\?"
+		s.mockSQL.ExpectBegin()
 		s.mockSQL.ExpectPrepare(q).WillReturnError(errors.New("prepare error"))
+		s.mockSQL.ExpectRollback()
 		err := s.repo.Update(context.TODO(), raw_uuid.String(), task)
 		s.NotNil(err)
 		s.Equal("query_prepare_ctx", err.Error())
@@ -396,11 +492,12 @@ func (s *SuiteRepository) TestUpdate() {
 		raw_uuid := uuid.New()
 		binary_uuid, _ := raw_uuid.MarshalBinary()
 
-		q := "UPDATE task set title=\\?, description=\\?, updated_at=\\? WHERE ID = \\?"
+		s.mockSQL.ExpectBegin()
 		s.mockSQL.ExpectPrepare(q).
 			ExpectExec().
 			WithArgs(task.Title, task.Description, sqlmock.AnyArg(), binary_uuid).
 			WillReturnError(errors.New("exec error"))
+		s.mockSQL.ExpectRollback()
 		err := s.repo.Update(context.TODO(), raw_uuid.String(), task)
 		s.NotNil(err)
 		s.Equal("query_exec", err.Error())
@@ -411,11 +508,12 @@ func (s *SuiteRepository) TestUpdate() {
 		raw_uuid := uuid.New()
 		binary_uuid, _ := raw_uuid.MarshalBinary()
 
-		q := "UPDATE task set title=\\?, description=\\?, updated_at=\\? WHERE ID = \\?"
+		s.mockSQL.ExpectBegin()
 		s.mockSQL.ExpectPrepare(q).
 			ExpectExec().
 			WithArgs(task.Title, task.Description, sqlmock.AnyArg(), binary_uuid).
 			WillReturnResult(sqlmock.NewErrorResult(errors.New("not_found")))
+		s.mockSQL.ExpectRollback()
 		err := s.repo.Update(context.TODO(), raw_uuid.String(), task)
 		s.NotNil(err)
 		s.Equal("not_found", err.Error())
@@ -426,29 +524,127 @@ func (s *SuiteRepository) TestUpdate() {
 		raw_uuid := uuid.New()
 		binary_uuid, _ := raw_uuid.MarshalBinary()
 
-		q := "UPDATE task set title=\\?, description=\\?, updated_at=\\? WHERE ID = \\?"
+		s.mockSQL.ExpectBegin()
 		s.mockSQL.ExpectPrepare(q).
 			ExpectExec().
 			WithArgs(task.Title, task.Description, sqlmock.AnyArg(), binary_uuid).
 			WillReturnResult(sqlmock.NewResult(1, 2))
+		s.mockSQL.ExpectRollback()
 		err := s.repo.Update(context.TODO(), raw_uuid.String(), task)
 		s.NotNil(err)
 		s.Equal("conflict_update", err.Error())
 	})
 }
 
+func (s *SuiteRepository) TestUpsert() {
+	q := "INSERT INTO task \\(id, title, description, created_at, updated_at, user_id\\) VALUES \\(\\?, \\?, \\?, \\?, \\?, \\?\\) ON DUPLICATE KEY UPDATE"
+	qOutbox := "INSERT task_outbox SET id=\\?, event_type=\\?, task_id=\\?, payload=\\?, created_at=\\?"
+
+	for _, tc := range []struct {
+		name    string
+		affect  int64
+		created bool
+		event   string
+	}{
+		{"When the task is new it is created", 1, true, domain.TaskCreated},
+		{"When the task exists it is replaced", 2, false, domain.TaskUpdated},
+		{"When nothing changed it is replaced", 0, false, domain.TaskUpdated},
+	} {
+		createdAt := time.Date(2021, 10, 1, 9, 0, 0, 0, time.UTC)
+		completedAt := time.Date(2021, 10, 2, 9, 0, 0, 0, time.UTC)
+		s.Run(tc.name, func() {
+			task := domain.NewTask("title test 01", "description test 01")
+			raw_uuid := uuid.New()
+			binary_uuid, _ := raw_uuid.MarshalBinary()
+
+			s.mockSQL.ExpectBegin()
+			s.mockSQL.ExpectPrepare(q).
+				ExpectExec().
+				WithArgs(binary_uuid, task.Title, task.Description, sqlmock.AnyArg(), sqlmock.AnyArg(), "user-1").
+				WillReturnResult(sqlmock.NewResult(0, tc.affect))
+			if !tc.created {
+				s.mockSQL.ExpectQuery("SELECT created_at, completed_at FROM task WHERE id=\\?").
+					WithArgs(binary_uuid).
+					WillReturnRows(sqlmock.NewRows([]string{"created_at", "completed_at"}).AddRow(createdAt, completedAt))
+			}
+			s.expectChangeSeq()
+			s.mockSQL.ExpectExec(qOutbox).
+				WithArgs(sqlmock.AnyArg(), tc.event, binary_uuid, sqlmock.AnyArg(), sqlmock.AnyArg(), changeSeq, "user-1").
+				WillReturnResult(sqlmock.NewResult(1, 1))
+			s.mockSQL.ExpectCommit()
+
+			ctx := domain.WithUser(context.TODO(), "user-1")
+			created, err := s.repo.Upsert(ctx, raw_uuid.String(), task)
+			s.NoError(err)
+			s.Equal(tc.created, created)
+			s.Equal(raw_uuid, task.ID)
+			s.Require().NotNil(task.CreatedAt)
+			if !tc.created {
+				s.Equal(createdAt, *task.CreatedAt, "a replaced task keeps created_at")
+				s.Equal(completedAt, *task.CompletedAt, "a replaced task keeps completed_at")
+			}
+			s.NoError(s.mockSQL.ExpectationsWereMet())
+		})
+	}
+
+	s.Run("When the Exec stmt faild must return error", func() {
+		task := domain.NewTask("title test 01", "description test 01")
+		raw_uuid := uuid.New()
+
+		s.mockSQL.ExpectBegin()
+		s.mockSQL.ExpectPrepare(q).
+			ExpectExec().
+			WillReturnError(errors.New("exec error"))
+		s.mockSQL.ExpectRollback()
+		created, err := s.repo.Upsert(context.TODO(), raw_uuid.String(), task)
+		s.False(created)
+		s.EqualError(err, "query_exec")
+	})
+
+	s.Run("When created_at can not be read back must return error", func() {
+		task := domain.NewTask("title test 01", "description test 01")
+		raw_uuid := uuid.New()
+
+		s.mockSQL.ExpectBegin()
+		s.mockSQL.ExpectPrepare(q).
+			ExpectExec().
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		s.mockSQL.ExpectQuery("SELECT created_at, completed_at FROM task WHERE id=\\?").
+			WillReturnError(errors.New("query error"))
+		s.mockSQL.ExpectRollback()
+		_, err := s.repo.Upsert(context.TODO(), raw_uuid.String(), task)
+		s.EqualError(err, "query_context")
+		s.NoError(s.mockSQL.ExpectationsWereMet())
+	})
+
+	s.Run("When test uuid without format return error", func() {
+		_, err := s.repo.Upsert(context.TODO(), "00000000", domain.NewTask("title", "description"))
+		s.EqualError(err, "uuid_format")
+	})
+}
+
 func (s *SuiteRepository) TestDelete() {
+	q := "DELETE FROM task WHERE id=\\?"
+	qOutbox := "INSERT task_outbox SET id=\\?, event_type=\\?, task_id=\\?, payload=\\?, created_at=\\?"
+
 	s.Run("Success test return a task", func() {
 		raw_uuid := uuid.New()
 		binary_uuid, _ := raw_uuid.MarshalBinary()
-		q := "DELETE FROM task WHERE id=\\?"
+		s.mockSQL.ExpectBegin()
 		s.mockSQL.
 			ExpectPrepare(q).
 			ExpectExec().
 			WithArgs(binary_uuid).
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		s.expectChangeSeq()
+		s.mockSQL.ExpectExec(qOutbox).
+			WithArgs(sqlmock.AnyArg(), domain.TaskDeleted, binary_uuid,
+				sqlmock.AnyArg(), sqlmock.AnyArg(), changeSeq, "").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		s.mockSQL.ExpectCommit()
 		err := s.repo.Delete(context.TODO(), raw_uuid.String())
 		s.Nil(err)
+		s.NoError(s.mockSQL.ExpectationsWereMet())
 	})
 
 	s.Run("When test uuid without format return error", func() {
@@ -459,8 +655,9 @@ func (s *SuiteRepository) TestDelete() {
 
 	s.Run("When the prepare context faild must return error", func() {
 		raw_uuid := uuid.New()
-		q := "DELETE FROM task WHERE id=\\?"
+		s.mockSQL.ExpectBegin()
 		s.mockSQL.ExpectPrepare(q).WillReturnError(errors.New("prepare error"))
+		s.mockSQL.ExpectRollback()
 		err := s.repo.Delete(context.TODO(), raw_uuid.String())
 		s.Error(err)
 		s.Equal("query_prepare_ctx", err.Error())
@@ -470,11 +667,12 @@ func (s *SuiteRepository) TestDelete() {
 		raw_uuid := uuid.New()
 		binary_uuid, _ := raw_uuid.MarshalBinary()
 
-		q := "DELETE FROM task WHERE id=\\?"
+		s.mockSQL.ExpectBegin()
 		s.mockSQL.ExpectPrepare(q).
 			ExpectExec().
 			WithArgs(binary_uuid).
 			WillReturnError(errors.New("exec error"))
+		s.mockSQL.ExpectRollback()
 		err := s.repo.Delete(context.TODO(), raw_uuid.String())
 		s.NotNil(err)
 		s.Equal("query_exec", err.Error())
@@ -484,11 +682,12 @@ func (s *SuiteRepository) TestDelete() {
 		raw_uuid := uuid.New()
 		binary_uuid, _ := raw_uuid.MarshalBinary()
 
-		q := "DELETE FROM task WHERE id=\\?"
+		s.mockSQL.ExpectBegin()
 		s.mockSQL.ExpectPrepare(q).
 			ExpectExec().
 			WithArgs(binary_uuid).
 			WillReturnResult(sqlmock.NewErrorResult(errors.New("not_found")))
+		s.mockSQL.ExpectRollback()
 		err := s.repo.Delete(context.TODO(), raw_uuid.String())
 		s.NotNil(err)
 		s.Equal("query_exec_delete", err.Error())
@@ -498,17 +697,177 @@ func (s *SuiteRepository) TestDelete() {
 		raw_uuid := uuid.New()
 		binary_uuid, _ := raw_uuid.MarshalBinary()
 
-		q := "DELETE FROM task WHERE id=\\?"
+		s.mockSQL.ExpectBegin()
 		s.mockSQL.ExpectPrepare(q).
 			ExpectExec().
 			WithArgs(binary_uuid).
 			WillReturnResult(sqlmock.NewResult(1, 2))
+		s.mockSQL.ExpectRollback()
 		err := s.repo.Delete(context.TODO(), raw_uuid.String())
 		s.NotNil(err)
 		s.Equal("conflict_delete", err.Error())
 	})
 }
 
+func (s *SuiteRepository) TestComplete() {
+	qSelect := "SELECT id, title, description, created_at, updated_at, completed_at FROM task WHERE id=\\? FOR UPDATE"
+	q := "UPDATE task SET completed_at=\\?, updated_at=\\? WHERE id=\\?"
+	qOutbox := "INSERT task_outbox SET id=\\?, event_type=\\?, task_id=\\?, payload=\\?, created_at=\\?"
+	columns := []string{"id", "title", "description", "created_at", "updated_at", "completed_at"}
+	createdAt := time.Date(2021, 10, 1, 9, 0, 0, 0, time.UTC)
+
+	s.Run("When the task is open it is completed once", func() {
+		raw_uuid := uuid.New()
+		binary_uuid, _ := raw_uuid.MarshalBinary()
+		s.mockSQL.ExpectBegin()
+		s.mockSQL.ExpectQuery(qSelect).WithArgs(binary_uuid).WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(binary_uuid, "title", "description", createdAt, createdAt, nil))
+		s.mockSQL.ExpectExec(q).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), binary_uuid).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		s.expectChangeSeq()
+		s.mockSQL.ExpectExec(qOutbox).
+			WithArgs(sqlmock.AnyArg(), domain.TaskCompleted, binary_uuid, sqlmock.AnyArg(), sqlmock.AnyArg(), changeSeq, "").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		s.mockSQL.ExpectCommit()
+
+		task, completed, err := s.repo.Complete(context.TODO(), raw_uuid.String())
+		s.NoError(err)
+		s.True(completed)
+		s.Equal(raw_uuid, task.ID)
+		s.Equal("title", task.Title)
+		s.Equal(createdAt, *task.CreatedAt)
+		s.Require().NotNil(task.CompletedAt)
+		s.Equal(task.CompletedAt, task.UpdatedAt)
+		s.NoError(s.mockSQL.ExpectationsWereMet())
+	})
+
+	s.Run("When the task is already completed nothing changes", func() {
+		raw_uuid := uuid.New()
+		binary_uuid, _ := raw_uuid.MarshalBinary()
+		completedAt := createdAt.Add(time.Hour)
+		s.mockSQL.ExpectBegin()
+		s.mockSQL.ExpectQuery(qSelect).WithArgs(binary_uuid).WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(binary_uuid, "title", "description", createdAt, completedAt, completedAt))
+		s.mockSQL.ExpectCommit()
+
+		task, completed, err := s.repo.Complete(context.TODO(), raw_uuid.String())
+		s.NoError(err)
+		s.False(completed)
+		s.Equal(completedAt, *task.CompletedAt)
+		s.NoError(s.mockSQL.ExpectationsWereMet())
+	})
+
+	s.Run("When the task does not exist must return error", func() {
+		raw_uuid := uuid.New()
+		s.mockSQL.ExpectBegin()
+		s.mockSQL.ExpectQuery(qSelect).WillReturnRows(sqlmock.NewRows(columns))
+		s.mockSQL.ExpectRollback()
+		task, _, err := s.repo.Complete(context.TODO(), raw_uuid.String())
+		s.EqualError(err, "not_found")
+		s.Nil(task)
+		s.NoError(s.mockSQL.ExpectationsWereMet())
+	})
+
+	s.Run("When the outbox insert fails must return error", func() {
+		raw_uuid := uuid.New()
+		binary_uuid, _ := raw_uuid.MarshalBinary()
+		s.mockSQL.ExpectBegin()
+		s.mockSQL.ExpectQuery(qSelect).WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(binary_uuid, "title", "description", createdAt, createdAt, nil))
+		s.mockSQL.ExpectExec(q).WillReturnResult(sqlmock.NewResult(0, 1))
+		s.expectChangeSeq()
+		s.mockSQL.ExpectExec(qOutbox).WillReturnError(errors.New("outbox error"))
+		s.mockSQL.ExpectRollback()
+		_, completed, err := s.repo.Complete(context.TODO(), raw_uuid.String())
+		s.EqualError(err, "outbox_insert")
+		s.False(completed)
+		s.NoError(s.mockSQL.ExpectationsWereMet())
+	})
+
+	s.Run("When test uuid without format return error", func() {
+		_, _, err := s.repo.Complete(context.TODO(), "00000000")
+		s.EqualError(err, "uuid_format")
+	})
+}
+
+func (s *SuiteRepository) TestObserver() {
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	observed := map[string]error{}
+	repo := mysql.NewtaskRepository(s.db, logger.Sugar(), func(method string, elapsed time.Duration, err error) {
+		observed[method] = err
+	})
+
+	raw_uuid := uuid.New()
+	binary_uuid, _ := raw_uuid.MarshalBinary()
+	s.mockSQL.ExpectQuery("SELECT id, title, description, created_at, updated_at, completed_at FROM task WHERE id=\\? ").WithArgs(binary_uuid).
+		WillReturnError(errors.New("query error"))
+	_, err := repo.GetByID(context.TODO(), raw_uuid.String())
+	s.Error(err)
+	s.Contains(observed, "GetByID")
+	s.Equal(err, observed["GetByID"])
+}
+
+func (s *SuiteRepository) TestCountByUser() {
+	q := "SELECT count\\(\\*\\) FROM task WHERE user_id=\\?"
+
+	s.Run("Success test", func() {
+		s.mockSQL.ExpectQuery(q).WithArgs("user-1").
+			WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(3))
+		total, err := s.repo.CountByUser(context.TODO(), "user-1")
+		s.NoError(err)
+		s.Equal(3, total)
+	})
+
+	s.Run("Error test", func() {
+		s.mockSQL.ExpectQuery(q).WithArgs("user-1").WillReturnError(errors.New("query error"))
+		_, err := s.repo.CountByUser(context.TODO(), "user-1")
+		s.EqualError(err, "query_context")
+	})
+}
+
+func (s *SuiteRepository) TestInsertOwner() {
+	s.mockSQL.ExpectBegin()
+	s.mockSQL.ExpectPrepare("INSERT task SET").ExpectExec().
+		WithArgs(sqlmock.AnyArg(), "title", "description", sqlmock.AnyArg(), sqlmock.AnyArg(), "user-1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	s.expectChangeSeq()
+	s.mockSQL.ExpectExec("INSERT task_outbox").WillReturnResult(sqlmock.NewResult(1, 1))
+	s.mockSQL.ExpectCommit()
+
+	ctx := domain.WithUser(context.TODO(), "user-1")
+	s.NoError(s.repo.Insert(ctx, domain.NewTask("title", "description")))
+	s.NoError(s.mockSQL.ExpectationsWereMet())
+}
+
+func (s *SuiteRepository) TestSpans() {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	s.mockSQL.ExpectQuery("SELECT id, title, description, created_at, updated_at, completed_at FROM task ORDER BY created_at ASC LIMIT \\? OFFSET \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description", "updated_at", "created_at", "completed_at"}))
+	s.mockSQL.ExpectQuery("SELECT count\\(\\*\\) FROM task").
+		WillReturnError(errors.New("query error"))
+	_, err := s.repo.Fetch(context.TODO(), &domain.Filter{Limit: 10})
+	s.Error(err)
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	s.Require().Len(spans, 3)
+	parent := spans["taskRepository.Fetch"]
+	s.Equal(codes.Error, parent.Status().Code)
+	s.Equal(codes.Unset, spans["taskRepository.select"].Status().Code)
+	count := spans["taskRepository.count"]
+	s.Equal(codes.Error, count.Status().Code)
+	s.Equal(parent.SpanContext().SpanID(), count.Parent().SpanID())
+	s.Contains(count.Attributes(), semconv.DBStatementKey.String("SELECT count(*) FROM task"))
+}
+
 func TestSuiteRepository(t *testing.T) {
 	suite.Run(t, new(SuiteRepository))
 }
@@ -0,0 +1,17 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// SchemaVersion reads the last migration goose applied.
+func SchemaVersion(ctx context.Context, conn *sql.DB) (int64, error) {
+	query := `SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version WHERE is_applied = 1`
+	var version int64
+	if err := conn.QueryRowContext(ctx, query).Scan(&version); err != nil {
+		return 0, errors.New("query_context")
+	}
+	return version, nil
+}
@@ -0,0 +1,39 @@
+package mysql_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/isaias-dgr/todo/src/task/repository/mysql"
+	"github.com/stretchr/testify/suite"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+type SuiteSchema struct {
+	suite.Suite
+}
+
+func (s *SuiteSchema) TestSchemaVersion() {
+	q := "SELECT COALESCE\\(MAX\\(version_id\\), 0\\) FROM goose_db_version WHERE is_applied = 1"
+
+	s.Run("Success test return the last applied version", func() {
+		db, mockSQL, _ := sqlmock.New()
+		mockSQL.ExpectQuery(q).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(20211015183320))
+		version, err := mysql.SchemaVersion(context.TODO(), db)
+		s.NoError(err)
+		s.Equal(int64(20211015183320), version)
+	})
+
+	s.Run("Fail test when the table is missing", func() {
+		db, mockSQL, _ := sqlmock.New()
+		mockSQL.ExpectQuery(q).WillReturnError(errors.New("Table 'goose_db_version' doesn't exist"))
+		_, err := mysql.SchemaVersion(context.TODO(), db)
+		s.Error(err)
+		s.Equal("query_context", err.Error())
+	})
+}
+
+func TestSuiteSchema(t *testing.T) {
+	suite.Run(t, new(SuiteSchema))
+}
@@ -0,0 +1,89 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/logging"
+	"go.uber.org/zap"
+)
+
+type syncRepository struct {
+	Conn *sql.DB
+	l    *zap.SugaredLogger
+}
+
+// NewSyncRepository reads the changes from task_outbox by change_seq,
+// which is handed out in commit order, so a page never skips a change
+// committed later with a lower number. Published events are kept there,
+// and tasks saved before the outbox existed got a change by migration, so
+// it goes back to the first task.
+func NewSyncRepository(Conn *sql.DB, logger *zap.SugaredLogger) domain.SyncRepository {
+	return &syncRepository{
+		Conn: Conn,
+		l:    logger,
+	}
+}
+
+func (m *syncRepository) Changes(ctx context.Context, userID string, since int64, limit int) ([]*domain.Change, error) {
+	query := `SELECT change_seq, event_type, task_id, payload, created_at FROM task_outbox
+		WHERE user_id=? AND change_seq > ? ORDER BY change_seq ASC LIMIT ?`
+	return m.query(ctx, query, userID, since, limit)
+}
+
+func (m *syncRepository) LastChange(ctx context.Context, taskID uuid.UUID) (*domain.Change, error) {
+	query := `SELECT change_seq, event_type, task_id, payload, created_at FROM task_outbox
+		WHERE task_id=? ORDER BY change_seq DESC LIMIT 1`
+	binary_uuid, _ := taskID.MarshalBinary()
+	changes, err := m.query(ctx, query, binary_uuid)
+	if err != nil || len(changes) == 0 {
+		return nil, err
+	}
+	return changes[0], nil
+}
+
+func (m *syncRepository) query(ctx context.Context, query string, args ...interface{}) ([]*domain.Change, error) {
+	l := logging.FromContext(ctx, m.l)
+	rows, err := m.Conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		l.Error(err.Error())
+		return nil, errors.New("query_context")
+	}
+	defer rows.Close()
+
+	changes := []*domain.Change{}
+	for rows.Next() {
+		var (
+			eventType string
+			payload   []byte
+			changedAt time.Time
+		)
+		change := &domain.Change{}
+		err := rows.Scan(&change.Seq, &eventType, &change.TaskID, &payload, &changedAt)
+		if err != nil {
+			l.Error(err.Error())
+			return nil, errors.New("row_data_types")
+		}
+		change.ChangedAt = &changedAt
+		change.Op = domain.SyncDelete
+		if eventType != domain.TaskDeleted {
+			change.Op = domain.SyncUpsert
+			change.Task = &domain.Task{}
+			if err := json.Unmarshal(payload, change.Task); err != nil {
+				l.Error(err.Error())
+				return nil, errors.New("row_data_types")
+			}
+		}
+		changes = append(changes, change)
+	}
+	if err := rows.Err(); err != nil {
+		l.Error(err.Error())
+		return nil, errors.New("row_corrupt")
+	}
+	return changes, nil
+}
@@ -0,0 +1,213 @@
+package mysql_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+	"github.com/isaias-dgr/todo/migrate"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/task/repository/mysql"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+type SuiteSyncRepository struct {
+	suite.Suite
+	mockSQL sqlmock.Sqlmock
+	repo    domain.SyncRepository
+}
+
+func (s *SuiteSyncRepository) SetupTest() {
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	db, mockSQL, err := sqlmock.New()
+	s.Require().NoError(err)
+	s.mockSQL = mockSQL
+	s.repo = mysql.NewSyncRepository(db, logger.Sugar())
+}
+
+func (s *SuiteSyncRepository) TestChanges() {
+	q := "SELECT change_seq, event_type, task_id, payload, created_at FROM task_outbox WHERE user_id=\\? AND change_seq > \\? ORDER BY change_seq ASC LIMIT \\?"
+	columns := []string{"change_seq", "event_type", "task_id", "payload", "created_at"}
+
+	s.Run("Success test return the changes in order", func() {
+		raw_uuid := uuid.New()
+		binary_uuid, _ := raw_uuid.MarshalBinary()
+		now := time.Now()
+		s.mockSQL.ExpectQuery(q).WithArgs("user-1", 10, 2).WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(11, domain.TaskCreated, binary_uuid, []byte(`{"title":"t","description":"d"}`), now).
+			AddRow(12, domain.TaskDeleted, binary_uuid, []byte(`{"id":"`+raw_uuid.String()+`"}`), now))
+
+		changes, err := s.repo.Changes(context.TODO(), "user-1", 10, 2)
+		s.NoError(err)
+		s.Require().Len(changes, 2)
+		s.Equal(int64(11), changes[0].Seq)
+		s.Equal(domain.SyncUpsert, changes[0].Op)
+		s.Equal(raw_uuid, changes[0].TaskID)
+		s.Equal("t", changes[0].Task.Title)
+		s.Equal(domain.SyncDelete, changes[1].Op)
+		s.Nil(changes[1].Task)
+	})
+
+	s.Run("When the payload is corrupt must return error", func() {
+		binary_uuid, _ := uuid.New().MarshalBinary()
+		s.mockSQL.ExpectQuery(q).WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(11, domain.TaskUpdated, binary_uuid, []byte(`{`), time.Now()))
+		_, err := s.repo.Changes(context.TODO(), "user-1", 10, 2)
+		s.EqualError(err, "row_data_types")
+	})
+
+	s.Run("When the query fails must return error", func() {
+		s.mockSQL.ExpectQuery(q).WillReturnError(errors.New("gone"))
+		_, err := s.repo.Changes(context.TODO(), "user-1", 10, 2)
+		s.EqualError(err, "query_context")
+	})
+	s.NoError(s.mockSQL.ExpectationsWereMet())
+}
+
+func (s *SuiteSyncRepository) TestLastChange() {
+	q := "SELECT change_seq, event_type, task_id, payload, created_at FROM task_outbox WHERE task_id=\\? ORDER BY change_seq DESC LIMIT 1"
+	columns := []string{"change_seq", "event_type", "task_id", "payload", "created_at"}
+	raw_uuid := uuid.New()
+	binary_uuid, _ := raw_uuid.MarshalBinary()
+
+	s.mockSQL.ExpectQuery(q).WithArgs(binary_uuid).WillReturnRows(sqlmock.NewRows(columns).
+		AddRow(7, domain.TaskUpdated, binary_uuid, []byte(`{"title":"t","description":"d"}`), time.Now()))
+	s.mockSQL.ExpectQuery(q).WithArgs(binary_uuid).WillReturnRows(sqlmock.NewRows(columns))
+
+	change, err := s.repo.LastChange(context.TODO(), raw_uuid)
+	s.NoError(err)
+	s.Equal(int64(7), change.Seq)
+	change, err = s.repo.LastChange(context.TODO(), raw_uuid)
+	s.NoError(err)
+	s.Nil(change)
+	s.NoError(s.mockSQL.ExpectationsWereMet())
+}
+
+// TestOverlappingWriters runs only when MYSQL_CONN points to a migrated
+// database, e.g. the one from docker-compose. Writer A takes its change
+// number and stays open while writer B saves a task: B must not show up
+// before A commits, or a client pulling in between would skip A.
+func (s *SuiteSyncRepository) TestOverlappingWriters() {
+	conn := os.Getenv("MYSQL_CONN")
+	if conn == "" {
+		s.T().Skip("MYSQL_CONN not set")
+	}
+	db, err := sql.Open("mysql", conn+"?parseTime=true")
+	s.Require().NoError(err)
+	defer db.Close()
+	ctx := context.TODO()
+	logger := zap.NewNop().Sugar()
+	changes := mysql.NewSyncRepository(db, logger)
+	tasks := mysql.NewtaskRepository(db, logger)
+
+	var since int64
+	s.Require().NoError(db.QueryRowContext(ctx, `SELECT seq FROM task_change_seq WHERE id=1`).Scan(&since))
+
+	a, err := db.BeginTx(ctx, nil)
+	s.Require().NoError(err)
+	defer a.Rollback()
+	res, err := a.ExecContext(ctx, `UPDATE task_change_seq SET seq=LAST_INSERT_ID(seq+1) WHERE id=1`)
+	s.Require().NoError(err)
+	seqA, _ := res.LastInsertId()
+	event_uuid, _ := uuid.New().MarshalBinary()
+	task_uuid, _ := uuid.New().MarshalBinary()
+	_, err = a.ExecContext(ctx,
+		`INSERT task_outbox SET id=?, event_type=?, task_id=?, created_at=?, change_seq=?`,
+		event_uuid, domain.TaskDeleted, task_uuid, time.Now(), seqA)
+	s.Require().NoError(err)
+
+	b := make(chan error, 1)
+	go func() { b <- tasks.Insert(ctx, domain.NewTask("title", "description")) }()
+	select {
+	case err := <-b:
+		s.FailNow("writer B committed while writer A was open", "%v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	page, err := changes.Changes(ctx, "", since, 10)
+	s.NoError(err)
+	s.Empty(page)
+
+	s.Require().NoError(a.Commit())
+	s.Require().NoError(<-b)
+	page, err = changes.Changes(ctx, "", since, 10)
+	s.NoError(err)
+	s.Require().Len(page, 2)
+	s.Equal(seqA, page[0].Seq)
+	s.Equal(seqA+1, page[1].Seq)
+	s.Equal(domain.SyncUpsert, page[1].Op)
+}
+
+// TestBackfill runs only when MYSQL_CONN points to a migrated database. A
+// task saved before the outbox existed has no change; the backfill
+// migration gives it one, once, so a full sync returns it.
+func (s *SuiteSyncRepository) TestBackfill() {
+	conn := os.Getenv("MYSQL_CONN")
+	if conn == "" {
+		s.T().Skip("MYSQL_CONN not set")
+	}
+	db, err := sql.Open("mysql", conn+"?parseTime=true")
+	s.Require().NoError(err)
+	defer db.Close()
+	ctx := context.TODO()
+	changes := mysql.NewSyncRepository(db, zap.NewNop().Sugar())
+
+	user := "backfill-" + uuid.New().String()
+	raw_uuid := uuid.New()
+	binary_uuid, _ := raw_uuid.MarshalBinary()
+	now := time.Now().UTC().Truncate(time.Second)
+	_, err = db.ExecContext(ctx,
+		`INSERT task SET id=?, title=?, description=?, created_at=?, updated_at=?, user_id=?`,
+		binary_uuid, "legacy", "saved before the outbox", now, now, user)
+	s.Require().NoError(err)
+	defer db.ExecContext(ctx, `DELETE FROM task_outbox WHERE task_id=?`, binary_uuid)
+	defer db.ExecContext(ctx, `DELETE FROM task WHERE id=?`, binary_uuid)
+
+	page, err := changes.Changes(ctx, user, 0, 10)
+	s.NoError(err)
+	s.Empty(page)
+
+	for i := 0; i < 2; i++ {
+		tx, err := db.BeginTx(ctx, nil)
+		s.Require().NoError(err)
+		for _, stmt := range upStatements(s.T(), "20211019090100_backfill_task_outbox.sql") {
+			_, err := tx.ExecContext(ctx, stmt)
+			s.Require().NoError(err, stmt)
+		}
+		s.Require().NoError(tx.Commit())
+	}
+
+	page, err = changes.Changes(ctx, user, 0, 10)
+	s.NoError(err)
+	s.Require().Len(page, 1, "the task gets one change")
+	s.Equal(domain.SyncUpsert, page[0].Op)
+	s.Equal(raw_uuid, page[0].Task.ID)
+	s.Equal("legacy", page[0].Task.Title)
+	s.True(now.Equal(*page[0].Task.CreatedAt))
+}
+
+// upStatements reads the statements of the Up section of a migration.
+func upStatements(t *testing.T, name string) []string {
+	raw, err := migrate.Files.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	up := strings.SplitN(string(raw), "-- +goose Down", 2)[0]
+	var stmts []string
+	for _, block := range strings.Split(up, "-- +goose StatementBegin")[1:] {
+		stmts = append(stmts, strings.SplitN(block, "-- +goose StatementEnd", 2)[0])
+	}
+	return stmts
+}
+
+func TestSuiteSyncRepository(t *testing.T) {
+	suite.Run(t, new(SuiteSyncRepository))
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/isaias-dgr/todo/src/domain"
+	"go.uber.org/zap"
+)
+
+var blobKey = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+type localStore struct {
+	root string
+	l    *zap.SugaredLogger
+}
+
+// NewLocalBlobStore keeps blobs under root/blobs/<2 hex>/<sha256>. Uploads
+// land in root/tmp first and are renamed into place, so a blob path
+// either holds the complete content or does not exist.
+func NewLocalBlobStore(root string, logger *zap.SugaredLogger) (domain.BlobStore, error) {
+	if root == "" {
+		return nil, errors.New("blob_dir")
+	}
+	for _, dir := range []string{"tmp", "blobs"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o750); err != nil {
+			logger.Error(err.Error())
+			return nil, errors.New("blob_dir")
+		}
+	}
+	return &localStore{
+		root: root,
+		l:    logger,
+	}, nil
+}
+
+func (s *localStore) Put(ctx context.Context, contentType string, body io.Reader) (*domain.Blob, error) {
+	tmp, err := ioutil.TempFile(filepath.Join(s.root, "tmp"), "upload-")
+	if err != nil {
+		s.l.Error(err.Error())
+		return nil, errors.New("storage_put")
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), body)
+	if err != nil {
+		tmp.Close()
+		if errors.Is(err, domain.ErrAttachmentTooLarge) {
+			return nil, err
+		}
+		s.l.Error(err.Error())
+		return nil, errors.New("storage_put")
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		s.l.Error(err.Error())
+		return nil, errors.New("storage_put")
+	}
+	if err := tmp.Close(); err != nil {
+		s.l.Error(err.Error())
+		return nil, errors.New("storage_put")
+	}
+
+	blob := &domain.Blob{Key: hex.EncodeToString(hash.Sum(nil)), Size: size}
+	dest := s.path(blob.Key)
+	if _, err := os.Stat(dest); err == nil {
+		return blob, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0o750); err != nil {
+		s.l.Error(err.Error())
+		return nil, errors.New("storage_put")
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		s.l.Error(err.Error())
+		return nil, errors.New("storage_put")
+	}
+	return blob, nil
+}
+
+// Open returns the file itself; being an io.ReadSeeker it serves range
+// requests without reading the blob from the start.
+func (s *localStore) Open(ctx context.Context, key string) (domain.BlobReader, error) {
+	if !blobKey.MatchString(key) {
+		return nil, errors.New("not_found")
+	}
+	f, err := os.Open(s.path(key))
+	if err != nil {
+		s.l.Error(err.Error())
+		return nil, errors.New("not_found")
+	}
+	return f, nil
+}
+
+func (s *localStore) Delete(ctx context.Context, key string) error {
+	if !blobKey.MatchString(key) {
+		return errors.New("not_found")
+	}
+	if err := os.Remove(s.path(key)); err != nil && !os.IsNotExist(err) {
+		s.l.Error(err.Error())
+		return errors.New("storage_delete")
+	}
+	return nil
+}
+
+func (s *localStore) path(key string) string {
+	return filepath.Join(s.root, "blobs", key[:2], key)
+}
@@ -0,0 +1,108 @@
+package storage_test
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/task/storage"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+// sha256("hello")
+const helloKey = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+type SuiteLocalStorage struct {
+	suite.Suite
+	root  string
+	store domain.BlobStore
+}
+
+func (s *SuiteLocalStorage) SetupTest() {
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	root, err := ioutil.TempDir("", "blobs-")
+	s.Require().NoError(err)
+	s.root = root
+	s.store, err = storage.NewLocalBlobStore(root, logger.Sugar())
+	s.Require().NoError(err)
+}
+
+func (s *SuiteLocalStorage) TearDownTest() {
+	os.RemoveAll(s.root)
+}
+
+func (s *SuiteLocalStorage) TestPut() {
+	blob, err := s.store.Put(context.TODO(), "text/plain", strings.NewReader("hello"))
+	s.NoError(err)
+	s.Equal(helloKey, blob.Key)
+	s.Equal(int64(5), blob.Size)
+
+	content, err := ioutil.ReadFile(filepath.Join(s.root, "blobs", helloKey[:2], helloKey))
+	s.NoError(err)
+	s.Equal("hello", string(content))
+	tmp, _ := ioutil.ReadDir(filepath.Join(s.root, "tmp"))
+	s.Empty(tmp, "temporary files must not be left behind")
+}
+
+func (s *SuiteLocalStorage) TestPutDedupe() {
+	first, err := s.store.Put(context.TODO(), "text/plain", strings.NewReader("hello"))
+	s.NoError(err)
+	second, err := s.store.Put(context.TODO(), "text/plain", strings.NewReader("hello"))
+	s.NoError(err)
+	s.Equal(first.Key, second.Key)
+
+	blobs, _ := ioutil.ReadDir(filepath.Join(s.root, "blobs", helloKey[:2]))
+	s.Len(blobs, 1)
+}
+
+func (s *SuiteLocalStorage) TestPutFailedLeavesNothing() {
+	body := io.MultiReader(strings.NewReader("hel"), &failingReader{err: domain.ErrAttachmentTooLarge})
+	_, err := s.store.Put(context.TODO(), "text/plain", body)
+	s.Equal(domain.ErrAttachmentTooLarge, err)
+
+	tmp, _ := ioutil.ReadDir(filepath.Join(s.root, "tmp"))
+	s.Empty(tmp)
+	blobs, _ := ioutil.ReadDir(filepath.Join(s.root, "blobs"))
+	s.Empty(blobs)
+}
+
+func (s *SuiteLocalStorage) TestOpenRange() {
+	_, err := s.store.Put(context.TODO(), "text/plain", strings.NewReader("hello"))
+	s.Require().NoError(err)
+
+	r, err := s.store.Open(context.TODO(), helloKey)
+	s.Require().NoError(err)
+	defer r.Close()
+	_, err = r.Seek(1, io.SeekStart)
+	s.NoError(err)
+	part := make([]byte, 3)
+	_, err = io.ReadFull(r, part)
+	s.NoError(err)
+	s.Equal("ell", string(part))
+}
+
+func (s *SuiteLocalStorage) TestOpenInvalidKey() {
+	_, err := s.store.Open(context.TODO(), "../../etc/passwd")
+	s.Error(err)
+	s.Equal("not_found", err.Error())
+}
+
+func (s *SuiteLocalStorage) TestDelete() {
+	_, err := s.store.Put(context.TODO(), "text/plain", strings.NewReader("hello"))
+	s.Require().NoError(err)
+	s.NoError(s.store.Delete(context.TODO(), helloKey))
+	_, err = s.store.Open(context.TODO(), helloKey)
+	s.Error(err)
+	s.NoError(s.store.Delete(context.TODO(), helloKey), "deleting twice is not an error")
+}
+
+func TestSuiteLocalStorage(t *testing.T) {
+	suite.Run(t, new(SuiteLocalStorage))
+}
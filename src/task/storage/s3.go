@@ -0,0 +1,253 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/google/uuid"
+	"github.com/isaias-dgr/todo/src/domain"
+	"go.uber.org/zap"
+)
+
+type S3Config struct {
+	Bucket string
+	Region string
+	// Endpoint overrides the AWS endpoint, e.g. http://minio:9000.
+	Endpoint  string
+	URLExpiry time.Duration
+}
+
+type s3Store struct {
+	client   *s3.S3
+	uploader *s3manager.Uploader
+	bucket   string
+	expiry   time.Duration
+	l        *zap.SugaredLogger
+}
+
+func NewS3BlobStore(cfg S3Config, logger *zap.SugaredLogger) (domain.BlobStore, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("s3_bucket")
+	}
+	awsCfg := aws.NewConfig().WithRegion(cfg.Region)
+	if cfg.Endpoint != "" {
+		// S3-compatible servers like MinIO don't resolve bucket subdomains.
+		awsCfg = awsCfg.WithEndpoint(cfg.Endpoint).WithS3ForcePathStyle(true)
+	}
+	sess, err := session.NewSession(awsCfg)
+	if err != nil {
+		logger.Error(err.Error())
+		return nil, errors.New("s3_session")
+	}
+	expiry := cfg.URLExpiry
+	if expiry == 0 {
+		expiry = 15 * time.Minute
+	}
+	client := s3.New(sess)
+	return &s3Store{
+		client:   client,
+		uploader: s3manager.NewUploaderWithClient(client),
+		bucket:   cfg.Bucket,
+		expiry:   expiry,
+		l:        logger,
+	}, nil
+}
+
+// Put streams body to a temporary object while hashing it, then copies it
+// to its content address. The hash is only known at the end of the stream,
+// which is why the upload can't go to the final key directly.
+func (s *s3Store) Put(ctx context.Context, contentType string, body io.Reader) (*domain.Blob, error) {
+	tmp := "tmp/" + uuid.New().String()
+	hash := sha256.New()
+	counter := &countingReader{r: io.TeeReader(body, hash)}
+	_, err := s.uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(tmp),
+		ContentType: aws.String(contentType),
+		Body:        counter,
+	})
+	if err != nil {
+		s.l.Error(err.Error())
+		if errors.Is(origErr(err), domain.ErrAttachmentTooLarge) {
+			return nil, domain.ErrAttachmentTooLarge
+		}
+		return nil, errors.New("storage_put")
+	}
+	defer s.remove(ctx, tmp)
+
+	blob := &domain.Blob{Key: hex.EncodeToString(hash.Sum(nil)), Size: counter.n}
+	if s.exists(ctx, blob.Key) {
+		return blob, nil
+	}
+	_, err = s.client.CopyObjectWithContext(ctx, &s3.CopyObjectInput{
+		Bucket:     aws.String(s.bucket),
+		CopySource: aws.String(s.bucket + "/" + tmp),
+		Key:        aws.String(s.path(blob.Key)),
+	})
+	if err != nil {
+		s.l.Error(err.Error())
+		return nil, errors.New("storage_put")
+	}
+	return blob, nil
+}
+
+func (s *s3Store) Open(ctx context.Context, key string) (domain.BlobReader, error) {
+	if !blobKey.MatchString(key) {
+		return nil, errors.New("not_found")
+	}
+	head, err := s.client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.path(key)),
+	})
+	if err != nil {
+		s.l.Error(err.Error())
+		return nil, errors.New("not_found")
+	}
+	return &s3Reader{
+		ctx:  ctx,
+		s:    s,
+		key:  s.path(key),
+		size: aws.Int64Value(head.ContentLength),
+	}, nil
+}
+
+func (s *s3Store) Delete(ctx context.Context, key string) error {
+	if !blobKey.MatchString(key) {
+		return errors.New("not_found")
+	}
+	if err := s.remove(ctx, s.path(key)); err != nil {
+		return errors.New("storage_delete")
+	}
+	return nil
+}
+
+// URL returns a presigned GET url valid for the configured expiry.
+func (s *s3Store) URL(ctx context.Context, key string) (string, error) {
+	req, _ := s.client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.path(key)),
+	})
+	url, err := req.Presign(s.expiry)
+	if err != nil {
+		s.l.Error(err.Error())
+		return "", errors.New("storage_presign")
+	}
+	return url, nil
+}
+
+func (s *s3Store) exists(ctx context.Context, key string) bool {
+	_, err := s.client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.path(key)),
+	})
+	return err == nil
+}
+
+func (s *s3Store) remove(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		s.l.Error(err.Error())
+	}
+	return err
+}
+
+func (s *s3Store) path(key string) string {
+	return "blobs/" + key
+}
+
+// s3Reader turns seeks into ranged GETs, so serving a byte range only
+// downloads that range from the bucket.
+type s3Reader struct {
+	ctx    context.Context
+	s      *s3Store
+	key    string
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+func (r *s3Reader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if r.body == nil {
+		out, err := r.s.client.GetObjectWithContext(r.ctx, &s3.GetObjectInput{
+			Bucket: aws.String(r.s.bucket),
+			Key:    aws.String(r.key),
+			Range:  aws.String(fmt.Sprintf("bytes=%d-", r.offset)),
+		})
+		if err != nil {
+			r.s.l.Error(err.Error())
+			return 0, errors.New("storage_get")
+		}
+		r.body = out.Body
+	}
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+func (r *s3Reader) Seek(offset int64, whence int) (int64, error) {
+	var next int64
+	switch whence {
+	case io.SeekStart:
+		next = offset
+	case io.SeekCurrent:
+		next = r.offset + offset
+	case io.SeekEnd:
+		next = r.size + offset
+	default:
+		return 0, errors.New("storage_seek")
+	}
+	if next < 0 {
+		return 0, errors.New("storage_seek")
+	}
+	if next != r.offset {
+		r.Close()
+		r.offset = next
+	}
+	return next, nil
+}
+
+func (r *s3Reader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return err
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func origErr(err error) error {
+	for {
+		awsErr, ok := err.(awserr.Error)
+		if !ok || awsErr.OrigErr() == nil {
+			return err
+		}
+		err = awsErr.OrigErr()
+	}
+}
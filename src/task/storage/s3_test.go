@@ -0,0 +1,177 @@
+package storage_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/task/storage"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+// fakeS3 is the smallest path-style S3 the blob store talks to: put, copy,
+// head, ranged get and delete.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	gets    []string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := r.URL.Path
+	switch r.Method {
+	case http.MethodPut:
+		if src := r.Header.Get("X-Amz-Copy-Source"); src != "" {
+			f.objects[key] = f.objects["/"+strings.TrimPrefix(src, "/")]
+			fmt.Fprint(w, `<CopyObjectResult><ETag>"x"</ETag></CopyObjectResult>`)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		f.objects[key] = body
+	case http.MethodHead:
+		body, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	case http.MethodGet:
+		body, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		rng := r.Header.Get("Range")
+		f.gets = append(f.gets, rng)
+		start, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rng, "bytes="), "-"))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(body[start:])
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+type SuiteS3Storage struct {
+	suite.Suite
+	logger *zap.SugaredLogger
+	fake   *fakeS3
+	server *httptest.Server
+	store  domain.BlobStore
+}
+
+func (s *SuiteS3Storage) SetupTest() {
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+	s.logger = logger.Sugar()
+
+	os.Setenv("AWS_ACCESS_KEY_ID", "local")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "local")
+	s.fake = &fakeS3{objects: map[string][]byte{}}
+	s.server = httptest.NewServer(s.fake)
+	store, err := storage.NewS3BlobStore(storage.S3Config{
+		Bucket:   "attachments",
+		Region:   "us-east-1",
+		Endpoint: s.server.URL,
+	}, s.logger)
+	s.Require().NoError(err)
+	s.store = store
+}
+
+func (s *SuiteS3Storage) TearDownTest() {
+	s.server.Close()
+}
+
+func (s *SuiteS3Storage) TestNewS3BlobStoreWithoutBucket() {
+	_, err := storage.NewS3BlobStore(storage.S3Config{Region: "us-east-1"}, s.logger)
+	s.Error(err)
+	s.Equal("s3_bucket", err.Error())
+}
+
+func (s *SuiteS3Storage) TestPut() {
+	blob, err := s.store.Put(context.TODO(), "text/plain", strings.NewReader("hello"))
+	s.NoError(err)
+	s.Equal(helloKey, blob.Key)
+	s.Equal(int64(5), blob.Size)
+	s.Equal([]byte("hello"), s.fake.objects["/attachments/blobs/"+helloKey])
+	s.Len(s.fake.objects, 1, "the temporary upload must be removed")
+
+	again, err := s.store.Put(context.TODO(), "text/plain", strings.NewReader("hello"))
+	s.NoError(err)
+	s.Equal(blob.Key, again.Key)
+	s.Len(s.fake.objects, 1)
+}
+
+func (s *SuiteS3Storage) TestPutTooLarge() {
+	body := &failingReader{err: domain.ErrAttachmentTooLarge}
+	_, err := s.store.Put(context.TODO(), "text/plain", body)
+	s.Equal(domain.ErrAttachmentTooLarge, err)
+}
+
+func (s *SuiteS3Storage) TestOpenRange() {
+	_, err := s.store.Put(context.TODO(), "text/plain", strings.NewReader("hello"))
+	s.Require().NoError(err)
+
+	r, err := s.store.Open(context.TODO(), helloKey)
+	s.Require().NoError(err)
+	defer r.Close()
+	size, err := r.Seek(0, io.SeekEnd)
+	s.NoError(err)
+	s.Equal(int64(5), size)
+	_, err = r.Seek(2, io.SeekStart)
+	s.NoError(err)
+	rest, err := ioutil.ReadAll(r)
+	s.NoError(err)
+	s.Equal("llo", string(rest))
+	s.Equal([]string{"bytes=2-"}, s.fake.gets)
+}
+
+func (s *SuiteS3Storage) TestOpenMissing() {
+	_, err := s.store.Open(context.TODO(), helloKey)
+	s.Error(err)
+	s.Equal("not_found", err.Error())
+
+	_, err = s.store.Open(context.TODO(), "../secret")
+	s.Error(err)
+}
+
+func (s *SuiteS3Storage) TestDelete() {
+	_, err := s.store.Put(context.TODO(), "text/plain", strings.NewReader("hello"))
+	s.Require().NoError(err)
+	s.NoError(s.store.Delete(context.TODO(), helloKey))
+	s.Empty(s.fake.objects)
+}
+
+func (s *SuiteS3Storage) TestURL() {
+	urler, ok := s.store.(domain.BlobURLer)
+	s.Require().True(ok)
+	url, err := urler.URL(context.TODO(), helloKey)
+	s.NoError(err)
+	s.True(strings.HasPrefix(url, s.server.URL+"/attachments/blobs/"+helloKey+"?"))
+	s.Contains(url, "X-Amz-Signature=")
+	s.Contains(url, "X-Amz-Expires=900")
+}
+
+type failingReader struct {
+	err error
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	return 0, f.err
+}
+
+func TestSuiteS3Storage(t *testing.T) {
+	suite.Run(t, new(SuiteS3Storage))
+}
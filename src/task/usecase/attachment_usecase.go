@@ -0,0 +1,175 @@
+package useCase
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+
+	"github.com/isaias-dgr/todo/src/domain"
+)
+
+type attachmentUseCase struct {
+	repo   domain.AttachmentRepository
+	tasks  domain.TaskRepository
+	blobs  domain.BlobStore
+	policy *domain.AttachmentPolicy
+}
+
+func NewAttachmentUseCase(a domain.AttachmentRepository, t domain.TaskRepository, b domain.BlobStore, p *domain.AttachmentPolicy) domain.AttachmentUseCase {
+	return &attachmentUseCase{
+		repo:   a,
+		tasks:  t,
+		blobs:  b,
+		policy: p,
+	}
+}
+
+// Upload sniffs the content type from the first bytes instead of trusting
+// the client, then streams the body to the blob store until the size limit.
+func (a *attachmentUseCase) Upload(ctx context.Context, taskID string, name string, body io.Reader) (*domain.Attachment, error) {
+	task, err := a.tasks.GetByID(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	buffered := bufio.NewReaderSize(body, 512)
+	head, err := buffered.Peek(512)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	contentType := http.DetectContentType(head)
+	if !a.policy.Allows(contentType) {
+		return nil, domain.ErrAttachmentType
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	blob, err := a.blobs.Put(ctx, mediaType, &limitedReader{r: buffered, max: a.policy.MaxSize})
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := domain.NewAttachment(task.ID, path.Base(name), mediaType)
+	attachment.Key = blob.Key
+	attachment.Size = blob.Size
+	err = a.repo.Insert(ctx, attachment, func(ctx context.Context) error {
+		return a.exists(ctx, blob.Key)
+	})
+	if err != nil {
+		a.release(ctx, blob.Key)
+		return nil, err
+	}
+	return a.withURL(ctx, attachment)
+}
+
+func (a *attachmentUseCase) Fetch(ctx context.Context, taskID string) ([]*domain.Attachment, error) {
+	attachments, err := a.repo.Fetch(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	for _, attachment := range attachments {
+		if _, err := a.withURL(ctx, attachment); err != nil {
+			return nil, err
+		}
+	}
+	return attachments, nil
+}
+
+func (a *attachmentUseCase) GetByID(ctx context.Context, taskID string, id string) (*domain.Attachment, error) {
+	attachment, err := a.repo.GetByID(ctx, taskID, id)
+	if err != nil {
+		return nil, err
+	}
+	return a.withURL(ctx, attachment)
+}
+
+func (a *attachmentUseCase) Open(ctx context.Context, taskID string, id string) (*domain.Attachment, domain.BlobReader, error) {
+	attachment, err := a.repo.GetByID(ctx, taskID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	reader, err := a.blobs.Open(ctx, attachment.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return attachment, reader, nil
+}
+
+func (a *attachmentUseCase) Delete(ctx context.Context, taskID string, id string) error {
+	attachment, err := a.repo.GetByID(ctx, taskID, id)
+	if err != nil {
+		return err
+	}
+	if err := a.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	return a.release(ctx, attachment.Key)
+}
+
+// DeleteByTask removes every attachment of a task. It keeps going after a
+// failure so one bad blob doesn't leave the rest behind.
+func (a *attachmentUseCase) DeleteByTask(ctx context.Context, taskID string) error {
+	attachments, err := a.repo.Fetch(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	var last error
+	for _, attachment := range attachments {
+		if err := a.repo.Delete(ctx, attachment.ID.String()); err != nil {
+			last = err
+			continue
+		}
+		if err := a.release(ctx, attachment.Key); err != nil {
+			last = err
+		}
+	}
+	return last
+}
+
+// release deletes a blob once no attachment points at it anymore; the same
+// content may be attached to several tasks.
+func (a *attachmentUseCase) release(ctx context.Context, key string) error {
+	return a.repo.Release(ctx, key, func(ctx context.Context) error {
+		return a.blobs.Delete(ctx, key)
+	})
+}
+
+// exists tells whether the blob an upload deduped onto is still there; it
+// may have been released since it was put.
+func (a *attachmentUseCase) exists(ctx context.Context, key string) error {
+	reader, err := a.blobs.Open(ctx, key)
+	if err != nil {
+		return domain.ErrAttachmentConflict
+	}
+	return reader.Close()
+}
+
+func (a *attachmentUseCase) withURL(ctx context.Context, attachment *domain.Attachment) (*domain.Attachment, error) {
+	urler, ok := a.blobs.(domain.BlobURLer)
+	if !ok {
+		return attachment, nil
+	}
+	url, err := urler.URL(ctx, attachment.Key)
+	if err != nil {
+		return nil, err
+	}
+	attachment.URL = url
+	return attachment, nil
+}
+
+type limitedReader struct {
+	r   io.Reader
+	n   int64
+	max int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.n += int64(n)
+	if l.n > l.max {
+		return n, domain.ErrAttachmentTooLarge
+	}
+	return n, err
+}
@@ -0,0 +1,223 @@
+package useCase_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/domain/mocks"
+	useCase "github.com/isaias-dgr/todo/src/task/usecase"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// urlBlobStore is a blob store that also presigns urls, like S3.
+type urlBlobStore struct {
+	*mocks.BlobStore
+}
+
+func (u *urlBlobStore) URL(ctx context.Context, key string) (string, error) {
+	ret := u.Called(ctx, key)
+	return ret.String(0), ret.Error(1)
+}
+
+type nopBlob struct {
+	*strings.Reader
+}
+
+func (nopBlob) Close() error { return nil }
+
+type AttachmentUseCaseSuite struct {
+	suite.Suite
+	repo   *mocks.AttachmentRepository
+	tasks  *mocks.TaskRepository
+	blobs  *mocks.BlobStore
+	policy *domain.AttachmentPolicy
+	task   *domain.Task
+	cu     domain.AttachmentUseCase
+}
+
+func (s *AttachmentUseCaseSuite) SetupTest() {
+	s.repo = new(mocks.AttachmentRepository)
+	s.tasks = new(mocks.TaskRepository)
+	s.blobs = new(mocks.BlobStore)
+	s.task = domain.NewTask("title", "description")
+	s.task.ID = uuid.New()
+	s.policy = domain.NewAttachmentPolicy(16, "text/plain", "image/png")
+	s.cu = useCase.NewAttachmentUseCase(s.repo, s.tasks, s.blobs, s.policy)
+}
+
+func (s *AttachmentUseCaseSuite) put(key string) func(context.Context, string, io.Reader) *domain.Blob {
+	return func(ctx context.Context, ct string, body io.Reader) *domain.Blob {
+		n, _ := io.Copy(ioutil.Discard, body)
+		return &domain.Blob{Key: key, Size: n}
+	}
+}
+
+// insert runs the check of the use case, like the repositories do before
+// committing.
+func (s *AttachmentUseCaseSuite) insert(ctx context.Context, a *domain.Attachment, check func(context.Context) error) error {
+	return check(ctx)
+}
+
+// release drops the blob when total attachments still point at it is zero.
+func (s *AttachmentUseCaseSuite) release(total int) func(context.Context, string, func(context.Context) error) error {
+	return func(ctx context.Context, key string, drop func(context.Context) error) error {
+		if total > 0 {
+			return nil
+		}
+		return drop(ctx)
+	}
+}
+
+func (s *AttachmentUseCaseSuite) TestUpload() {
+	s.tasks.On("GetByID", mock.Anything, s.task.ID.String()).Return(s.task, nil)
+	s.blobs.On("Put", mock.Anything, "text/plain", mock.Anything).Return(s.put("abc"), nil)
+	s.blobs.On("Open", mock.Anything, "abc").Return(nopBlob{strings.NewReader("hello")}, nil)
+	s.repo.On("Insert", mock.Anything, mock.Anything, mock.Anything).Return(s.insert)
+
+	a, err := s.cu.Upload(context.TODO(), s.task.ID.String(), "../notes.txt", strings.NewReader("hello"))
+	s.NoError(err)
+	s.Equal("notes.txt", a.Name)
+	s.Equal("text/plain", a.ContentType)
+	s.Equal(int64(5), a.Size)
+	s.Equal("abc", a.Key)
+	s.Equal(s.task.ID, a.TaskID)
+	s.Empty(a.URL)
+}
+
+func (s *AttachmentUseCaseSuite) TestUploadWithURL() {
+	blobs := &urlBlobStore{s.blobs}
+	cu := useCase.NewAttachmentUseCase(s.repo, s.tasks, blobs, s.policy)
+	s.tasks.On("GetByID", mock.Anything, mock.Anything).Return(s.task, nil)
+	s.blobs.On("Put", mock.Anything, mock.Anything, mock.Anything).Return(s.put("abc"), nil)
+	s.blobs.On("URL", mock.Anything, "abc").Return("http://s3/signed", nil)
+	s.repo.On("Insert", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	a, err := cu.Upload(context.TODO(), s.task.ID.String(), "notes.txt", strings.NewReader("hello"))
+	s.NoError(err)
+	s.Equal("http://s3/signed", a.URL)
+}
+
+func (s *AttachmentUseCaseSuite) TestUploadTaskNotFound() {
+	s.tasks.On("GetByID", mock.Anything, mock.Anything).Return(nil, errors.New("not_found"))
+	_, err := s.cu.Upload(context.TODO(), uuid.New().String(), "notes.txt", strings.NewReader("hello"))
+	s.Error(err)
+	s.Equal("not_found", err.Error())
+	s.blobs.AssertNotCalled(s.T(), "Put", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *AttachmentUseCaseSuite) TestUploadRejectsType() {
+	s.tasks.On("GetByID", mock.Anything, mock.Anything).Return(s.task, nil)
+	zip := bytes.NewReader([]byte("PK\x03\x04rest-of-zip"))
+	_, err := s.cu.Upload(context.TODO(), s.task.ID.String(), "notes.txt", zip)
+	s.Equal(domain.ErrAttachmentType, err)
+}
+
+func (s *AttachmentUseCaseSuite) TestUploadTooLarge() {
+	s.tasks.On("GetByID", mock.Anything, mock.Anything).Return(s.task, nil)
+	s.blobs.On("Put", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, func(ctx context.Context, ct string, body io.Reader) error {
+			_, err := ioutil.ReadAll(body)
+			return err
+		})
+	_, err := s.cu.Upload(context.TODO(), s.task.ID.String(), "notes.txt",
+		strings.NewReader(strings.Repeat("a", 17)))
+	s.Equal(domain.ErrAttachmentTooLarge, err)
+	s.repo.AssertNotCalled(s.T(), "Insert", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *AttachmentUseCaseSuite) TestUploadReleasesBlobWhenInsertFails() {
+	s.tasks.On("GetByID", mock.Anything, mock.Anything).Return(s.task, nil)
+	s.blobs.On("Put", mock.Anything, mock.Anything, mock.Anything).Return(s.put("abc"), nil)
+	s.repo.On("Insert", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("query_exec"))
+	s.repo.On("Release", mock.Anything, "abc", mock.Anything).Return(s.release(0))
+	s.blobs.On("Delete", mock.Anything, "abc").Return(nil)
+
+	_, err := s.cu.Upload(context.TODO(), s.task.ID.String(), "notes.txt", strings.NewReader("hello"))
+	s.Error(err)
+	s.blobs.AssertNumberOfCalls(s.T(), "Delete", 1)
+}
+
+func (s *AttachmentUseCaseSuite) TestUploadConflictsWhenBlobWasReleased() {
+	s.tasks.On("GetByID", mock.Anything, mock.Anything).Return(s.task, nil)
+	s.blobs.On("Put", mock.Anything, mock.Anything, mock.Anything).Return(s.put("abc"), nil)
+	s.blobs.On("Open", mock.Anything, "abc").Return(nil, errors.New("not_found"))
+	s.repo.On("Insert", mock.Anything, mock.Anything, mock.Anything).Return(s.insert)
+	s.repo.On("Release", mock.Anything, "abc", mock.Anything).Return(s.release(0))
+	s.blobs.On("Delete", mock.Anything, "abc").Return(nil)
+
+	_, err := s.cu.Upload(context.TODO(), s.task.ID.String(), "notes.txt", strings.NewReader("hello"))
+	s.Equal(domain.ErrAttachmentConflict, err)
+}
+
+func (s *AttachmentUseCaseSuite) TestFetch() {
+	attachments := []*domain.Attachment{{Key: "a"}, {Key: "b"}}
+	s.repo.On("Fetch", mock.Anything, "1").Return(attachments, nil)
+
+	got, err := s.cu.Fetch(context.TODO(), "1")
+	s.NoError(err)
+	s.Len(got, 2)
+}
+
+func (s *AttachmentUseCaseSuite) TestOpen() {
+	f, _ := ioutil.TempFile("", "blob-")
+	defer os.Remove(f.Name())
+	attachment := &domain.Attachment{Key: "abc"}
+	s.repo.On("GetByID", mock.Anything, "1", "a").Return(attachment, nil)
+	s.blobs.On("Open", mock.Anything, "abc").Return(f, nil)
+
+	got, reader, err := s.cu.Open(context.TODO(), "1", "a")
+	s.NoError(err)
+	s.Equal(attachment, got)
+	s.Equal(f, reader)
+}
+
+func (s *AttachmentUseCaseSuite) TestDelete() {
+	s.Run("When the blob is not shared it is removed", func() {
+		attachment := &domain.Attachment{Key: "abc"}
+		s.repo.On("GetByID", mock.Anything, "1", "a").Return(attachment, nil)
+		s.repo.On("Delete", mock.Anything, "a").Return(nil)
+		s.repo.On("Release", mock.Anything, "abc", mock.Anything).Return(s.release(0))
+		s.blobs.On("Delete", mock.Anything, "abc").Return(nil)
+
+		s.NoError(s.cu.Delete(context.TODO(), "1", "a"))
+		s.blobs.AssertCalled(s.T(), "Delete", mock.Anything, "abc")
+	})
+
+	s.Run("When another attachment shares the blob it is kept", func() {
+		attachment := &domain.Attachment{Key: "def"}
+		s.repo.On("GetByID", mock.Anything, "1", "b").Return(attachment, nil)
+		s.repo.On("Delete", mock.Anything, "b").Return(nil)
+		s.repo.On("Release", mock.Anything, "def", mock.Anything).Return(s.release(1))
+
+		s.NoError(s.cu.Delete(context.TODO(), "1", "b"))
+		s.blobs.AssertNotCalled(s.T(), "Delete", mock.Anything, "def")
+	})
+}
+
+func (s *AttachmentUseCaseSuite) TestDeleteByTask() {
+	first, second := domain.NewAttachment(uuid.New(), "a", ""), domain.NewAttachment(uuid.New(), "b", "")
+	first.Key, second.Key = "a", "b"
+	s.repo.On("Fetch", mock.Anything, "1").Return([]*domain.Attachment{first, second}, nil)
+	s.repo.On("Delete", mock.Anything, first.ID.String()).Return(errors.New("query_exec"))
+	s.repo.On("Delete", mock.Anything, second.ID.String()).Return(nil)
+	s.repo.On("Release", mock.Anything, "b", mock.Anything).Return(s.release(0))
+	s.blobs.On("Delete", mock.Anything, "b").Return(nil)
+
+	err := s.cu.DeleteByTask(context.TODO(), "1")
+	s.Error(err)
+	s.blobs.AssertCalled(s.T(), "Delete", mock.Anything, "b")
+	s.blobs.AssertNotCalled(s.T(), "Delete", mock.Anything, "a")
+}
+
+func TestAttachmentUseCaseSuite(t *testing.T) {
+	suite.Run(t, new(AttachmentUseCaseSuite))
+}
@@ -0,0 +1,146 @@
+package useCase
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/isaias-dgr/todo/src/domain"
+)
+
+const (
+	maxSyncPage  = 1000
+	maxSyncBatch = 500
+)
+
+type syncUseCase struct {
+	changes domain.SyncRepository
+	tasks   domain.TaskUseCase
+	policy  string
+}
+
+// NewSyncUseCase applies pushed changes through tasks, so they are
+// validated, counted against the quota and broadcast like any other.
+func NewSyncUseCase(s domain.SyncRepository, t domain.TaskUseCase, policy string) domain.SyncUseCase {
+	return &syncUseCase{
+		changes: s,
+		tasks:   t,
+		policy:  policy,
+	}
+}
+
+func (u *syncUseCase) Pull(ctx context.Context, since string, limit int) (*domain.ChangeSet, error) {
+	seq, err := domain.ParseSyncToken(since)
+	if err != nil {
+		return nil, err
+	}
+	return u.pull(ctx, seq, limit)
+}
+
+// Push answers with the changes after since, the pushed ones included, so
+// the client gets what other devices did in the same round trip.
+func (u *syncUseCase) Push(ctx context.Context, p *domain.SyncPush) (*domain.SyncReply, error) {
+	seq, err := domain.ParseSyncToken(p.Since)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.Changes) > maxSyncBatch {
+		return nil, domain.ErrSyncBatch
+	}
+	results := make([]*domain.SyncResult, 0, len(p.Changes))
+	for _, change := range p.Changes {
+		results = append(results, u.apply(ctx, seq, change))
+	}
+	set, err := u.pull(ctx, seq, maxSyncPage)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.SyncReply{Results: results, ChangeSet: set}, nil
+}
+
+// pull hands out the seq of the last change read as the token. The
+// repository numbers changes in commit order, so no change can commit
+// later below it.
+func (u *syncUseCase) pull(ctx context.Context, seq int64, limit int) (*domain.ChangeSet, error) {
+	if limit <= 0 || limit > maxSyncPage {
+		limit = maxSyncPage
+	}
+	changes, err := u.changes.Changes(ctx, domain.UserFromContext(ctx), seq, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	hasMore := len(changes) > limit
+	if hasMore {
+		changes = changes[:limit]
+	}
+	if len(changes) > 0 {
+		seq = changes[len(changes)-1].Seq
+	}
+	return &domain.ChangeSet{
+		Changes: latest(changes),
+		Token:   domain.NewSyncToken(seq),
+		HasMore: hasMore,
+	}, nil
+}
+
+// apply takes the change unless the server changed the task after since
+// and the policy keeps the server side. Checking and applying are not
+// atomic, so a change racing another one for the same task may win.
+func (u *syncUseCase) apply(ctx context.Context, since int64, c *domain.ClientChange) *domain.SyncResult {
+	result := &domain.SyncResult{TaskID: c.TaskID}
+	reject := func(err error) *domain.SyncResult {
+		result.Status = domain.SyncRejected
+		result.Error = err.Error()
+		return result
+	}
+	if c.Op != domain.SyncUpsert && c.Op != domain.SyncDelete {
+		return reject(domain.ErrSyncOp)
+	}
+	id, err := domain.ParseTaskID(c.TaskID)
+	if err != nil {
+		return reject(err)
+	}
+	last, err := u.changes.LastChange(ctx, id)
+	if err != nil {
+		return reject(err)
+	}
+	if last != nil && last.Seq > since && !u.clientWins(c, last) {
+		result.Status = domain.SyncConflict
+		result.Server = last
+		return result
+	}
+
+	switch {
+	case c.Op == domain.SyncUpsert:
+		_, err = u.tasks.Upsert(ctx, id.String(), domain.NewTask(c.Title, c.Description))
+	case last != nil && last.Op != domain.SyncDelete:
+		err = u.tasks.Delete(ctx, id.String())
+	}
+	if err != nil {
+		return reject(err)
+	}
+	result.Status = domain.SyncApplied
+	return result
+}
+
+// clientWins settles a conflict. A change without ChangedAt loses.
+func (u *syncUseCase) clientWins(c *domain.ClientChange, server *domain.Change) bool {
+	if u.policy != domain.LastWriterWins || c.ChangedAt == nil || server.ChangedAt == nil {
+		return false
+	}
+	return c.ChangedAt.After(*server.ChangedAt)
+}
+
+// latest keeps the last change of each task, in sequence order.
+func latest(changes []*domain.Change) []*domain.Change {
+	last := make(map[uuid.UUID]int64, len(changes))
+	for _, change := range changes {
+		last[change.TaskID] = change.Seq
+	}
+	kept := make([]*domain.Change, 0, len(last))
+	for _, change := range changes {
+		if last[change.TaskID] == change.Seq {
+			kept = append(kept, change)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,147 @@
+package useCase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/domain/mocks"
+	useCase "github.com/isaias-dgr/todo/src/task/usecase"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type SyncUseCaseSuite struct {
+	suite.Suite
+	repo  *mocks.SyncRepository
+	tasks *mocks.TaskUseCase
+}
+
+func (s *SyncUseCaseSuite) SetupTest() {
+	s.repo = new(mocks.SyncRepository)
+	s.tasks = new(mocks.TaskUseCase)
+}
+
+func change(seq int64, op string, id uuid.UUID, at time.Time) *domain.Change {
+	c := &domain.Change{Seq: seq, Op: op, TaskID: id, ChangedAt: &at}
+	if op == domain.SyncUpsert {
+		c.Task = &domain.Task{ID: id, Title: "server", Description: "server"}
+	}
+	return c
+}
+
+func (s *SyncUseCaseSuite) TestPull() {
+	cu := useCase.NewSyncUseCase(s.repo, s.tasks, domain.LastWriterWins)
+	a, b := uuid.New(), uuid.New()
+	now := time.Now()
+	s.repo.On("Changes", mock.Anything, "user-1", int64(7), 4).Return([]*domain.Change{
+		change(8, domain.SyncUpsert, a, now),
+		change(9, domain.SyncUpsert, b, now),
+		change(10, domain.SyncDelete, a, now),
+		change(11, domain.SyncUpsert, b, now),
+	}, nil)
+	s.repo.On("Changes", mock.Anything, "user-1", int64(11), 4).Return([]*domain.Change{}, nil)
+
+	ctx := domain.WithUser(context.TODO(), "user-1")
+	set, err := cu.Pull(ctx, "7", 3)
+	s.NoError(err)
+	s.True(set.HasMore)
+	s.Equal("10", set.Token)
+	s.Require().Len(set.Changes, 2)
+	s.Equal(b, set.Changes[0].TaskID)
+	s.Equal(domain.SyncDelete, set.Changes[1].Op)
+
+	set, err = cu.Pull(ctx, "11", 3)
+	s.NoError(err)
+	s.False(set.HasMore)
+	s.Equal("11", set.Token)
+	s.Empty(set.Changes)
+
+	_, err = cu.Pull(context.TODO(), "yesterday", 3)
+	s.Equal(domain.ErrSyncToken, err)
+}
+
+func (s *SyncUseCaseSuite) TestPush() {
+	fresh, edited, gone, contested := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	serverAt := time.Now()
+	before, after := serverAt.Add(-time.Minute), serverAt.Add(time.Minute)
+	s.repo.On("LastChange", mock.Anything, fresh).Return(nil, nil)
+	s.repo.On("LastChange", mock.Anything, edited).Return(change(3, domain.SyncUpsert, edited, serverAt), nil)
+	s.repo.On("LastChange", mock.Anything, gone).Return(change(4, domain.SyncDelete, gone, serverAt), nil)
+	s.repo.On("LastChange", mock.Anything, contested).Return(change(9, domain.SyncUpsert, contested, serverAt), nil)
+	s.repo.On("Changes", mock.Anything, mock.Anything, int64(5), 1001).Return([]*domain.Change{
+		change(9, domain.SyncUpsert, contested, serverAt),
+	}, nil)
+	s.tasks.On("Upsert", mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+	s.tasks.On("Delete", mock.Anything, edited.String()).Return(nil)
+
+	push := &domain.SyncPush{Since: "5", Changes: []*domain.ClientChange{
+		{Op: domain.SyncUpsert, TaskID: fresh.String(), Title: "t", Description: "d"},
+		{Op: domain.SyncDelete, TaskID: edited.String()},
+		{Op: domain.SyncDelete, TaskID: gone.String()},
+		{Op: domain.SyncUpsert, TaskID: contested.String(), Title: "t", Description: "d", ChangedAt: &before},
+		{Op: domain.SyncUpsert, TaskID: contested.String(), Title: "t", Description: "d", ChangedAt: &after},
+		{Op: "merge", TaskID: fresh.String()},
+		{Op: domain.SyncUpsert, TaskID: "1"},
+	}}
+
+	s.Run("With last writer wins", func() {
+		reply, err := useCase.NewSyncUseCase(s.repo, s.tasks, domain.LastWriterWins).Push(context.TODO(), push)
+		s.NoError(err)
+		s.Equal("9", reply.Token)
+		s.Len(reply.Changes, 1)
+		statuses := []string{}
+		for _, r := range reply.Results {
+			statuses = append(statuses, r.Status)
+		}
+		s.Equal([]string{
+			domain.SyncApplied, domain.SyncApplied, domain.SyncApplied,
+			domain.SyncConflict, domain.SyncApplied,
+			domain.SyncRejected, domain.SyncRejected,
+		}, statuses)
+		s.Equal(int64(9), reply.Results[3].Server.Seq)
+		s.Equal("sync_op", reply.Results[5].Error)
+		s.Equal("uuid_format", reply.Results[6].Error)
+		s.tasks.AssertNumberOfCalls(s.T(), "Delete", 1)
+	})
+
+	s.Run("With server wins", func() {
+		reply, err := useCase.NewSyncUseCase(s.repo, s.tasks, domain.ServerWins).Push(context.TODO(), push)
+		s.NoError(err)
+		s.Equal(domain.SyncConflict, reply.Results[3].Status)
+		s.Equal(domain.SyncConflict, reply.Results[4].Status)
+	})
+}
+
+func (s *SyncUseCaseSuite) TestPushErrors() {
+	cu := useCase.NewSyncUseCase(s.repo, s.tasks, domain.LastWriterWins)
+	id := uuid.New()
+	s.repo.On("LastChange", mock.Anything, id).Return(nil, nil)
+	s.tasks.On("Upsert", mock.Anything, id.String(), mock.Anything).Return(false, domain.ErrQuotaExceeded)
+	s.repo.On("Changes", mock.Anything, mock.Anything, int64(0), 1001).Return(nil, errors.New("query_context")).Once()
+
+	_, err := cu.Push(context.TODO(), &domain.SyncPush{Since: "-1"})
+	s.Equal(domain.ErrSyncToken, err)
+	_, err = cu.Push(context.TODO(), &domain.SyncPush{Changes: make([]*domain.ClientChange, 501)})
+	s.Equal(domain.ErrSyncBatch, err)
+	_, err = cu.Push(context.TODO(), &domain.SyncPush{Changes: []*domain.ClientChange{
+		{Op: domain.SyncUpsert, TaskID: id.String(), Title: "t", Description: "d"},
+	}})
+	s.EqualError(err, "query_context")
+
+	s.repo.On("Changes", mock.Anything, mock.Anything, int64(0), 1001).Return([]*domain.Change{}, nil)
+	reply, err := cu.Push(context.TODO(), &domain.SyncPush{Changes: []*domain.ClientChange{
+		{Op: domain.SyncUpsert, TaskID: id.String(), Title: "t", Description: "d"},
+	}})
+	s.NoError(err)
+	s.Equal("0", reply.Token)
+	s.Equal(domain.SyncRejected, reply.Results[0].Status)
+	s.Equal("quota_exceeded", reply.Results[0].Error)
+}
+
+func TestSyncUseCaseSuite(t *testing.T) {
+	suite.Run(t, new(SyncUseCaseSuite))
+}
@@ -2,17 +2,27 @@ package useCase
 
 import (
 	"context"
+	"errors"
 
+	"github.com/google/uuid"
 	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/logging"
 )
 
 type taskUseCase struct {
-	repo domain.TaskRepository
+	repo        domain.TaskRepository
+	attachments domain.AttachmentUseCase
+	broker      domain.Broker
+	maxTasks    int
 }
 
-func NewTaskUseCase(t domain.TaskRepository) domain.TaskUseCase {
+// NewTaskUseCase limits each user to maxTasks tasks; zero means no limit.
+func NewTaskUseCase(t domain.TaskRepository, a domain.AttachmentUseCase, b domain.Broker, maxTasks int) domain.TaskUseCase {
 	return &taskUseCase{
-		repo: t,
+		repo:        t,
+		attachments: a,
+		broker:      b,
+		maxTasks:    maxTasks,
 	}
 }
 
@@ -25,13 +35,113 @@ func (t *taskUseCase) GetByID(ctx context.Context, uuid string) (ta *domain.Task
 }
 
 func (t *taskUseCase) Update(ctx context.Context, uuid string, ta *domain.Task) (err error) {
-	return t.repo.Update(ctx, uuid, ta)
+	if err := ta.Validate(); err != nil {
+		return err
+	}
+	if err := t.repo.Update(ctx, uuid, ta); err != nil {
+		return err
+	}
+	t.notify(ctx, domain.TaskUpdated, ta)
+	return nil
 }
 
 func (t *taskUseCase) Insert(ctx context.Context, ta *domain.Task) (err error) {
-	return t.repo.Insert(ctx, ta)
+	if err := ta.Validate(); err != nil {
+		return err
+	}
+	if err := t.checkQuota(ctx); err != nil {
+		return err
+	}
+	if err := t.repo.Insert(ctx, ta); err != nil {
+		return err
+	}
+	t.notify(ctx, domain.TaskCreated, ta)
+	return nil
+}
+
+// Upsert only checks the quota of the user when it is reached, to tell a
+// new task apart from one being replaced.
+func (t *taskUseCase) Upsert(ctx context.Context, id string, ta *domain.Task) (created bool, err error) {
+	if _, err := domain.ParseTaskID(id); err != nil {
+		return false, err
+	}
+	if err := ta.Validate(); err != nil {
+		return false, err
+	}
+	if err := t.checkQuota(ctx); err != nil {
+		if !errors.Is(err, domain.ErrQuotaExceeded) {
+			return false, err
+		}
+		if _, getErr := t.repo.GetByID(ctx, id); getErr != nil {
+			return false, err
+		}
+	}
+	created, err = t.repo.Upsert(ctx, id, ta)
+	if err != nil {
+		return false, err
+	}
+	if created {
+		t.notify(ctx, domain.TaskCreated, ta)
+	} else {
+		t.notify(ctx, domain.TaskUpdated, ta)
+	}
+	return created, nil
+}
+
+// Delete removes the attachments first so a failed cleanup leaves the task
+// in place to retry instead of orphaning files in storage.
+func (t *taskUseCase) Delete(ctx context.Context, id string) (err error) {
+	if err := t.attachments.DeleteByTask(ctx, id); err != nil {
+		logging.FromContext(ctx, nil).Warnw("Attachments not deleted", "task_id", id, "error", err.Error())
+		return err
+	}
+	if err := t.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	raw_uuid, _ := uuid.Parse(id)
+	t.notify(ctx, domain.TaskDeleted, &domain.Task{ID: raw_uuid})
+	return nil
+}
+
+// Complete only broadcasts the first completion of a task.
+func (t *taskUseCase) Complete(ctx context.Context, id string) (*domain.Task, error) {
+	ta, completed, err := t.repo.Complete(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if completed {
+		t.notify(ctx, domain.TaskCompleted, ta)
+	}
+	return ta, nil
 }
 
-func (t *taskUseCase) Delete(ctx context.Context, uuid string) (err error) {
-	return t.repo.Delete(ctx, uuid)
+// checkQuota counts the tasks of the user before inserting, so concurrent
+// inserts of the same user can go over by a few. Anonymous callers have
+// no quota.
+func (t *taskUseCase) checkQuota(ctx context.Context) error {
+	userID := domain.UserFromContext(ctx)
+	if t.maxTasks <= 0 || userID == "" {
+		return nil
+	}
+	total, err := t.repo.CountByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if total >= t.maxTasks {
+		logging.FromContext(ctx, nil).Infow("Task quota reached", "user", userID, "tasks", total)
+		return domain.ErrQuotaExceeded
+	}
+	return nil
+}
+
+// notify feeds the live subscribers of this process. Durable delivery to
+// other services goes through the outbox written by the repository.
+func (t *taskUseCase) notify(ctx context.Context, eventType string, ta *domain.Task) {
+	event, err := domain.NewEvent(eventType, ta)
+	if err != nil {
+		logging.FromContext(ctx, nil).Errorw("Event not broadcast", "type", eventType, "error", err.Error())
+		return
+	}
+	event.UserID = domain.UserFromContext(ctx)
+	t.broker.Publish(event)
 }
@@ -2,11 +2,14 @@ package useCase_test
 
 import (
 	"context"
+	"errors"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/isaias-dgr/todo/src/domain"
 	"github.com/isaias-dgr/todo/src/domain/mocks"
+	"github.com/isaias-dgr/todo/src/task/repository/memory"
 	useCase "github.com/isaias-dgr/todo/src/task/usecase"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -15,13 +18,18 @@ import (
 
 type UseCaseSuite struct {
 	suite.Suite
-	repo *mocks.TaskRepository
-	cu   domain.TaskUseCase
+	repo        *mocks.TaskRepository
+	attachments *mocks.AttachmentUseCase
+	broker      *mocks.Broker
+	cu          domain.TaskUseCase
 }
 
 func (s *UseCaseSuite) SetupTest() {
 	s.repo = new(mocks.TaskRepository)
-	s.cu = useCase.NewTaskUseCase(s.repo)
+	s.attachments = new(mocks.AttachmentUseCase)
+	s.broker = new(mocks.Broker)
+	s.broker.On("Publish", mock.Anything)
+	s.cu = useCase.NewTaskUseCase(s.repo, s.attachments, s.broker, 2)
 }
 
 func (s *UseCaseSuite) TestFetch() {
@@ -47,26 +55,174 @@ func (s *UseCaseSuite) TestGetByID() {
 func (s *UseCaseSuite) TestUpdate() {
 	s.repo.On("Update", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	ctx := context.Background()
-	task := domain.Task{}
-	err := s.cu.Update(ctx, "000-0000", &task)
+	task := domain.NewTask("title", "description")
+	err := s.cu.Update(ctx, "000-0000", task)
 	assert.Nil(s.T(), err, "The get mock its not working")
 }
 
 func (s *UseCaseSuite) TestInsert() {
 	s.repo.On("Insert", mock.Anything, mock.Anything).Return(nil)
 	ctx := context.Background()
-	task := domain.Task{}
-	err := s.cu.Insert(ctx, &task)
+	task := domain.NewTask("title", "description")
+	err := s.cu.Insert(ctx, task)
 	assert.Nil(s.T(), err, "The get mock its not working")
 }
 
+func (s *UseCaseSuite) TestInsertQuota() {
+	s.repo.On("CountByUser", mock.Anything, "full").Return(2, nil)
+	s.repo.On("CountByUser", mock.Anything, "roomy").Return(1, nil)
+	s.repo.On("Insert", mock.Anything, mock.Anything).Return(nil)
+
+	err := s.cu.Insert(domain.WithUser(context.Background(), "full"), domain.NewTask("title", "description"))
+	s.Equal(domain.ErrQuotaExceeded, err)
+	s.NoError(s.cu.Insert(domain.WithUser(context.Background(), "roomy"), domain.NewTask("title", "description")))
+	s.NoError(s.cu.Insert(context.Background(), domain.NewTask("title", "description")))
+	s.repo.AssertNumberOfCalls(s.T(), "CountByUser", 2)
+	s.repo.AssertNumberOfCalls(s.T(), "Insert", 2)
+}
+
+func (s *UseCaseSuite) TestUpsert() {
+	newID := "6f1c1b0e-3a4d-4c8e-9b7a-2d5e8f0a1b2c"
+	oldID := "0b8e7f6a-5d4c-4b3a-8f2e-1d0c9b8a7f6e"
+	s.repo.On("CountByUser", mock.Anything, "full").Return(2, nil)
+	s.repo.On("GetByID", mock.Anything, newID).Return(nil, errors.New("not_found"))
+	s.repo.On("GetByID", mock.Anything, oldID).Return(domain.NewTask("title", "description"), nil)
+	s.repo.On("Upsert", mock.Anything, newID, mock.Anything).Return(true, nil)
+	s.repo.On("Upsert", mock.Anything, oldID, mock.Anything).Return(false, nil)
+
+	created, err := s.cu.Upsert(context.Background(), newID, domain.NewTask("title", "description"))
+	s.NoError(err)
+	s.True(created)
+	s.broker.AssertCalled(s.T(), "Publish", mock.MatchedBy(func(e *domain.Event) bool {
+		return e.Type == domain.TaskCreated
+	}))
+
+	ctx := domain.WithUser(context.Background(), "full")
+	_, err = s.cu.Upsert(ctx, newID, domain.NewTask("title", "description"))
+	s.Equal(domain.ErrQuotaExceeded, err)
+	created, err = s.cu.Upsert(ctx, oldID, domain.NewTask("title", "description"))
+	s.NoError(err)
+	s.False(created)
+	s.broker.AssertCalled(s.T(), "Publish", mock.MatchedBy(func(e *domain.Event) bool {
+		return e.Type == domain.TaskUpdated
+	}))
+	s.repo.AssertNumberOfCalls(s.T(), "Upsert", 2)
+}
+
+func (s *UseCaseSuite) TestUpsertChecksTheID() {
+	_, err := s.cu.Upsert(context.Background(), "000-0000", domain.NewTask("title", "description"))
+	s.Equal(domain.ErrUUIDFormat, err)
+	_, err = s.cu.Upsert(context.Background(), "6f1c1b0e-3a4d-1c8e-9b7a-2d5e8f0a1b2c", domain.NewTask("title", "description"))
+	s.Equal(domain.ErrUUIDVersion, err)
+	s.repo.AssertNotCalled(s.T(), "Upsert", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *UseCaseSuite) TestInvalidTaskIsNotStored() {
+	ctx := context.Background()
+	err := s.cu.Insert(ctx, &domain.Task{})
+	s.True(errors.Is(err, domain.ErrValidation))
+	err = s.cu.Update(ctx, "000-0000", &domain.Task{})
+	s.True(errors.Is(err, domain.ErrValidation))
+	s.repo.AssertNotCalled(s.T(), "Insert", mock.Anything, mock.Anything)
+	s.repo.AssertNotCalled(s.T(), "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
 func (s *UseCaseSuite) TestDelete() {
+	s.attachments.On("DeleteByTask", mock.Anything, "000-0000").Return(nil)
 	s.repo.On("Delete", mock.Anything, mock.Anything).Return(nil, nil)
 	ctx := context.Background()
 	err := s.cu.Delete(ctx, "000-0000")
 	assert.Nil(s.T(), err, "The get mock its not working")
+	s.attachments.AssertCalled(s.T(), "DeleteByTask", mock.Anything, "000-0000")
+}
+
+func (s *UseCaseSuite) TestDeleteKeepsTaskWhenCleanupFails() {
+	s.attachments.On("DeleteByTask", mock.Anything, "000-0000").Return(errors.New("storage_delete"))
+	ctx := context.Background()
+	err := s.cu.Delete(ctx, "000-0000")
+	assert.Error(s.T(), err)
+	s.repo.AssertNotCalled(s.T(), "Delete", mock.Anything, mock.Anything)
+	s.broker.AssertNotCalled(s.T(), "Publish", mock.Anything)
+}
+
+func (s *UseCaseSuite) TestInsertNotifiesBroker() {
+	s.repo.On("CountByUser", mock.Anything, "user-1").Return(0, nil)
+	s.repo.On("Insert", mock.Anything, mock.Anything).Return(nil)
+	ctx := domain.WithUser(context.Background(), "user-1")
+	task := domain.NewTask("title", "description")
+	err := s.cu.Insert(ctx, task)
+	s.NoError(err)
+	s.broker.AssertCalled(s.T(), "Publish", mock.MatchedBy(func(e *domain.Event) bool {
+		return e.Type == domain.TaskCreated && e.UserID == "user-1"
+	}))
+}
+
+func (s *UseCaseSuite) TestFailedUpdateDoesNotNotify() {
+	s.repo.On("Update", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("not_found"))
+	err := s.cu.Update(context.Background(), "000-0000", domain.NewTask("title", "description"))
+	s.Error(err)
+	s.broker.AssertNotCalled(s.T(), "Publish", mock.Anything)
+}
+
+func (s *UseCaseSuite) TestCompleteNotifiesOnce() {
+	task := domain.NewTask("title", "description")
+	s.repo.On("Complete", mock.Anything, "000-0000").Return(task, true, nil).Once()
+	s.repo.On("Complete", mock.Anything, "000-0000").Return(task, false, nil).Once()
+	ctx := domain.WithUser(context.Background(), "user-1")
+
+	got, err := s.cu.Complete(ctx, "000-0000")
+	s.NoError(err)
+	s.Equal(task, got)
+	_, err = s.cu.Complete(ctx, "000-0000")
+	s.NoError(err)
+	s.broker.AssertNumberOfCalls(s.T(), "Publish", 1)
+	s.broker.AssertCalled(s.T(), "Publish", mock.MatchedBy(func(e *domain.Event) bool {
+		return e.Type == domain.TaskCompleted && e.UserID == "user-1"
+	}))
 }
 
 func TestUseCaseSuite(t *testing.T) {
 	suite.Run(t, new(UseCaseSuite))
 }
+
+// MemoryUseCaseSuite uses the in-memory repository instead of the mocks.
+type MemoryUseCaseSuite struct {
+	suite.Suite
+	broker *mocks.Broker
+	cu     domain.TaskUseCase
+}
+
+func (s *MemoryUseCaseSuite) SetupTest() {
+	attachments := new(mocks.AttachmentUseCase)
+	attachments.On("DeleteByTask", mock.Anything, mock.Anything).Return(nil)
+	s.broker = new(mocks.Broker)
+	s.broker.On("Publish", mock.Anything)
+	repo := memory.NewTaskRepository(memory.NewDB(time.Now))
+	s.cu = useCase.NewTaskUseCase(repo, attachments, s.broker, 2)
+}
+
+func (s *MemoryUseCaseSuite) TestQuotaCountsStoredTasks() {
+	ctx := domain.WithUser(context.Background(), "user-1")
+	first := domain.NewTask("first", "description")
+	s.NoError(s.cu.Insert(ctx, first))
+	s.NoError(s.cu.Insert(ctx, domain.NewTask("second", "description")))
+	s.Equal(domain.ErrQuotaExceeded, s.cu.Insert(ctx, domain.NewTask("third", "description")))
+
+	created, err := s.cu.Upsert(ctx, first.ID.String(), domain.NewTask("first again", "description"))
+	s.NoError(err, "replacing a task does not need room")
+	s.False(created)
+
+	s.NoError(s.cu.Delete(ctx, first.ID.String()))
+	s.NoError(s.cu.Insert(ctx, domain.NewTask("third", "description")))
+	s.broker.AssertNumberOfCalls(s.T(), "Publish", 5)
+}
+
+func (s *MemoryUseCaseSuite) TestUpdateOfAMissingTask() {
+	err := s.cu.Update(context.Background(), "6f1c1b0e-3a4d-4c8e-9b7a-2d5e8f0a1b2c", domain.NewTask("title", "description"))
+	s.EqualError(err, "conflict_update")
+	s.broker.AssertNotCalled(s.T(), "Publish", mock.Anything)
+}
+
+func TestMemoryUseCaseSuite(t *testing.T) {
+	suite.Run(t, new(MemoryUseCaseSuite))
+}
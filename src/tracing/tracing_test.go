@@ -0,0 +1,131 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/isaias-dgr/todo/src/domain"
+	"github.com/isaias-dgr/todo/src/domain/mocks"
+	"github.com/isaias-dgr/todo/src/tracing"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+type SuiteTracing struct {
+	suite.Suite
+	recorder *tracetest.SpanRecorder
+}
+
+func (s *SuiteTracing) SetupTest() {
+	_, err := tracing.Setup(context.TODO(), tracing.Config{Exporter: "none"})
+	s.Require().NoError(err)
+	s.recorder = tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(s.recorder)))
+}
+
+func (s *SuiteTracing) TearDownTest() {
+	otel.SetTracerProvider(trace.NewNoopTracerProvider())
+}
+
+func (s *SuiteTracing) TestMiddleware() {
+	r := mux.NewRouter()
+	r.Use(tracing.Middleware)
+	var inside trace.SpanContext
+	r.HandleFunc("/task/{task_id}/", func(w http.ResponseWriter, r *http.Request) {
+		inside = trace.SpanContextFromContext(r.Context())
+		w.WriteHeader(http.StatusInternalServerError)
+	}).Methods("GET")
+
+	s.Run("When the caller sends a traceparent", func() {
+		req := httptest.NewRequest(http.MethodGet, "/task/1/", nil)
+		req.Header.Set("traceparent", traceparent)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		s.Require().Len(s.recorder.Ended(), 1)
+		span := s.recorder.Ended()[0]
+		s.Equal("GET /task/{task_id}/", span.Name())
+		s.Equal(trace.SpanKindServer, span.SpanKind())
+		s.Equal("4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+		s.Equal("00f067aa0ba902b7", span.Parent().SpanID().String())
+		s.Equal(span.SpanContext().SpanID(), inside.SpanID())
+		s.Equal(codes.Error, span.Status().Code)
+		s.Contains(span.Attributes(), semconv.HTTPStatusCodeKey.Int(500))
+	})
+
+	s.Run("When the request starts the trace", func() {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/task/2/", nil))
+		span := s.recorder.Ended()[1]
+		s.False(span.Parent().IsValid())
+		s.True(span.SpanContext().IsValid())
+	})
+}
+
+func (s *SuiteTracing) TestLogger() {
+	core, logs := observer.New(zap.InfoLevel)
+	l := zap.New(core).Sugar()
+
+	tracing.Logger(context.TODO(), l).Info("no trace")
+	ctx, span := tracing.Start(context.TODO(), "work")
+	tracing.Logger(ctx, l).Info("traced")
+	span.End()
+
+	entries := logs.AllUntimed()
+	s.Require().Len(entries, 2)
+	s.Empty(entries[0].ContextMap())
+	fields := entries[1].ContextMap()
+	s.Equal(span.SpanContext().TraceID().String(), fields["trace_id"])
+	s.Equal(span.SpanContext().SpanID().String(), fields["span_id"])
+}
+
+func (s *SuiteTracing) TestTaskUseCase() {
+	next := new(mocks.TaskUseCase)
+	next.On("GetByID", mock.Anything, "1").Return(nil, errors.New("not_found"))
+	next.On("Delete", mock.MatchedBy(func(ctx context.Context) bool {
+		return trace.SpanContextFromContext(ctx).IsValid()
+	}), "2").Return(nil)
+	uc := tracing.NewTaskUseCase(next)
+
+	_, err := uc.GetByID(context.TODO(), "1")
+	s.Error(err)
+	s.NoError(uc.Delete(context.TODO(), "2"))
+
+	spans := s.recorder.Ended()
+	s.Require().Len(spans, 2)
+	s.Equal("taskUseCase.GetByID", spans[0].Name())
+	s.Equal(codes.Error, spans[0].Status().Code)
+	s.Equal("not_found", spans[0].Status().Description)
+	s.Equal("taskUseCase.Delete", spans[1].Name())
+	s.Equal(codes.Unset, spans[1].Status().Code)
+	next.AssertExpectations(s.T())
+}
+
+func (s *SuiteTracing) TestTaskUseCaseRecordsNewID() {
+	next := new(mocks.TaskUseCase)
+	id := uuid.New()
+	next.On("Insert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(*domain.Task).ID = id
+	}).Return(nil)
+
+	s.NoError(tracing.NewTaskUseCase(next).Insert(context.TODO(), domain.NewTask("title", "description")))
+	s.Contains(s.recorder.Ended()[0].Attributes(), attribute.String("task.id", id.String()))
+}
+
+func TestSuiteTracing(t *testing.T) {
+	suite.Run(t, new(SuiteTracing))
+}
@@ -0,0 +1,70 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/isaias-dgr/todo/src/domain"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var taskID = attribute.Key("task.id")
+
+type taskUseCase struct {
+	next domain.TaskUseCase
+}
+
+// NewTaskUseCase wraps every call to next in a span.
+func NewTaskUseCase(next domain.TaskUseCase) domain.TaskUseCase {
+	return &taskUseCase{next: next}
+}
+
+func (t *taskUseCase) Fetch(ctx context.Context, f *domain.Filter) (ts *domain.Tasks, err error) {
+	ctx, span := Start(ctx, "taskUseCase.Fetch", trace.WithAttributes(
+		attribute.Int("filter.limit", f.Limit),
+		attribute.Int("filter.offset", f.Offset)))
+	defer func() { End(span, err) }()
+	return t.next.Fetch(ctx, f)
+}
+
+func (t *taskUseCase) Insert(ctx context.Context, ta *domain.Task) (err error) {
+	ctx, span := Start(ctx, "taskUseCase.Insert")
+	defer func() {
+		span.SetAttributes(taskID.String(ta.ID.String()))
+		End(span, err)
+	}()
+	return t.next.Insert(ctx, ta)
+}
+
+func (t *taskUseCase) Update(ctx context.Context, uuid string, ta *domain.Task) (err error) {
+	ctx, span := Start(ctx, "taskUseCase.Update", trace.WithAttributes(taskID.String(uuid)))
+	defer func() { End(span, err) }()
+	return t.next.Update(ctx, uuid, ta)
+}
+
+func (t *taskUseCase) Upsert(ctx context.Context, uuid string, ta *domain.Task) (created bool, err error) {
+	ctx, span := Start(ctx, "taskUseCase.Upsert", trace.WithAttributes(taskID.String(uuid)))
+	defer func() {
+		span.SetAttributes(attribute.Bool("task.created", created))
+		End(span, err)
+	}()
+	return t.next.Upsert(ctx, uuid, ta)
+}
+
+func (t *taskUseCase) GetByID(ctx context.Context, uuid string) (ta *domain.Task, err error) {
+	ctx, span := Start(ctx, "taskUseCase.GetByID", trace.WithAttributes(taskID.String(uuid)))
+	defer func() { End(span, err) }()
+	return t.next.GetByID(ctx, uuid)
+}
+
+func (t *taskUseCase) Delete(ctx context.Context, uuid string) (err error) {
+	ctx, span := Start(ctx, "taskUseCase.Delete", trace.WithAttributes(taskID.String(uuid)))
+	defer func() { End(span, err) }()
+	return t.next.Delete(ctx, uuid)
+}
+
+func (t *taskUseCase) Complete(ctx context.Context, uuid string) (ta *domain.Task, err error) {
+	ctx, span := Start(ctx, "taskUseCase.Complete", trace.WithAttributes(taskID.String(uuid)))
+	defer func() { End(span, err) }()
+	return t.next.Complete(ctx, uuid)
+}